@@ -0,0 +1,238 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/sender"
+)
+
+const anonymizedEmailDomain = "deleted.invalid"
+
+type DeleteUserOpts struct {
+	// Anonymize keeps the user id as a tombstone and scrubs the personal data
+	// instead of removing the user
+	Anonymize bool
+	// Password is required to re-authenticate the user unless Token is set
+	Password string
+	// Token is the one sent by RequestUserDeletion, it re-authenticates the
+	// user in place of the password, for the users without one
+	Token string
+	// Code is the totp code, required if the user has the second factor enabled
+	Code string
+}
+
+// anonymizedEmail builds a unique placeholder so that the email column
+// constraints are kept on anonymized users
+func anonymizedEmail(userID uuid.UUID) string {
+	return userID.String() + "@" + anonymizedEmailDomain
+}
+
+// DeleteUser removes the user and everything associated with it, the user
+// has to re-authenticate so that a stolen access token isn't enough
+func (auth Auth) DeleteUser(ctx context.Context, userID uuid.UUID, opts DeleteUserOpts) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionDeleteUser, userID, err) }()
+
+	if auth.userStorage == nil || auth.tokenStorage == nil {
+		return ErrStorageRequired
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = auth.reauthenticateDeletion(ctx, user, opts)
+	if err != nil {
+		return err
+	}
+
+	if user.IsTOTPEnabled {
+		err = auth.verifyTOTPCode(ctx, user, opts.Code)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = auth.tokenStorage.RemoveUserTokens(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if auth.recoveryCodeStorage != nil {
+		err = auth.recoveryCodeStorage.RemoveUserRecoveryCodes(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth.passkeyStorage != nil {
+		err = auth.passkeyStorage.RemoveUserPasskeys(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth.phoneCodeStorage != nil {
+		err = auth.phoneCodeStorage.RemovePhoneCode(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth.sessionStorage != nil {
+		err = auth.sessionStorage.RemoveUserSessions(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth.signInAttemptStorage != nil {
+		err = auth.signInAttemptStorage.RemoveSignInAttempt(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth.organizationStorage != nil {
+		err = auth.organizationStorage.RemoveUserOrganizationMembers(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth.apiKeyStorage != nil {
+		err = auth.apiKeyStorage.RemoveUserAPIKeys(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth.oauthStorage != nil {
+		err = auth.oauthStorage.RemoveUserOAuthConsents(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth.identityStorage != nil {
+		err = auth.identityStorage.RemoveUserIdentities(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth.roleStorage != nil {
+		err = auth.roleStorage.RemoveUserRoles(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth.invitationStorage != nil {
+		err = auth.invitationStorage.RemovePendingUserInvitations(ctx, user.ID, user.Email)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Anonymize {
+		err = auth.userStorage.AnonymizeUser(ctx, user.ID, anonymizedEmail(user.ID))
+	} else {
+		err = auth.userStorage.DeleteUser(ctx, user.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the user data was fetched before the removal so the confirmation can
+	// still reach the user
+	data := mapUsersToNotificationData(auth.baseURL, []entity.AuthUser{user}, nil)
+	errs := sender.SendBulk(auth.senders, sender.TemplateUserDeleted, data)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// reauthenticateDeletion checks the token of RequestUserDeletion when set
+// and the password of the user otherwise
+func (auth Auth) reauthenticateDeletion(
+	ctx context.Context,
+	user entity.AuthUser,
+	opts DeleteUserOpts,
+) error {
+	if len(opts.Token) == 0 {
+		if ok := comparePassword(user.Password, opts.Password); !ok {
+			return ErrWrongCredentials
+		}
+
+		return nil
+	}
+
+	ok, err := auth.tokenStorage.AreTokensRegistered(ctx, []string{opts.Token})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTokenNotRegistered
+	}
+
+	userID, err := auth.validateTokenOfKind(ctx, entity.TokenKindUserDeletion, opts.Token)
+	if err != nil {
+		return err
+	}
+
+	if userID != user.ID {
+		return ErrWrongUser
+	}
+
+	return nil
+}
+
+// RequestUserDeletion sends an email with a one time token that confirms the
+// deletion of the user in DeleteUser, for the users signing in without a
+// password
+func (auth Auth) RequestUserDeletion(ctx context.Context, userID uuid.UUID) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRequestUserDeletion, userID, err) }()
+
+	if auth.userStorage == nil || auth.tokenStorage == nil {
+		return ErrStorageRequired
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	token, err := auth.newKindToken(entity.TokenKindUserDeletion, user.ID, tokenClaims{})
+	if err != nil {
+		return err
+	}
+
+	// only the last requested token is kept
+	err = auth.tokenStorage.RemoveUserTokensByKind(ctx, user.ID, entity.TokenKindUserDeletion)
+	if err != nil {
+		return err
+	}
+
+	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{token})
+	if err != nil {
+		return err
+	}
+
+	data := mapUsersToNotificationData(
+		auth.baseURL,
+		[]entity.AuthUser{user},
+		map[string]string{"code": token.Value},
+	)
+	errs := sender.SendBulk(auth.senders, sender.TemplateUserDeletion, data)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,146 @@
+package goauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var deleteUserTests = []struct {
+	description    string
+	inOpts         DeleteUserOpts
+	inPasswordless bool
+	inRequestToken bool
+	inUsedTOTP     bool
+	expectError    bool
+}{
+	{"hard delete", DeleteUserOpts{Password: "12345678"}, false, false, false, false},
+	{"anonymize", DeleteUserOpts{Password: "12345678", Anonymize: true}, false, false, false, false},
+	{"wrong password", DeleteUserOpts{Password: "87654321"}, false, false, false, true},
+	{"passwordless without token", DeleteUserOpts{}, true, false, false, true},
+	{"passwordless with token", DeleteUserOpts{}, true, true, false, false},
+	{"wrong token", DeleteUserOpts{Token: "wrong"}, false, false, false, true},
+	{"used totp code", DeleteUserOpts{Password: "12345678"}, false, false, true, true},
+}
+
+func TestDeleteUser(t *testing.T) {
+	for _, testCase := range deleteUserTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			userID := uuid.New()
+			password := encryptPassword("12345678")
+			if testCase.inPasswordless {
+				password = ""
+			}
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			roleStore := inmem.NewRoles(
+				[]entity.Role{{Name: "editor"}},
+				[]entity.UserRole{{UserID: userID, Role: "editor"}},
+			)
+			invitationStore := inmem.NewInvitations([]entity.Invitation{
+				{ID: uuid.New(), InviterID: userID, Email: "new@bar.com", Status: entity.InvitationStatusPending},
+			})
+			passkeyStore := inmem.NewPasskeys([]entity.Passkey{
+				{ID: []byte("credential"), UserID: userID},
+			})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: uuid.New(), Email: "nofoo@bar.com", Password: encryptPassword("4321")},
+				{
+					ID:          userID,
+					Email:       "foo@bar.com",
+					PhoneNumber: "+351910000000",
+					Password:    password,
+					Meta:        map[string]string{"firstName": "Foo"},
+				},
+			})
+			notifications := &senderRecorder{}
+			auth := New(
+				AuthSecrets{
+					TokenAccess:       "1234",
+					TokenRefresh:      "2345",
+					TokenUserDeletion: "3456",
+					Encryption:        "4567",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithPasskeyStorage(passkeyStore),
+				WithRoleStorage(roleStore),
+				WithInvitationStorage(invitationStore),
+				WithSender(notifications),
+			)
+
+			ctx := context.Background()
+			_, _ = auth.SignIn(ctx, "foo@bar.com", "12345678")
+
+			// the code of the sign in can't be used again
+			if testCase.inUsedTOTP {
+				secret, _ := newTOTPSecret()
+				encrypted, _ := encryptSecret(auth.secrets.Encryption, secret)
+				_ = userStore.UpdateUserTOTP(ctx, userID, encrypted, true)
+				_ = userStore.UpdateUserTOTPLastStep(ctx, userID, time.Now().Unix()/int64(totpPeriod.Seconds()))
+				testCase.inOpts.Code, _ = generateTOTPCode(secret, time.Now())
+			}
+
+			if testCase.inRequestToken {
+				err := auth.RequestUserDeletion(ctx, userID)
+				if err != nil || len(notifications.sent) != 1 {
+					t.Fatalf("expected: the deletion token to be sent and got %v", err)
+				}
+				testCase.inOpts.Token = notifications.sent[0]["code"]
+				notifications.sent = nil
+			}
+
+			err := auth.DeleteUser(ctx, userID, testCase.inOpts)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error and got %v", err)
+			}
+
+			if testCase.expectError {
+				t.Fatal("expected: error")
+			}
+
+			user, err := userStore.GetUserByID(context.Background(), userID)
+			if testCase.inOpts.Anonymize {
+				if err != nil {
+					t.Fatalf("expected: user tombstone to be kept and got %v", err)
+				}
+
+				if user.Email == "foo@bar.com" || len(user.PhoneNumber) > 0 || len(user.Meta) > 0 {
+					t.Fatalf("expected: user data to be scrubbed and got %v", user)
+				}
+			} else if err == nil {
+				t.Fatal("expected: user to be removed")
+			}
+
+			tokens, _ := tokenStore.GetUserTokens(context.Background(), userID)
+			if len(tokens) > 0 {
+				t.Fatal("expected: user tokens to be removed")
+			}
+
+			passkeys, _ := passkeyStore.GetUserPasskeys(context.Background(), userID)
+			if len(passkeys) > 0 {
+				t.Fatal("expected: user passkeys to be removed")
+			}
+
+			roles, _ := roleStore.GetUserRoles(context.Background(), userID)
+			if len(roles) > 0 {
+				t.Fatal("expected: user roles to be removed")
+			}
+
+			invitations, _ := invitationStore.GetAll(context.Background())
+			if len(invitations) > 0 {
+				t.Fatal("expected: user pending invitations to be removed")
+			}
+
+			if len(notifications.sent) != 1 || notifications.sent[0]["email"] != "foo@bar.com" {
+				t.Fatal("expected: confirmation to be sent to the user")
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package goauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+var (
+	ErrAPIKeyInvalid  = errors.New("api key invalid")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+const (
+	apiKeyPrefix      = "gak"
+	apiKeyLookupBytes = 6
+	apiKeySecretBytes = 32
+)
+
+// newAPIKey generates the key as gak_<lookup prefix>_<secret>
+func newAPIKey() (string, string, error) {
+	lookup := make([]byte, apiKeyLookupBytes)
+	if _, err := rand.Read(lookup); err != nil {
+		return "", "", err
+	}
+
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix := hex.EncodeToString(lookup)
+	key := strings.Join([]string{
+		apiKeyPrefix,
+		prefix,
+		base64.RawURLEncoding.EncodeToString(secret),
+	}, "_")
+
+	return key, prefix, nil
+}
+
+// parseAPIKey returns the lookup prefix of the key
+func parseAPIKey(key string) (string, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || len(parts[1]) != apiKeyLookupBytes*2 {
+		return "", ErrAPIKeyInvalid
+	}
+
+	return parts[1], nil
+}
+
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// CreateAPIKey creates a key for machine access, the returned key is the only
+// time it is available since only its hash is kept. A zero expiresAt never
+// expires
+func (auth Auth) CreateAPIKey(
+	ctx context.Context,
+	userID uuid.UUID,
+	name string,
+	scopes []string,
+	expiresAt time.Time,
+) (_ string, _ entity.APIKey, err error) {
+	defer func() { err = auth.audit(ctx, AuditActionCreateAPIKey, userID, err) }()
+
+	if auth.apiKeyStorage == nil {
+		return "", entity.APIKey{}, ErrStorageRequired
+	}
+
+	key, prefix, err := newAPIKey()
+	if err != nil {
+		return "", entity.APIKey{}, err
+	}
+
+	apiKey := entity.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hashAPIKey(key),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	err = auth.apiKeyStorage.CreateAPIKey(ctx, apiKey)
+	if err != nil {
+		return "", entity.APIKey{}, err
+	}
+
+	return key, apiKey, nil
+}
+
+// ListAPIKeys returns the keys of the user
+func (auth Auth) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]entity.APIKey, error) {
+	if auth.apiKeyStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	return auth.apiKeyStorage.GetUserAPIKeys(ctx, userID)
+}
+
+// RevokeAPIKey removes the key of the user
+func (auth Auth) RevokeAPIKey(ctx context.Context, userID uuid.UUID, keyID uuid.UUID) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRevokeAPIKey, userID, err) }()
+
+	if auth.apiKeyStorage == nil {
+		return ErrStorageRequired
+	}
+
+	keys, err := auth.apiKeyStorage.GetUserAPIKeys(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if key.ID == keyID {
+			return auth.apiKeyStorage.RemoveAPIKey(ctx, userID, keyID)
+		}
+	}
+
+	return ErrAPIKeyNotFound
+}
+
+// ValidateAPIKey resolves the key and tracks its usage
+func (auth Auth) ValidateAPIKey(ctx context.Context, key string) (entity.APIKey, error) {
+	if auth.apiKeyStorage == nil {
+		return entity.APIKey{}, ErrStorageRequired
+	}
+
+	prefix, err := parseAPIKey(key)
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	apiKey, err := auth.apiKeyStorage.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		return entity.APIKey{}, ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey.Hash), []byte(hashAPIKey(key))) != 1 {
+		return entity.APIKey{}, ErrAPIKeyInvalid
+	}
+
+	now := time.Now()
+	if !apiKey.ExpiresAt.IsZero() && now.After(apiKey.ExpiresAt) {
+		return entity.APIKey{}, ErrExpirationTime
+	}
+
+	apiKey.LastUsedAt = now
+	err = auth.apiKeyStorage.TouchAPIKey(ctx, apiKey.ID, now)
+
+	return apiKey, err
+}
+
+// withAPIKeyContext sets the owner of the key on the context, the roles and
+// permissions are the ones of the user within the scopes of the key, all of
+// them when the key has none
+func (auth Auth) withAPIKeyContext(ctx context.Context, key string) (context.Context, error) {
+	apiKey, err := auth.ValidateAPIKey(ctx, key)
+	if err != nil {
+		return ctx, err
+	}
+
+	claims, err := auth.getAccessClaims(ctx, apiKey.UserID, uuid.Nil)
+	if err != nil {
+		return ctx, err
+	}
+
+	roles := claims.Roles
+	permissions := claims.Permissions
+	if len(apiKey.Scopes) > 0 {
+		roles = []string{}
+		for _, role := range claims.Roles {
+			if hasAny(apiKey.Scopes, []string{role}) {
+				roles = append(roles, role)
+			}
+		}
+
+		permissions = []string{}
+		for _, permission := range claims.Permissions {
+			if hasAny(apiKey.Scopes, []string{permission}) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	ctx = context.WithValue(ctx, UserIDKey, apiKey.UserID.String())
+	ctx = context.WithValue(ctx, rolesKey, roles)
+	ctx = context.WithValue(ctx, permissionsKey, permissions)
+	ctx = context.WithValue(ctx, apiKeyScopesKey, apiKey.Scopes)
+
+	return ctx, nil
+}
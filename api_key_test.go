@@ -0,0 +1,123 @@
+package goauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var apiKeysTests = []struct {
+	description  string
+	inHeader     string
+	inScopes     []string
+	inExpiresIn  time.Duration
+	inRevoke     bool
+	inTamper     bool
+	inPermission string
+	inRole       string
+	expectStatus int
+}{
+	{"authorization header", "", nil, 0, false, false, "", "", http.StatusOK},
+	{"custom header", "X-API-Key", nil, 0, false, false, "", "", http.StatusOK},
+	{"permission in scope", "", []string{"users:read"}, 0, false, false, "users:read", "", http.StatusOK},
+	{"permission out of scope", "", []string{"users:read"}, 0, false, false, "users:write", "", http.StatusForbidden},
+	{"role in scope", "", []string{"admin"}, 0, false, false, "", "admin", http.StatusOK},
+	{"role out of scope", "", []string{"users:read"}, 0, false, false, "", "admin", http.StatusForbidden},
+	{"expired", "", nil, -time.Minute, false, false, "", "", http.StatusUnauthorized},
+	{"revoked", "", nil, 0, true, false, "", "", http.StatusUnauthorized},
+	{"wrong key", "", nil, 0, false, true, "", "", http.StatusUnauthorized},
+}
+
+func TestAPIKeys(t *testing.T) {
+	for _, testCase := range apiKeysTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			userID := uuid.New()
+			keyStore := inmem.NewAPIKeys([]entity.APIKey{})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:  "1234",
+					TokenRefresh: "2345",
+				},
+				WithTokenStorage(inmem.NewTokens([]entity.Token{})),
+				WithRoleStorage(inmem.NewRoles(
+					[]entity.Role{{Name: "admin", Permissions: []string{"users:read", "users:write"}}},
+					[]entity.UserRole{{UserID: userID, Role: "admin"}},
+				)),
+				WithAPIKeyStorage(keyStore),
+				WithAPIKeyHeader(testCase.inHeader),
+			)
+
+			ctx := context.Background()
+			expiresAt := time.Time{}
+			if testCase.inExpiresIn != 0 {
+				expiresAt = time.Now().Add(testCase.inExpiresIn)
+			}
+
+			key, apiKey, err := auth.CreateAPIKey(ctx, userID, "ci", testCase.inScopes, expiresAt)
+			if err != nil {
+				t.Fatalf("expected: non error on create and got %v", err)
+			}
+
+			keys, _ := keyStore.GetAll(ctx)
+			if len(keys) != 1 || keys[0].Hash == key {
+				t.Fatalf("expected: only the hash of the key to be stored and got %v", keys)
+			}
+
+			if testCase.inRevoke {
+				err = auth.RevokeAPIKey(ctx, userID, apiKey.ID)
+				if err != nil {
+					t.Fatalf("expected: non error on revoke and got %v", err)
+				}
+			}
+
+			if testCase.inTamper {
+				key = key[:len(key)-1] + "x"
+			}
+
+			var ctxUserID *uuid.UUID
+			next := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxUserID = GetContextUserID(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+			if len(testCase.inPermission) > 0 {
+				next = RequirePermission(ErrorHandler, testCase.inPermission)(next)
+			}
+			if len(testCase.inRole) > 0 {
+				next = RequireRoles(ErrorHandler, testCase.inRole)(next)
+			}
+			handler := auth.WithAuthUserID(true, ErrorHandler)(next)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if len(testCase.inHeader) > 0 {
+				r.Header.Set(testCase.inHeader, key)
+			} else {
+				r.Header.Set("Authorization", "ApiKey "+key)
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != testCase.expectStatus {
+				t.Fatalf("expected: %d and got %d", testCase.expectStatus, w.Code)
+			}
+
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			if ctxUserID == nil || *ctxUserID != userID {
+				t.Fatalf("expected: %v on the context and got %v", userID, ctxUserID)
+			}
+
+			keys, _ = auth.ListAPIKeys(ctx, userID)
+			if len(keys) != 1 || keys[0].LastUsedAt.IsZero() {
+				t.Fatalf("expected: the usage to be tracked and got %v", keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package goauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+)
+
+var ErrAuditLogTampered = errors.New("audit log tampered")
+
+type AuditAction string
+
+const (
+	AuditActionSignIn                   AuditAction = "sign_in"
+	AuditActionSignOut                  AuditAction = "sign_out"
+	AuditActionSignUp                   AuditAction = "sign_up"
+	AuditActionResendVerification       AuditAction = "resend_verification"
+	AuditActionSignUpVerify             AuditAction = "sign_up_verify"
+	AuditActionRequestResetPassword     AuditAction = "request_reset_password"
+	AuditActionResetPassword            AuditAction = "reset_password"
+	AuditActionChangePassword           AuditAction = "change_password"
+	AuditActionRefreshToken             AuditAction = "refresh_token"
+	AuditActionEnrollTOTP               AuditAction = "enroll_totp"
+	AuditActionConfirmTOTP              AuditAction = "confirm_totp"
+	AuditActionDisableTOTP              AuditAction = "disable_totp"
+	AuditActionSignInVerifyMFA          AuditAction = "sign_in_verify_mfa"
+	AuditActionSignInRecoveryCode       AuditAction = "sign_in_recovery_code"
+	AuditActionRegenerateRecoveryCodes  AuditAction = "regenerate_recovery_codes"
+	AuditActionRegisterPasskey          AuditAction = "register_passkey"
+	AuditActionSignInPasskey            AuditAction = "sign_in_passkey"
+	AuditActionRequestMagicLink         AuditAction = "request_magic_link"
+	AuditActionSignInMagicLink          AuditAction = "sign_in_magic_link"
+	AuditActionRequestPhoneCode         AuditAction = "request_phone_code"
+	AuditActionSignInPhoneCode          AuditAction = "sign_in_phone_code"
+	AuditActionSignInProvider           AuditAction = "sign_in_provider"
+	AuditActionDeleteUser               AuditAction = "delete_user"
+	AuditActionRequestUserDeletion      AuditAction = "request_user_deletion"
+	AuditActionRevokeSession            AuditAction = "revoke_session"
+	AuditActionRevokeOtherSessions      AuditAction = "revoke_other_sessions"
+	AuditActionCreateAPIKey             AuditAction = "create_api_key"
+	AuditActionRevokeAPIKey             AuditAction = "revoke_api_key"
+	AuditActionRegisterOAuthClient      AuditAction = "register_oauth_client"
+	AuditActionRemoveOAuthClient        AuditAction = "remove_oauth_client"
+	AuditActionGrantOAuthConsent        AuditAction = "grant_oauth_consent"
+	AuditActionRevokeOAuthConsent       AuditAction = "revoke_oauth_consent"
+	AuditActionSaveRole                 AuditAction = "save_role"
+	AuditActionRemoveRole               AuditAction = "remove_role"
+	AuditActionAssignRole               AuditAction = "assign_role"
+	AuditActionUnassignRole             AuditAction = "unassign_role"
+	AuditActionCreateOrganization       AuditAction = "create_organization"
+	AuditActionAddOrganizationMember    AuditAction = "add_organization_member"
+	AuditActionRemoveOrganizationMember AuditAction = "remove_organization_member"
+	AuditActionSwitchOrganization       AuditAction = "switch_organization"
+	AuditActionInviteUser               AuditAction = "invite_user"
+	AuditActionAcceptInvite             AuditAction = "accept_invite"
+	AuditActionRevokeInvitation         AuditAction = "revoke_invitation"
+)
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// auditPageSize is the amount of records read at a time by VerifyAuditLog
+const auditPageSize = 100
+
+// hashAuditRecord chains the record to the previous one, any change to the
+// fields or to the order of the records changes the hashes that follow
+func hashAuditRecord(record entity.AuditRecord) string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{
+		record.PrevHash,
+		record.Action,
+		record.ActorID.String(),
+		record.UserID.String(),
+		record.IP,
+		record.UserAgent,
+		record.Outcome,
+		record.Error,
+		record.CreatedAt.UTC().Format(time.RFC3339),
+	}, "\n")))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// audit appends the outcome of the action to the audit log, the actor is the
+// user of the request (set by WithAuthUserID). The error of the action is
+// returned joined with the one of the storage, if any
+func (auth Auth) audit(
+	ctx context.Context,
+	action AuditAction,
+	userID uuid.UUID,
+	actionErr error,
+) error {
+	if auth.auditStorage == nil {
+		return actionErr
+	}
+
+	record := entity.AuditRecord{
+		Action:    string(action),
+		UserID:    userID,
+		IP:        GetContextClientIP(ctx),
+		UserAgent: GetContextUserAgent(ctx),
+		Outcome:   AuditOutcomeSuccess,
+		// the storages keep the seconds, the hash has to match once read
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	if actorID := GetContextUserID(ctx); actorID != nil {
+		record.ActorID = *actorID
+	}
+
+	if actionErr != nil {
+		record.Outcome = AuditOutcomeFailure
+		record.Error = actionErr.Error()
+	}
+
+	// the chain needs the appends to be sequential
+	auth.auditMu.Lock()
+	defer auth.auditMu.Unlock()
+
+	err := auth.auditStorage.AppendAuditRecord(ctx, record, hashAuditRecord)
+	if err != nil {
+		return errors.Join(actionErr, err)
+	}
+
+	return actionErr
+}
+
+// ListAuditRecords returns the audit log, oldest first, per the filter
+func (auth Auth) ListAuditRecords(
+	ctx context.Context,
+	filter entity.AuditFilter,
+) ([]entity.AuditRecord, error) {
+	if auth.auditStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	return auth.auditStorage.ListAuditRecords(ctx, filter)
+}
+
+// VerifyAuditLog walks the whole audit log and errors with
+// ErrAuditLogTampered on the first record that breaks the chain
+func (auth Auth) VerifyAuditLog(ctx context.Context) error {
+	if auth.auditStorage == nil {
+		return ErrStorageRequired
+	}
+
+	prevHash := ""
+	for offset := 0; ; offset += auditPageSize {
+		records, err := auth.auditStorage.ListAuditRecords(ctx, entity.AuditFilter{
+			Limit:  auditPageSize,
+			Offset: offset,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if record.PrevHash != prevHash || hashAuditRecord(record) != record.Hash {
+				return fmt.Errorf("%w: record %d", ErrAuditLogTampered, record.ID)
+			}
+
+			prevHash = record.Hash
+		}
+
+		if len(records) < auditPageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var auditTests = []struct {
+	description   string
+	inPassword    string
+	inTamper      bool
+	expectOutcome string
+	expectError   error
+}{
+	{"sign in", "1234", false, AuditOutcomeSuccess, nil},
+	{"wrong password", "4321", false, AuditOutcomeFailure, nil},
+	{"tampered", "1234", true, AuditOutcomeSuccess, ErrAuditLogTampered},
+}
+
+func TestAudit(t *testing.T) {
+	for _, testCase := range auditTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			userID := uuid.New()
+			auditStore := inmem.NewAuditRecords([]entity.AuditRecord{})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:  "1234",
+					TokenRefresh: "2345",
+				},
+				WithTokenStorage(inmem.NewTokens([]entity.Token{})),
+				WithUserStorage(inmem.NewUsers([]entity.AuthUser{
+					{ID: userID, Email: "foo@bar.com", Password: encryptPassword("1234"), IsVerified: true},
+				})),
+				WithAuditStorage(auditStore),
+			)
+
+			ctx := context.WithValue(context.Background(), ClientIPKey, "10.0.0.1")
+			ctx = context.WithValue(ctx, ClientUserAgentKey, "laptop")
+			_, _ = auth.SignIn(ctx, "foo@bar.com", testCase.inPassword)
+			_ = auth.SignOut(ctx, userID)
+			_, _ = auth.SignIn(ctx, "nofoo@bar.com", "1234")
+
+			records, err := auth.ListAuditRecords(ctx, entity.AuditFilter{
+				UserID: userID,
+				Action: string(AuditActionSignIn),
+				From:   time.Now().Add(-time.Minute),
+			})
+			if err != nil {
+				t.Fatalf("expected: non error on list and got %v", err)
+			}
+			if len(records) != 1 {
+				t.Fatalf("expected: 1 sign in of the user and got %v", records)
+			}
+
+			record := records[0]
+			if record.Outcome != testCase.expectOutcome {
+				t.Fatalf("expected: %v and got %v", testCase.expectOutcome, record.Outcome)
+			}
+			if record.IP != "10.0.0.1" || record.UserAgent != "laptop" {
+				t.Fatalf("expected: request metadata on the record and got %v", record)
+			}
+			if (len(record.Error) > 0) != (testCase.expectOutcome == AuditOutcomeFailure) {
+				t.Fatalf("expected: error only on failures and got %v", record.Error)
+			}
+
+			// the unknown user is recorded on the second page
+			records, _ = auth.ListAuditRecords(ctx, entity.AuditFilter{Limit: 2, Offset: 2})
+			if len(records) != 1 || records[0].UserID != uuid.Nil {
+				t.Fatalf("expected: the failed sign in of the unknown user and got %v", records)
+			}
+
+			if testCase.inTamper {
+				all, _ := auditStore.GetAll(context.Background())
+				all[1].UserAgent = "phone"
+			}
+
+			err = auth.VerifyAuditLog(ctx)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("expected: %v and got %v", testCase.expectError, err)
+			}
+		})
+	}
+}
+
+var auditActionsTests = []struct {
+	description   string
+	inAction      func(ctx context.Context, auth Auth, userID uuid.UUID) error
+	expectAction  AuditAction
+	expectOutcome string
+}{
+	{
+		"enroll totp",
+		func(ctx context.Context, auth Auth, userID uuid.UUID) error {
+			_, err := auth.EnrollTOTP(ctx, userID)
+			return err
+		},
+		AuditActionEnrollTOTP,
+		AuditOutcomeSuccess,
+	},
+	{
+		"request magic link",
+		func(ctx context.Context, auth Auth, userID uuid.UUID) error {
+			return auth.RequestMagicLink(ctx, "foo@bar.com")
+		},
+		AuditActionRequestMagicLink,
+		AuditOutcomeSuccess,
+	},
+	{
+		"create api key",
+		func(ctx context.Context, auth Auth, userID uuid.UUID) error {
+			_, _, err := auth.CreateAPIKey(ctx, userID, "ci", nil, time.Time{})
+			return err
+		},
+		AuditActionCreateAPIKey,
+		AuditOutcomeSuccess,
+	},
+	{
+		"revoke unknown api key",
+		func(ctx context.Context, auth Auth, userID uuid.UUID) error {
+			return auth.RevokeAPIKey(ctx, userID, uuid.New())
+		},
+		AuditActionRevokeAPIKey,
+		AuditOutcomeFailure,
+	},
+	{
+		"revoke unknown session",
+		func(ctx context.Context, auth Auth, userID uuid.UUID) error {
+			return auth.RevokeSession(ctx, userID, uuid.New())
+		},
+		AuditActionRevokeSession,
+		AuditOutcomeFailure,
+	},
+	{
+		"assign unknown role",
+		func(ctx context.Context, auth Auth, userID uuid.UUID) error {
+			return auth.AssignRole(ctx, userID, "unknown")
+		},
+		AuditActionAssignRole,
+		AuditOutcomeFailure,
+	},
+	{
+		"create organization",
+		func(ctx context.Context, auth Auth, userID uuid.UUID) error {
+			_, err := auth.CreateOrganization(ctx, "acme", userID)
+			return err
+		},
+		AuditActionCreateOrganization,
+		AuditOutcomeSuccess,
+	},
+	{
+		"invite registered user",
+		func(ctx context.Context, auth Auth, userID uuid.UUID) error {
+			_, err := auth.InviteUser(ctx, userID, "foo@bar.com", nil)
+			return err
+		},
+		AuditActionInviteUser,
+		AuditOutcomeFailure,
+	},
+	{
+		"grant oauth consent to unknown client",
+		func(ctx context.Context, auth Auth, userID uuid.UUID) error {
+			return auth.GrantOAuthConsent(ctx, userID, uuid.New(), []string{"users:read"})
+		},
+		AuditActionGrantOAuthConsent,
+		AuditOutcomeFailure,
+	},
+	{
+		"delete user",
+		func(ctx context.Context, auth Auth, userID uuid.UUID) error {
+			return auth.DeleteUser(ctx, userID, DeleteUserOpts{Password: "1234"})
+		},
+		AuditActionDeleteUser,
+		AuditOutcomeSuccess,
+	},
+}
+
+func TestAuditActions(t *testing.T) {
+	for _, testCase := range auditActionsTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			userID := uuid.New()
+			auth := New(
+				AuthSecrets{
+					TokenAccess:    "1234",
+					TokenRefresh:   "2345",
+					TokenMagicLink: "3456",
+					Encryption:     "4567",
+				},
+				WithTokenStorage(inmem.NewTokens([]entity.Token{})),
+				WithUserStorage(inmem.NewUsers([]entity.AuthUser{
+					{ID: userID, Email: "foo@bar.com", Password: encryptPassword("1234"), IsVerified: true},
+				})),
+				WithAPIKeyStorage(inmem.NewAPIKeys([]entity.APIKey{})),
+				WithOAuthStorage(inmem.NewOAuth([]entity.OAuthClient{}, []entity.OAuthConsent{})),
+				WithRoleStorage(inmem.NewRoles([]entity.Role{}, []entity.UserRole{})),
+				WithOrganizationStorage(inmem.NewOrganizations([]entity.Organization{}, []entity.OrganizationMember{})),
+				WithInvitationStorage(inmem.NewInvitations([]entity.Invitation{})),
+				WithAuditStorage(inmem.NewAuditRecords([]entity.AuditRecord{})),
+			)
+
+			ctx := context.Background()
+			_ = testCase.inAction(ctx, *auth, userID)
+
+			records, err := auth.ListAuditRecords(ctx, entity.AuditFilter{
+				UserID: userID,
+				Action: string(testCase.expectAction),
+			})
+			if err != nil {
+				t.Fatalf("expected: non error on list and got %v", err)
+			}
+
+			if len(records) != 1 || records[0].Outcome != testCase.expectOutcome {
+				t.Fatalf("expected: 1 record with %v and got %v", testCase.expectOutcome, records)
+			}
+		})
+	}
+}
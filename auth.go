@@ -2,10 +2,13 @@ package goauth
 
 import (
 	"context"
+	"crypto"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/providers"
 	"github.com/iamajoe/goauth/sender"
 )
 
@@ -14,6 +17,23 @@ type AuthSecrets struct {
 	TokenRefresh       string
 	TokenVerify        string
 	TokenResetPassword string
+	TokenMFAChallenge  string
+	TokenPasskey       string
+	TokenMagicLink     string
+	TokenEmailChange   string
+	TokenEmailRevert   string
+	TokenAccountUnlock string
+	TokenInvitation    string
+	// TokenAuthorizationCode signs the codes of the oauth authorization server
+	TokenAuthorizationCode string
+	// TokenUserDeletion signs the confirmations of RequestUserDeletion
+	TokenUserDeletion string
+	// Encryption is used to encrypt values that need to be read back,
+	// for example the totp secrets
+	Encryption string
+	// Deprecated are the secrets of each kind still accepted while they are
+	// rotated out, newest first, the tokens are signed with the ones above
+	Deprecated map[entity.TokenKind][]string
 }
 
 type AuthTokenExpirationTimes struct {
@@ -21,6 +41,18 @@ type AuthTokenExpirationTimes struct {
 	Refresh       time.Duration
 	Verify        time.Duration
 	ResetPassword time.Duration
+	MFAChallenge  time.Duration
+	Passkey       time.Duration
+	MagicLink     time.Duration
+	PhoneCode     time.Duration
+	EmailChange   time.Duration
+	EmailRevert   time.Duration
+	AccountUnlock time.Duration
+	Invitation    time.Duration
+	// AuthorizationCode is how long the oauth clients have to exchange the
+	// code for the tokens
+	AuthorizationCode time.Duration
+	UserDeletion      time.Duration
 }
 
 // TODO: custom client methods
@@ -29,27 +61,180 @@ type Auth struct {
 	secrets              AuthSecrets
 	tokenExpirationTimes AuthTokenExpirationTimes
 
-	tokenStorage tokenStorage
-	userStorage  userStorage
-	senders      []sender.Sender
+	tokenStorage         tokenStorage
+	userStorage          userStorage
+	recoveryCodeStorage  recoveryCodeStorage
+	passkeyStorage       passkeyStorage
+	phoneCodeStorage     phoneCodeStorage
+	signInAttemptStorage signInAttemptStorage
+	sessionStorage       sessionStorage
+	auditStorage         auditStorage
+	roleStorage          roleStorage
+	organizationStorage  organizationStorage
+	invitationStorage    invitationStorage
+	apiKeyStorage        apiKeyStorage
+	identityStorage      identityStorage
+	oauthStorage         oauthStorage
+	keyProvider          KeyProvider
+	senders              []sender.Sender
+	providers            map[string]providers.Provider
+	rateLimiter          rateLimiter
 
-	autoVerifyUser bool
-	baseURL        string
+	eventSubscriptions []eventSubscription
+	// auditMu is shared by the copies of auth so that the appends of the
+	// process don't contend on the storage, which keeps the chain across them
+	auditMu *sync.Mutex
+
+	lockoutPolicy               LockoutPolicy
+	sendUnlockEmail             bool
+	requireVerifiedUser         bool
+	verificationCooldown        time.Duration
+	autoVerifyUser              bool
+	revokeSessionsOnEmailChange bool
+	tenantScopedEmails          bool
+	apiKeyHeader                string
+	baseURL                     string
+	serviceName                 string
+	tokenIssuer                 string
+	tokenAudience               string
+	legacyTokensUntil           time.Time
+	claimsEnricher              func(ctx context.Context, user entity.AuthUser) map[string]any
 }
 
 type tokenStorage interface {
 	CreateTokens(ctx context.Context, tokens []entity.Token) error
 	RemoveUserTokens(ctx context.Context, userID uuid.UUID) error
 	RemoveUserToken(ctx context.Context, userID uuid.UUID, token string) error
+	RemoveUserTokensByKind(ctx context.Context, userID uuid.UUID, kind entity.TokenKind) error
 	AreTokensRegistered(ctx context.Context, tokens []string) (bool, error)
+	GetUserTokens(ctx context.Context, userID uuid.UUID) ([]entity.Token, error)
+	GetToken(ctx context.Context, token string) (entity.Token, error)
+	RevokeToken(ctx context.Context, token string) error
+	RemoveTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RemoveClientTokens(ctx context.Context, clientID uuid.UUID) error
+	RemoveUserClientTokens(ctx context.Context, userID uuid.UUID, clientID uuid.UUID) error
 }
 
 type userStorage interface {
 	CreateUser(ctx context.Context, user entity.AuthUser) error
 	UpdateUserPassword(ctx context.Context, userID uuid.UUID, password string) error
+	UpdateUserEmail(ctx context.Context, userID uuid.UUID, email string) error
 	VerifyUser(ctx context.Context, userID uuid.UUID) error
+	UpdateUserTOTP(ctx context.Context, userID uuid.UUID, secret string, isEnabled bool) error
+	UpdateUserTOTPLastStep(ctx context.Context, userID uuid.UUID, step int64) error
 	GetUserByID(ctx context.Context, userID uuid.UUID) (entity.AuthUser, error)
 	GetUserByEmail(ctx context.Context, email string) (entity.AuthUser, error)
+	GetTenantUserByEmail(ctx context.Context, tenantID uuid.UUID, email string) (entity.AuthUser, error)
+	GetUserByPhone(ctx context.Context, phone string) (entity.AuthUser, error)
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+	AnonymizeUser(ctx context.Context, userID uuid.UUID, email string) error
+}
+
+type recoveryCodeStorage interface {
+	CreateRecoveryCodes(ctx context.Context, codes []entity.RecoveryCode) error
+	RemoveUserRecoveryCodes(ctx context.Context, userID uuid.UUID) error
+	RemoveUserRecoveryCode(ctx context.Context, userID uuid.UUID, code string) error
+	GetUserRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]entity.RecoveryCode, error)
+}
+
+type passkeyStorage interface {
+	CreatePasskey(ctx context.Context, passkey entity.Passkey) error
+	UpdatePasskeySignCount(ctx context.Context, credentialID []byte, signCount uint32) error
+	GetPasskey(ctx context.Context, credentialID []byte) (entity.Passkey, error)
+	GetUserPasskeys(ctx context.Context, userID uuid.UUID) ([]entity.Passkey, error)
+	RemoveUserPasskeys(ctx context.Context, userID uuid.UUID) error
+}
+
+type phoneCodeStorage interface {
+	CreatePhoneCode(ctx context.Context, code entity.PhoneCode) error
+	IncrementPhoneCodeAttempts(ctx context.Context, userID uuid.UUID) error
+	RemovePhoneCode(ctx context.Context, userID uuid.UUID) error
+	GetPhoneCode(ctx context.Context, userID uuid.UUID) (entity.PhoneCode, error)
+}
+
+type sessionStorage interface {
+	CreateSession(ctx context.Context, session entity.Session) error
+	TouchSession(ctx context.Context, sessionID uuid.UUID, ip string, lastSeenAt time.Time) error
+	RemoveSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error
+	RemoveUserSessions(ctx context.Context, userID uuid.UUID) error
+	GetUserSessions(ctx context.Context, userID uuid.UUID) ([]entity.Session, error)
+}
+
+type signInAttemptStorage interface {
+	SaveSignInAttempt(ctx context.Context, attempt entity.SignInAttempt) error
+	RemoveSignInAttempt(ctx context.Context, userID uuid.UUID) error
+	GetSignInAttempt(ctx context.Context, userID uuid.UUID) (entity.SignInAttempt, error)
+}
+
+type auditStorage interface {
+	// AppendAuditRecord reads the last record, sets its hash as the PrevHash
+	// of the record and the Hash with hash, then appends the record within
+	// the same transaction
+	AppendAuditRecord(ctx context.Context, record entity.AuditRecord, hash func(entity.AuditRecord) string) error
+	ListAuditRecords(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditRecord, error)
+}
+
+type roleStorage interface {
+	SaveRole(ctx context.Context, role entity.Role) error
+	RemoveRole(ctx context.Context, name string) error
+	GetRole(ctx context.Context, name string) (entity.Role, error)
+	AssignUserRole(ctx context.Context, userID uuid.UUID, role string) error
+	UnassignUserRole(ctx context.Context, userID uuid.UUID, role string) error
+	RemoveUserRoles(ctx context.Context, userID uuid.UUID) error
+	GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error)
+}
+
+type organizationStorage interface {
+	CreateOrganization(ctx context.Context, org entity.Organization) error
+	GetOrganization(ctx context.Context, orgID uuid.UUID) (entity.Organization, error)
+	SaveOrganizationMember(ctx context.Context, member entity.OrganizationMember) error
+	RemoveOrganizationMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error
+	RemoveUserOrganizationMembers(ctx context.Context, userID uuid.UUID) error
+	GetOrganizationMember(
+		ctx context.Context,
+		orgID uuid.UUID,
+		userID uuid.UUID,
+	) (entity.OrganizationMember, error)
+	GetOrganizationMembers(ctx context.Context, orgID uuid.UUID) ([]entity.OrganizationMember, error)
+	GetUserOrganizationMembers(
+		ctx context.Context,
+		userID uuid.UUID,
+	) ([]entity.OrganizationMember, error)
+}
+
+type invitationStorage interface {
+	CreateInvitation(ctx context.Context, invitation entity.Invitation) error
+	UpdateInvitationStatus(ctx context.Context, invitationID uuid.UUID, status string) error
+	GetInvitation(ctx context.Context, invitationID uuid.UUID) (entity.Invitation, error)
+	GetInvitations(ctx context.Context, orgID uuid.UUID) ([]entity.Invitation, error)
+	RemovePendingUserInvitations(ctx context.Context, inviterID uuid.UUID, email string) error
+}
+
+type apiKeyStorage interface {
+	CreateAPIKey(ctx context.Context, key entity.APIKey) error
+	TouchAPIKey(ctx context.Context, keyID uuid.UUID, lastUsedAt time.Time) error
+	RemoveAPIKey(ctx context.Context, userID uuid.UUID, keyID uuid.UUID) error
+	RemoveUserAPIKeys(ctx context.Context, userID uuid.UUID) error
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (entity.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID uuid.UUID) ([]entity.APIKey, error)
+}
+
+type identityStorage interface {
+	CreateIdentity(ctx context.Context, identity entity.UserIdentity) error
+	GetIdentity(ctx context.Context, provider string, subject string) (entity.UserIdentity, error)
+	GetUserIdentities(ctx context.Context, userID uuid.UUID) ([]entity.UserIdentity, error)
+	RemoveUserIdentities(ctx context.Context, userID uuid.UUID) error
+}
+
+type oauthStorage interface {
+	CreateOAuthClient(ctx context.Context, client entity.OAuthClient) error
+	RemoveOAuthClient(ctx context.Context, clientID uuid.UUID) error
+	GetOAuthClient(ctx context.Context, clientID uuid.UUID) (entity.OAuthClient, error)
+	SaveOAuthConsent(ctx context.Context, consent entity.OAuthConsent) error
+	RemoveOAuthConsent(ctx context.Context, userID uuid.UUID, clientID uuid.UUID) error
+	RemoveUserOAuthConsents(ctx context.Context, userID uuid.UUID) error
+	GetOAuthConsent(ctx context.Context, userID uuid.UUID, clientID uuid.UUID) (entity.OAuthConsent, error)
+	GetUserOAuthConsents(ctx context.Context, userID uuid.UUID) ([]entity.OAuthConsent, error)
 }
 
 type optFn func(*Auth) *Auth
@@ -62,11 +247,40 @@ func New(secrets AuthSecrets, opts ...optFn) *Auth {
 			Refresh:       7 * 24 * time.Hour,
 			Verify:        1 * 24 * time.Hour,
 			ResetPassword: 1 * 24 * time.Hour,
+			MFAChallenge:  5 * time.Minute,
+			Passkey:       5 * time.Minute,
+			MagicLink:     15 * time.Minute,
+			PhoneCode:     5 * time.Minute,
+			EmailChange:   1 * 24 * time.Hour,
+			EmailRevert:   7 * 24 * time.Hour,
+			AccountUnlock: 1 * 24 * time.Hour,
+			Invitation:    7 * 24 * time.Hour,
+			// kept short as the client exchanges it right away
+			AuthorizationCode: 1 * time.Minute,
+			UserDeletion:      1 * time.Hour,
 		},
-		baseURL: "http://localhost",
+		lockoutPolicy: LockoutPolicy{
+			MaxAttempts: 5,
+			Window:      15 * time.Minute,
+			Duration:    15 * time.Minute,
+			MaxDuration: 24 * time.Hour,
+		},
+		auditMu:              &sync.Mutex{},
+		requireVerifiedUser:  true,
+		verificationCooldown: 1 * time.Minute,
+		baseURL:              "http://localhost",
+		serviceName:          "goauth",
 	}
 
-	return auth.SetOpts(opts...)
+	auth = auth.SetOpts(opts...)
+
+	// the users signed in before the upgrade keep their session for as long
+	// as their refresh token would have lasted
+	if auth.legacyTokensUntil.IsZero() {
+		auth.legacyTokensUntil = time.Now().Add(auth.tokenExpirationTimes.Refresh)
+	}
+
+	return auth
 }
 
 // SetOpts gives a simple way upon creation to change some of the options
@@ -94,6 +308,190 @@ func WithTokenStorage(storage tokenStorage) optFn {
 	}
 }
 
+// WithRecoveryCodeStorage sets the storage to be used to register the
+// recovery codes of the second factor
+func WithRecoveryCodeStorage(storage recoveryCodeStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.recoveryCodeStorage = storage
+		return auth
+	}
+}
+
+// WithPasskeyStorage sets the storage to be used to register the webauthn
+// credentials, the relying party is resolved from the base url
+func WithPasskeyStorage(storage passkeyStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.passkeyStorage = storage
+		return auth
+	}
+}
+
+// WithPhoneCodeStorage sets the storage to be used to register the one time
+// codes sent by sms
+func WithPhoneCodeStorage(storage phoneCodeStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.phoneCodeStorage = storage
+		return auth
+	}
+}
+
+// WithSessionStorage sets the storage to be used to register the devices
+// signed in, each sign in is a session
+func WithSessionStorage(storage sessionStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.sessionStorage = storage
+		return auth
+	}
+}
+
+// WithAuditStorage sets the storage to be used to keep the audit log of the
+// client methods, successes and failures alike
+func WithAuditStorage(storage auditStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.auditStorage = storage
+		return auth
+	}
+}
+
+// WithRoleStorage sets the storage to be used to register the roles and their
+// assignments, the roles of the user are set on the access tokens
+func WithRoleStorage(storage roleStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.roleStorage = storage
+		return auth
+	}
+}
+
+// WithOrganizationStorage sets the storage to be used to register the
+// organizations and their members
+func WithOrganizationStorage(storage organizationStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.organizationStorage = storage
+		return auth
+	}
+}
+
+// WithInvitationStorage sets the storage to be used to register the
+// invitations to the app and to the organizations
+func WithInvitationStorage(storage invitationStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.invitationStorage = storage
+		return auth
+	}
+}
+
+// WithAPIKeyStorage sets the storage to be used to register the api keys,
+// WithAuthUserID accepts them once set
+func WithAPIKeyStorage(storage apiKeyStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.apiKeyStorage = storage
+		return auth
+	}
+}
+
+// WithAPIKeyHeader sets a header, for example X-API-Key, to be read for the
+// api keys besides the "Authorization: ApiKey <key>" one
+func WithAPIKeyHeader(header string) optFn {
+	return func(auth *Auth) *Auth {
+		auth.apiKeyHeader = header
+		return auth
+	}
+}
+
+// WithIdentityStorage sets the storage to be used to link the users to their
+// identities on the external providers
+func WithIdentityStorage(storage identityStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.identityStorage = storage
+		return auth
+	}
+}
+
+// WithProvider registers an external provider to sign in with, it is looked
+// up by its name
+func WithProvider(p providers.Provider) optFn {
+	return func(auth *Auth) *Auth {
+		if auth.providers == nil {
+			auth.providers = map[string]providers.Provider{}
+		}
+		auth.providers[p.Name()] = p
+		return auth
+	}
+}
+
+// WithOAuthStorage sets the storage to be used to register the oauth clients
+// and the consents of the users, the authorization server needs it
+func WithOAuthStorage(storage oauthStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.oauthStorage = storage
+		return auth
+	}
+}
+
+// WithSigningKey signs the access tokens with the key instead of the secret,
+// the kid is set on the tokens to match the key published on the jwks. See
+// SigningKey for the keys supported
+func WithSigningKey(kid string, key crypto.Signer) optFn {
+	return WithKeyProvider(NewKeyRing(SigningKey{ID: kid, Key: key, CreatedAt: time.Now()}))
+}
+
+// WithKeyProvider signs the access tokens with the keys of the provider, for
+// example a KeyRing with the keys rotated
+func WithKeyProvider(provider KeyProvider) optFn {
+	return func(auth *Auth) *Auth {
+		auth.keyProvider = provider
+		return auth
+	}
+}
+
+// WithSignInAttemptStorage sets the storage to be used to count the failed
+// sign ins, the accounts are locked per the lockout policy once set
+func WithSignInAttemptStorage(storage signInAttemptStorage) optFn {
+	return func(auth *Auth) *Auth {
+		auth.signInAttemptStorage = storage
+		return auth
+	}
+}
+
+// WithLockoutPolicy changes the default lockout policy
+func WithLockoutPolicy(policy LockoutPolicy) optFn {
+	return func(auth *Auth) *Auth {
+		auth.lockoutPolicy = policy
+		return auth
+	}
+}
+
+// WithUnlockEmail sends a notification with an unlock link once the
+// account is locked
+func WithUnlockEmail() optFn {
+	return func(auth *Auth) *Auth {
+		auth.sendUnlockEmail = true
+		return auth
+	}
+}
+
+// WithRateLimiter sets the limiter to be used on SignIn, SignUp and
+// RequestResetPassword, the keys are per action, identifier and ip
+func WithRateLimiter(limiter rateLimiter) optFn {
+	return func(auth *Auth) *Auth {
+		auth.rateLimiter = limiter
+		return auth
+	}
+}
+
+// WithEventHandler subscribes the handler to the events of kinds, all of
+// them if none is given, sync handlers can veto the action by erroring
+func WithEventHandler(handler EventHandler, delivery EventDelivery, kinds ...EventKind) optFn {
+	return func(auth *Auth) *Auth {
+		auth.eventSubscriptions = append(auth.eventSubscriptions, eventSubscription{
+			handler:  handler,
+			delivery: delivery,
+			kinds:    kinds,
+		})
+		return auth
+	}
+}
+
 // WithTokenExpirationTimes changes the default token expiration times
 func WithTokenExpirationTimes(times AuthTokenExpirationTimes) optFn {
 	return func(auth *Auth) *Auth {
@@ -120,6 +518,43 @@ func WithAutoVerifyUser() optFn {
 	}
 }
 
+// WithUnverifiedSignIn lets the users sign in before verifying the email,
+// by default SignIn returns ErrUserNotVerified until then
+func WithUnverifiedSignIn() optFn {
+	return func(auth *Auth) *Auth {
+		auth.requireVerifiedUser = false
+		return auth
+	}
+}
+
+// WithVerificationCooldown changes the time to wait between verifications
+// sent by ResendVerification and between the codes sent by RequestPhoneCode
+func WithVerificationCooldown(cooldown time.Duration) optFn {
+	return func(auth *Auth) *Auth {
+		auth.verificationCooldown = cooldown
+		return auth
+	}
+}
+
+// WithRevokeSessionsOnEmailChange signs the user out of every session once
+// the new email is confirmed
+func WithRevokeSessionsOnEmailChange() optFn {
+	return func(auth *Auth) *Auth {
+		auth.revokeSessionsOnEmailChange = true
+		return auth
+	}
+}
+
+// WithTenantScopedEmails makes the emails unique within the tenant set on
+// the context (TenantIDKey) instead of across every user, the same email
+// signs up on each tenant as a different user
+func WithTenantScopedEmails() optFn {
+	return func(auth *Auth) *Auth {
+		auth.tenantScopedEmails = true
+		return auth
+	}
+}
+
 // WithBaseURL sets the base url ot be used for example on the email links
 func WithBaseURL(baseURL string) optFn {
 	return func(auth *Auth) *Auth {
@@ -127,3 +562,50 @@ func WithBaseURL(baseURL string) optFn {
 		return auth
 	}
 }
+
+// WithServiceName sets the name of the service, for example to be shown
+// on the authenticator apps
+func WithServiceName(name string) optFn {
+	return func(auth *Auth) *Auth {
+		auth.serviceName = name
+		return auth
+	}
+}
+
+// WithTokenIssuer sets the issuer of the tokens and of the openid discovery,
+// the base url by default. The tokens of other issuers are refused
+func WithTokenIssuer(issuer string) optFn {
+	return func(auth *Auth) *Auth {
+		auth.tokenIssuer = issuer
+		return auth
+	}
+}
+
+// WithTokenAudience sets the audience of the tokens, the tokens of other
+// audiences are refused
+func WithTokenAudience(audience string) optFn {
+	return func(auth *Auth) *Auth {
+		auth.tokenAudience = audience
+		return auth
+	}
+}
+
+// WithLegacyTokens accepts the tokens issued before they carried their
+// subject and kind until the date, so that the users signed in before the
+// upgrade aren't signed out. They are refused once the date has passed, by
+// default the date is the refresh expiration time from New
+func WithLegacyTokens(until time.Time) optFn {
+	return func(auth *Auth) *Auth {
+		auth.legacyTokensUntil = until
+		return auth
+	}
+}
+
+// WithClaimsEnricher adds the claims returned by enricher to the access
+// tokens of the user, the claims of goauth can't be overridden
+func WithClaimsEnricher(enricher func(ctx context.Context, user entity.AuthUser) map[string]any) optFn {
+	return func(auth *Auth) *Auth {
+		auth.claimsEnricher = enricher
+		return auth
+	}
+}
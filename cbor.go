@@ -0,0 +1,143 @@
+package goauth
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var ErrCBORInvalid = errors.New("cbor invalid")
+
+const cborMaxDepth = 16
+
+// decodeCBOR decodes the first CBOR item of data and returns the remaining
+// bytes, it only supports the subset used by webauthn: integers are
+// returned as int64, maps as map[any]any, arrays as []any
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORHead(data []byte) (byte, uint64, []byte, error) {
+	if len(data) == 0 {
+		return 0, 0, nil, ErrCBORInvalid
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	switch {
+	case info < 24:
+		return major, uint64(info), data, nil
+	case info == 24:
+		if len(data) < 1 {
+			return 0, 0, nil, ErrCBORInvalid
+		}
+		return major, uint64(data[0]), data[1:], nil
+	case info == 25:
+		if len(data) < 2 {
+			return 0, 0, nil, ErrCBORInvalid
+		}
+		return major, uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26:
+		if len(data) < 4 {
+			return 0, 0, nil, ErrCBORInvalid
+		}
+		return major, uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27:
+		if len(data) < 8 {
+			return 0, 0, nil, ErrCBORInvalid
+		}
+		return major, binary.BigEndian.Uint64(data), data[8:], nil
+	}
+
+	// indefinite lengths are not used by authenticators
+	return 0, 0, nil, ErrCBORInvalid
+}
+
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if depth > cborMaxDepth {
+		return nil, nil, ErrCBORInvalid
+	}
+
+	major, value, rest, err := decodeCBORHead(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if value > math.MaxInt64 {
+			return nil, nil, ErrCBORInvalid
+		}
+		return int64(value), rest, nil
+	case 1:
+		if value > math.MaxInt64 {
+			return nil, nil, ErrCBORInvalid
+		}
+		return -1 - int64(value), rest, nil
+	case 2, 3:
+		if uint64(len(rest)) < value {
+			return nil, nil, ErrCBORInvalid
+		}
+		raw := make([]byte, value)
+		copy(raw, rest[:value])
+		if major == 3 {
+			return string(raw), rest[value:], nil
+		}
+		return raw, rest[value:], nil
+	case 4:
+		if uint64(len(rest)) < value {
+			return nil, nil, ErrCBORInvalid
+		}
+		list := make([]any, 0, value)
+		for i := uint64(0); i < value; i++ {
+			var item any
+			item, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			list = append(list, item)
+		}
+		return list, rest, nil
+	case 5:
+		if uint64(len(rest)) < value {
+			return nil, nil, ErrCBORInvalid
+		}
+		m := make(map[any]any, value)
+		for i := uint64(0); i < value; i++ {
+			var key, item any
+			key, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, ErrCBORInvalid
+			}
+
+			item, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			m[key] = item
+		}
+		return m, rest, nil
+	case 6:
+		// tags carry no meaning for webauthn, decode the tagged item
+		return decodeCBORItem(rest, depth+1)
+	case 7:
+		switch value {
+		case 20:
+			return false, rest, nil
+		case 21:
+			return true, rest, nil
+		case 22, 23:
+			return nil, rest, nil
+		}
+	}
+
+	return nil, nil, ErrCBORInvalid
+}
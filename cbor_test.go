@@ -0,0 +1,128 @@
+package goauth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func encodeCBORHead(major byte, value uint64) []byte {
+	switch {
+	case value < 24:
+		return []byte{major<<5 | byte(value)}
+	case value <= 0xff:
+		return []byte{major<<5 | 24, byte(value)}
+	case value <= 0xffff:
+		head := []byte{major<<5 | 25, 0, 0}
+		binary.BigEndian.PutUint16(head[1:], uint16(value))
+		return head
+	case value <= 0xffffffff:
+		head := []byte{major<<5 | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(head[1:], uint32(value))
+		return head
+	}
+
+	head := []byte{major<<5 | 27, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint64(head[1:], value)
+	return head
+}
+
+// encodeCBOR is the counterpart of decodeCBOR used to build test payloads
+func encodeCBOR(value any) []byte {
+	buf := bytes.Buffer{}
+
+	switch v := value.(type) {
+	case int:
+		return encodeCBOR(int64(v))
+	case int64:
+		if v >= 0 {
+			buf.Write(encodeCBORHead(0, uint64(v)))
+		} else {
+			buf.Write(encodeCBORHead(1, uint64(-1-v)))
+		}
+	case []byte:
+		buf.Write(encodeCBORHead(2, uint64(len(v))))
+		buf.Write(v)
+	case string:
+		buf.Write(encodeCBORHead(3, uint64(len(v))))
+		buf.WriteString(v)
+	case []any:
+		buf.Write(encodeCBORHead(4, uint64(len(v))))
+		for _, item := range v {
+			buf.Write(encodeCBOR(item))
+		}
+	case map[any]any:
+		buf.Write(encodeCBORHead(5, uint64(len(v))))
+		for key, item := range v {
+			buf.Write(encodeCBOR(key))
+			buf.Write(encodeCBOR(item))
+		}
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case nil:
+		buf.WriteByte(0xf6)
+	}
+
+	return buf.Bytes()
+}
+
+var decodeCBORTests = []struct {
+	description string
+	in          any
+	expected    any
+}{
+	{"small int", 10, int64(10)},
+	{"big int", 100000, int64(100000)},
+	{"negative int", -257, int64(-257)},
+	{"bytes", []byte{1, 2, 3}, []byte{1, 2, 3}},
+	{"text", "none", "none"},
+	{"bool", true, true},
+	{"array", []any{1, "a"}, []any{int64(1), "a"}},
+	{
+		"map",
+		map[any]any{"fmt": "none", 3: -7},
+		map[any]any{"fmt": "none", int64(3): int64(-7)},
+	},
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	for _, testCase := range decodeCBORTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			trailing := []byte{0xff}
+			raw := append(encodeCBOR(testCase.in), trailing...)
+
+			res, rest, err := decodeCBOR(raw)
+			if err != nil {
+				t.Fatalf("expected: non error and got %v", err)
+			}
+
+			if !reflect.DeepEqual(res, testCase.expected) {
+				t.Fatalf("expected: value=%v\ngot: %v", testCase.expected, res)
+			}
+
+			if !bytes.Equal(rest, trailing) {
+				t.Fatalf("expected: rest=%v\ngot: %v", trailing, rest)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORInvalid(t *testing.T) {
+	invalid := [][]byte{
+		{},
+		{0x5a, 0x00},             // byte string without the length
+		{0x45, 0x01, 0x02},       // byte string shorter than the length
+		{0xa1, 0x41, 0x00, 0x01}, // map with a bytes key
+	}
+
+	for _, raw := range invalid {
+		if _, _, err := decodeCBOR(raw); err == nil {
+			t.Fatalf("expected: error for %v", raw)
+		}
+	}
+}
@@ -8,19 +8,26 @@ import (
 	"github.com/google/uuid"
 	"github.com/iamajoe/goauth/entity"
 	"github.com/iamajoe/goauth/sender"
+	"github.com/iamajoe/goauth/storage"
 )
 
 var (
-	ErrStorageRequired    = errors.New("storage required for sign in")
-	ErrWrongCredentials   = errors.New("wrong credentials")
-	ErrUserConflict       = errors.New("user conflict")
-	ErrTokenNotRegistered = errors.New("token not registered")
+	ErrStorageRequired     = errors.New("storage required for sign in")
+	ErrWrongCredentials    = errors.New("wrong credentials")
+	ErrUserConflict        = errors.New("user conflict")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrUserNotVerified     = errors.New("user not verified")
+	ErrUserAlreadyVerified = errors.New("user already verified")
+	ErrTokenNotRegistered  = errors.New("token not registered")
 )
 
 type signInResult struct {
 	UserID       uuid.UUID
 	AccessToken  string
 	RefreshToken string
+	// MFAChallenge is set instead of the tokens when the user has a second
+	// factor enabled, it should be sent back through SignInVerifyMFA
+	MFAChallenge string
 }
 
 func mapUsersToNotificationData(
@@ -74,73 +81,243 @@ func getTokenKindSecretAndExpire(
 	case entity.TokenKindResetPassword:
 		secret = secrets.TokenResetPassword
 		expiringTime = expiringTimes.ResetPassword
+	case entity.TokenKindMFAChallenge:
+		secret = secrets.TokenMFAChallenge
+		expiringTime = expiringTimes.MFAChallenge
+	case entity.TokenKindPasskeyChallenge:
+		secret = secrets.TokenPasskey
+		expiringTime = expiringTimes.Passkey
+	case entity.TokenKindMagicLink:
+		secret = secrets.TokenMagicLink
+		expiringTime = expiringTimes.MagicLink
+	case entity.TokenKindEmailChange:
+		secret = secrets.TokenEmailChange
+		expiringTime = expiringTimes.EmailChange
+	case entity.TokenKindEmailChangeRevert:
+		secret = secrets.TokenEmailRevert
+		expiringTime = expiringTimes.EmailRevert
+	case entity.TokenKindAccountUnlock:
+		secret = secrets.TokenAccountUnlock
+		expiringTime = expiringTimes.AccountUnlock
+	case entity.TokenKindInvitation:
+		secret = secrets.TokenInvitation
+		expiringTime = expiringTimes.Invitation
+	case entity.TokenKindAuthorizationCode:
+		secret = secrets.TokenAuthorizationCode
+		expiringTime = expiringTimes.AuthorizationCode
+	case entity.TokenKindUserDeletion:
+		secret = secrets.TokenUserDeletion
+		expiringTime = expiringTimes.UserDeletion
 	}
 
 	return secret, expiringTime
 }
 
+// getTokenKindSecrets returns the secrets the tokens of the kind are accepted
+// with, the one signing them first
+func getTokenKindSecrets(kind entity.TokenKind, secrets AuthSecrets) []string {
+	secret, _ := getTokenKindSecretAndExpire(kind, secrets, AuthTokenExpirationTimes{})
+	return append([]string{secret}, secrets.Deprecated[kind]...)
+}
+
 // SignIn enters the user credentials and returns the user if succeeded.
-func (auth Auth) SignIn(ctx context.Context, email string, password string) (signInResult, error) {
-	result := signInResult{}
+func (auth Auth) SignIn(
+	ctx context.Context,
+	email string,
+	password string,
+) (result signInResult, err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionSignIn, userID, err) }()
 
 	if auth.userStorage == nil {
 		return result, ErrStorageRequired
 	}
 
-	user, err := auth.userStorage.GetUserByEmail(ctx, email)
+	err = auth.checkRateLimit(ctx, RateLimitActionSignIn, email)
+	if err != nil {
+		return result, err
+	}
+
+	user, err := auth.getUserByEmail(ctx, email)
+	if err != nil {
+		_ = auth.emit(ctx, Event{Kind: EventSignInFailed, Email: email, Err: err})
+		return result, err
+	}
+	userID = user.ID
+
+	err = auth.checkAccountLock(ctx, user.ID)
 	if err != nil {
 		return result, err
 	}
 
 	if ok := comparePassword(user.Password, password); !ok {
-		return result, ErrWrongCredentials
+		err = auth.registerFailedSignIn(ctx, user)
+		_ = auth.emit(ctx, Event{Kind: EventSignInFailed, UserID: user.ID, Email: email, Err: err})
+		return result, err
 	}
 
-	tokens := make([]entity.Token, 2)
+	// the failed attempts of the second factor are kept until it passes
+	if !user.IsTOTPEnabled {
+		err = auth.resetFailedSignIns(ctx, user.ID)
+		if err != nil {
+			return result, err
+		}
+	}
 
-	result.UserID = user.ID
-	secret, expiringTime := getTokenKindSecretAndExpire(
-		entity.TokenKindAccess,
-		auth.secrets,
-		auth.tokenExpirationTimes,
-	)
-	tokenValue, err := NewToken(entity.TokenKindAccess, user.ID, secret, expiringTime)
+	return auth.signInUser(ctx, user)
+}
+
+// checkUserSignIn refuses the users that can't sign in whatever the factor,
+// the unverified ones (unless allowed) and the locked ones
+func (auth Auth) checkUserSignIn(ctx context.Context, user entity.AuthUser) error {
+	if auth.requireVerifiedUser && !user.IsVerified {
+		return ErrUserNotVerified
+	}
+
+	return auth.checkAccountLock(ctx, user.ID)
+}
+
+// signInUser is to be called once the user passed the first factor, it
+// either issues the session tokens or a challenge for the second factor
+func (auth Auth) signInUser(ctx context.Context, user entity.AuthUser) (signInResult, error) {
+	result := signInResult{UserID: user.ID}
+
+	err := auth.checkUserSignIn(ctx, user)
 	if err != nil {
 		return result, err
 	}
-	tokens[0] = tokenValue
-	result.AccessToken = tokenValue.Value
 
-	secret, expiringTime = getTokenKindSecretAndExpire(
-		entity.TokenKindRefresh,
-		auth.secrets,
-		auth.tokenExpirationTimes,
-	)
-	tokenValue, err = NewToken(entity.TokenKindRefresh, user.ID, secret, expiringTime)
+	if !user.IsTOTPEnabled {
+		return auth.issueSignInTokens(ctx, user.ID)
+	}
+
+	token, err := auth.newKindToken(entity.TokenKindMFAChallenge, user.ID, tokenClaims{})
+	if err != nil {
+		return result, err
+	}
+
+	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{token})
 	if err != nil {
 		return result, err
 	}
-	tokens[1] = tokenValue
-	result.RefreshToken = tokenValue.Value
 
-	err = auth.tokenStorage.CreateTokens(ctx, tokens)
+	result.MFAChallenge = token.Value
+	return result, nil
+}
+
+// issueSignInTokens creates and registers the access and refresh tokens of a
+// new session
+func (auth Auth) issueSignInTokens(ctx context.Context, userID uuid.UUID) (signInResult, error) {
+	result := signInResult{UserID: userID}
+
+	// the tokens of the sign in are a new session
+	familyID := uuid.New()
+
+	err := auth.emit(ctx, Event{Kind: EventSignedIn, UserID: userID, SessionID: familyID})
+	if err != nil {
+		return result, err
+	}
+
+	result, err = auth.issueSessionTokens(ctx, userID, familyID, uuid.Nil)
+	if err != nil {
+		return result, err
+	}
+
+	err = auth.createSession(ctx, userID, familyID)
 	return result, err
 }
 
+// issueSessionTokens creates and registers the access and refresh tokens of
+// the session with the organization set as active
+func (auth Auth) issueSessionTokens(
+	ctx context.Context,
+	userID uuid.UUID,
+	familyID uuid.UUID,
+	orgID uuid.UUID,
+) (signInResult, error) {
+	result := signInResult{UserID: userID}
+
+	accessToken, refreshToken, err := auth.newSessionTokens(ctx, userID, orgID)
+	if err != nil {
+		return result, err
+	}
+	accessToken.FamilyID = familyID
+	refreshToken.FamilyID = familyID
+
+	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{accessToken, refreshToken})
+	if err != nil {
+		return result, err
+	}
+
+	result.AccessToken = accessToken.Value
+	result.RefreshToken = refreshToken.Value
+
+	return result, nil
+}
+
+// newSessionTokens issues the access token with the current roles of the user
+// and the refresh token, the organization is set on both so that it is kept
+// through the refreshes
+func (auth Auth) newSessionTokens(
+	ctx context.Context,
+	userID uuid.UUID,
+	orgID uuid.UUID,
+) (entity.Token, entity.Token, error) {
+	claims, err := auth.getAccessClaims(ctx, userID, orgID)
+	if err != nil {
+		return entity.Token{}, entity.Token{}, err
+	}
+
+	accessToken, err := auth.newKindToken(entity.TokenKindAccess, userID, claims)
+	if err != nil {
+		return entity.Token{}, entity.Token{}, err
+	}
+
+	refreshToken, err := auth.newKindToken(
+		entity.TokenKindRefresh,
+		userID,
+		tokenClaims{Org: claims.Org},
+	)
+	if err != nil {
+		return entity.Token{}, entity.Token{}, err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
 // SignOut revokes the users token and session.
-func (auth Auth) SignOut(ctx context.Context, userID uuid.UUID) error {
+func (auth Auth) SignOut(ctx context.Context, userID uuid.UUID) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionSignOut, userID, err) }()
+
 	if auth.tokenStorage == nil {
 		return ErrStorageRequired
 	}
 
-	return auth.tokenStorage.RemoveUserTokens(ctx, userID)
+	err = auth.emit(ctx, Event{Kind: EventSignedOut, UserID: userID})
+	if err != nil {
+		return err
+	}
+
+	err = auth.tokenStorage.RemoveUserTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if auth.sessionStorage == nil {
+		return nil
+	}
+
+	return auth.sessionStorage.RemoveUserSessions(ctx, userID)
 }
 
 // SignUp registers the user's email and password to the database.
 func (auth Auth) SignUp(
 	ctx context.Context,
 	user entity.AuthUser,
-) (uuid.UUID, error) {
+) (_ uuid.UUID, err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionSignUp, userID, err) }()
+
 	if auth.userStorage == nil || auth.tokenStorage == nil {
 		return uuid.UUID{}, ErrStorageRequired
 	}
@@ -153,38 +330,51 @@ func (auth Auth) SignUp(
 		return uuid.UUID{}, err
 	}
 
-	registeredUser, _ := auth.userStorage.GetUserByEmail(ctx, user.Email)
+	err = auth.checkRateLimit(ctx, RateLimitActionSignUp, user.Email)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	registeredUser, _ := auth.getUserByEmail(ctx, user.Email)
 	if registeredUser.Email == user.Email {
 		return uuid.UUID{}, ErrUserConflict
 	}
 
 	user.ID = uuid.New()
+	userID = user.ID
 	user.Password = encryptPassword(user.Password)
+	user.TenantID = auth.getContextTenantID(ctx)
 
-	err := auth.userStorage.CreateUser(ctx, user)
+	err = auth.userStorage.CreateUser(ctx, user)
 	if err != nil {
 		return user.ID, err
 	}
 
+	// a vetoed sign up doesn't keep the user
+	err = auth.emit(ctx, Event{Kind: EventUserSignedUp, UserID: user.ID, Email: user.Email})
+	if err != nil {
+		return uuid.UUID{}, errors.Join(err, auth.userStorage.DeleteUser(ctx, user.ID))
+	}
+
 	// DEV: in dev mode we might want to circumvent verification when testing
 	//      things, as such, we automatically verify the user
 	if auth.autoVerifyUser {
 		return user.ID, auth.userStorage.VerifyUser(ctx, user.ID)
 	}
 
-	secret, expiringTime := getTokenKindSecretAndExpire(
-		entity.TokenKindVerify,
-		auth.secrets,
-		auth.tokenExpirationTimes,
-	)
-	token, err := NewToken(entity.TokenKindVerify, user.ID, secret, expiringTime)
+	return user.ID, auth.sendVerification(ctx, user)
+}
+
+// sendVerification issues the verify token and sends it to the user
+func (auth Auth) sendVerification(ctx context.Context, user entity.AuthUser) error {
+	token, err := auth.newKindToken(entity.TokenKindVerify, user.ID, tokenClaims{})
 	if err != nil {
-		return user.ID, err
+		return err
 	}
 
 	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{token})
 	if err != nil {
-		return user.ID, err
+		return err
 	}
 
 	data := mapUsersToNotificationData(
@@ -194,15 +384,61 @@ func (auth Auth) SignUp(
 	)
 	errs := sender.SendBulk(auth.senders, sender.TemplateSignUp, data)
 	if len(errs) == 0 {
-		return user.ID, nil
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// ResendVerification invalidates the previous verification and sends a new
+// one, a new request is refused until the cooldown passes
+func (auth Auth) ResendVerification(ctx context.Context, email string) (err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionResendVerification, userID, err) }()
+
+	if auth.userStorage == nil || auth.tokenStorage == nil {
+		return ErrStorageRequired
+	}
+
+	user, err := auth.getUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	userID = user.ID
+
+	if user.IsVerified {
+		return ErrUserAlreadyVerified
+	}
+
+	tokens, err := auth.tokenStorage.GetUserTokens(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if token.Kind != entity.TokenKindVerify {
+			continue
+		}
+
+		retryAfter := time.Until(token.CreatedAt.Add(auth.verificationCooldown))
+		if retryAfter > 0 {
+			return RateLimitError{RetryAfter: retryAfter}
+		}
+	}
+
+	err = auth.tokenStorage.RemoveUserTokensByKind(ctx, user.ID, entity.TokenKindVerify)
+	if err != nil {
+		return err
 	}
 
-	err = errors.Join(errs...)
-	return user.ID, err
+	return auth.sendVerification(ctx, user)
 }
 
 // SignUpVerify is to be called upon a verification email to complete the signup process
-func (auth Auth) SignUpVerify(ctx context.Context, oneTimeToken string) error {
+func (auth Auth) SignUpVerify(ctx context.Context, oneTimeToken string) (err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionSignUpVerify, userID, err) }()
+
 	ok, err := auth.tokenStorage.AreTokensRegistered(ctx, []string{oneTimeToken})
 	if err != nil {
 		return err
@@ -211,7 +447,12 @@ func (auth Auth) SignUpVerify(ctx context.Context, oneTimeToken string) error {
 		return ErrTokenNotRegistered
 	}
 
-	userID, err := ValidateTokenUserID(oneTimeToken, auth.secrets.TokenVerify)
+	userID, err = auth.validateTokenOfKind(ctx, entity.TokenKindVerify, oneTimeToken)
+	if err != nil {
+		return err
+	}
+
+	err = auth.emit(ctx, Event{Kind: EventUserVerified, UserID: userID})
 	if err != nil {
 		return err
 	}
@@ -225,27 +466,31 @@ func (auth Auth) SignUpVerify(ctx context.Context, oneTimeToken string) error {
 }
 
 // RequestResetPassword sends an email for the user to perform the reset password
-func (auth Auth) RequestResetPassword(ctx context.Context, email string) error {
+func (auth Auth) RequestResetPassword(ctx context.Context, email string) (err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionRequestResetPassword, userID, err) }()
+
 	if auth.userStorage == nil {
 		return ErrStorageRequired
 	}
 
-	user, err := auth.userStorage.GetUserByEmail(ctx, email)
+	err = auth.checkRateLimit(ctx, RateLimitActionResetPassword, email)
 	if err != nil {
 		return err
 	}
 
-	secret, expiringTime := getTokenKindSecretAndExpire(
-		entity.TokenKindResetPassword,
-		auth.secrets,
-		auth.tokenExpirationTimes,
-	)
-	token, err := NewToken(
-		entity.TokenKindResetPassword,
-		user.ID,
-		secret,
-		expiringTime,
-	)
+	user, err := auth.getUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	userID = user.ID
+
+	err = auth.emit(ctx, Event{Kind: EventPasswordResetRequested, UserID: user.ID, Email: user.Email})
+	if err != nil {
+		return err
+	}
+
+	token, err := auth.newKindToken(entity.TokenKindResetPassword, user.ID, tokenClaims{})
 	if err != nil {
 		return err
 	}
@@ -269,7 +514,14 @@ func (auth Auth) RequestResetPassword(ctx context.Context, email string) error {
 }
 
 // ResetPassword will take the token generated by RequestResetPassword and change the password
-func (auth Auth) ResetPassword(ctx context.Context, oneTimeToken string, password string) error {
+func (auth Auth) ResetPassword(
+	ctx context.Context,
+	oneTimeToken string,
+	password string,
+) (err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionResetPassword, userID, err) }()
+
 	if auth.userStorage == nil {
 		return ErrStorageRequired
 	}
@@ -282,8 +534,16 @@ func (auth Auth) ResetPassword(ctx context.Context, oneTimeToken string, passwor
 		return ErrTokenNotRegistered
 	}
 
-	secret := auth.secrets.TokenResetPassword
-	userID, err := ValidateTokenUserID(oneTimeToken, secret)
+	if ok, err := validatePassword(password); !ok {
+		return err
+	}
+
+	userID, err = auth.validateTokenOfKind(ctx, entity.TokenKindResetPassword, oneTimeToken)
+	if err != nil {
+		return err
+	}
+
+	err = auth.emit(ctx, Event{Kind: EventPasswordReset, UserID: userID})
 	if err != nil {
 		return err
 	}
@@ -296,13 +556,64 @@ func (auth Auth) ResetPassword(ctx context.Context, oneTimeToken string, passwor
 	return auth.userStorage.UpdateUserPassword(ctx, userID, encryptPassword(password))
 }
 
-// RefreshToken takes auth and refresh tokens and resolves a new auth token
+// ChangePassword changes the password of a signed in user, the sessions other
+// than the one of the request (set by WithAuthUserID) are revoked
+func (auth Auth) ChangePassword(
+	ctx context.Context,
+	userID uuid.UUID,
+	currentPassword string,
+	newPassword string,
+) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionChangePassword, userID, err) }()
+
+	if auth.userStorage == nil || auth.tokenStorage == nil {
+		return ErrStorageRequired
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if ok := comparePassword(user.Password, currentPassword); !ok {
+		return ErrWrongCredentials
+	}
+
+	if ok, err := validatePassword(newPassword); !ok {
+		return err
+	}
+
+	err = auth.userStorage.UpdateUserPassword(ctx, user.ID, encryptPassword(newPassword))
+	if err != nil {
+		return err
+	}
+
+	// keep the session of the request
+	err = auth.revokeOtherSessions(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	data := mapUsersToNotificationData(auth.baseURL, []entity.AuthUser{user}, nil)
+	errs := sender.SendBulk(auth.senders, sender.TemplatePasswordChanged, data)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// RefreshToken takes auth and refresh tokens and resolves new ones, the
+// refresh token is rotated and presenting it again revokes the session
 func (auth Auth) RefreshToken(
 	ctx context.Context,
 	accessToken string,
 	refreshToken string,
-) (signInResult, error) {
-	result := signInResult{
+) (result signInResult, err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionRefreshToken, userID, err) }()
+
+	result = signInResult{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 	}
@@ -311,48 +622,142 @@ func (auth Auth) RefreshToken(
 		return result, ErrStorageRequired
 	}
 
-	ok, err := auth.tokenStorage.AreTokensRegistered(ctx, []string{refreshToken})
+	storedToken, err := auth.tokenStorage.GetToken(ctx, refreshToken)
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		return result, ErrTokenNotRegistered
+	}
 	if err != nil {
 		return result, err
 	}
-	if !ok {
+
+	if storedToken.Kind != entity.TokenKindRefresh {
 		return result, ErrTokenNotRegistered
 	}
+	userID = storedToken.UserID
 
-	authSecret, _ := getTokenKindSecretAndExpire(
-		entity.TokenKindAccess,
-		auth.secrets,
-		auth.tokenExpirationTimes,
-	)
-	refreshSecret, expiringTime := getTokenKindSecretAndExpire(
-		entity.TokenKindRefresh,
-		auth.secrets,
-		auth.tokenExpirationTimes,
-	)
+	// the token was already rotated, someone other than the user has it
+	if storedToken.IsRevoked {
+		err = auth.tokenStorage.RemoveTokenFamily(ctx, storedToken.FamilyID)
+		if err != nil {
+			return result, err
+		}
+
+		if auth.sessionStorage != nil {
+			err = auth.sessionStorage.RemoveSession(ctx, storedToken.UserID, storedToken.FamilyID)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		_ = auth.emit(ctx, Event{
+			Kind:      EventRefreshTokenReused,
+			UserID:    storedToken.UserID,
+			SessionID: storedToken.FamilyID,
+			Err:       ErrRefreshTokenReused,
+		})
+
+		return result, ErrRefreshTokenReused
+	}
+
+	// check the access token, it may be signed with the signing key and it is
+	// expected to be expired already
+	accessClaims, err := auth.parseAccessTokenClaims(ctx, accessToken)
+	if err != nil && !errors.Is(err, ErrExpirationTime) {
+		return result, err
+	}
+
+	authUserID, err := accessClaims.userID()
+	if err != nil {
+		return result, err
+	}
+
+	// the access token has to be of the session of the refresh token
+	storedAccessToken, err := auth.tokenStorage.GetToken(ctx, accessToken)
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		return result, ErrTokenNotRegistered
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if storedAccessToken.Kind != entity.TokenKindAccess || storedAccessToken.FamilyID != storedToken.FamilyID {
+		return result, ErrTokenNotRegistered
+	}
 
-	newToken, err := GetRefreshedToken(GetRefreshedTokenParams{
-		AccessToken:   accessToken,
-		RefreshToken:  refreshToken,
-		AuthSecret:    authSecret,
-		RefreshSecret: refreshSecret,
-		ExpiringTime:  expiringTime,
-	})
+	// the active organization is carried by the refresh token
+	refreshClaims, err := auth.parseKindTokenClaims(ctx, entity.TokenKindRefresh, refreshToken)
 	if err != nil {
 		return result, err
 	}
 
-	userID, err := ValidateTokenUserID(accessToken, authSecret)
+	refreshUserID, err := refreshClaims.userID()
 	if err != nil {
 		return result, err
 	}
 
+	// make sure the refresh and parsed are for the same user
+	if authUserID != refreshUserID {
+		return result, ErrWrongUser
+	}
+
+	// the refresh tokens of the oauth clients are limited to their scopes
+	if len(refreshClaims.ClientID) > 0 {
+		return result, ErrTokenNotRegistered
+	}
+
+	orgID := uuid.Nil
+	if len(refreshClaims.Org) > 0 {
+		orgID, err = uuid.Parse(refreshClaims.Org)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// the roles are read again so that their changes take effect
+	userID = refreshUserID
+	newAccessToken, newRefreshToken, err := auth.newSessionTokens(ctx, userID, orgID)
+	if err != nil {
+		return result, err
+	}
+
+	err = auth.emit(ctx, Event{Kind: EventTokenRefreshed, UserID: userID, SessionID: storedToken.FamilyID})
+	if err != nil {
+		return result, err
+	}
+
+	// tokens issued before the families become a session from now on
+	familyID := storedToken.FamilyID
+	if familyID == uuid.Nil {
+		familyID = uuid.New()
+		err = auth.createSession(ctx, userID, familyID)
+	} else {
+		err = auth.touchSession(ctx, familyID)
+	}
+	if err != nil {
+		return result, err
+	}
+	newAccessToken.FamilyID = familyID
+	newRefreshToken.FamilyID = familyID
+
 	err = auth.tokenStorage.RemoveUserToken(ctx, userID, accessToken)
 	if err != nil {
 		return result, err
 	}
 
-	result.AccessToken = newToken.Value
-	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{newToken})
+	// the old refresh token is kept as revoked to detect its reuse
+	err = auth.tokenStorage.RevokeToken(ctx, refreshToken)
+	if err != nil {
+		return result, err
+	}
 
-	return result, err
+	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{newAccessToken, newRefreshToken})
+	if err != nil {
+		return result, err
+	}
+
+	result.UserID = userID
+	result.AccessToken = newAccessToken.Value
+	result.RefreshToken = newRefreshToken.Value
+
+	return result, nil
 }
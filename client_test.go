@@ -2,6 +2,7 @@ package goauth
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -21,7 +22,7 @@ var signInTests = []struct {
 		"login",
 		[]entity.AuthUser{
 			{ID: uuid.New(), Email: "nofoo@bar.com", Password: encryptPassword("4321")},
-			{ID: uuid.New(), Email: "foo@bar.com", Password: encryptPassword("1234")},
+			{ID: uuid.New(), Email: "foo@bar.com", Password: encryptPassword("1234"), IsVerified: true},
 		},
 		"foo@bar.com",
 		"1234",
@@ -39,11 +40,19 @@ var signInTests = []struct {
 		"wrong password",
 		[]entity.AuthUser{
 			{ID: uuid.New(), Email: "nofoo@bar.com", Password: encryptPassword("4321")},
-			{ID: uuid.New(), Email: "foo@bar.com", Password: encryptPassword("1234")},
+			{ID: uuid.New(), Email: "foo@bar.com", Password: encryptPassword("1234"), IsVerified: true},
 		},
 		"foo@bar.com",
 		"2345",
 		true,
+	}, {
+		"not verified",
+		[]entity.AuthUser{
+			{ID: uuid.New(), Email: "foo@bar.com", Password: encryptPassword("1234")},
+		},
+		"foo@bar.com",
+		"1234",
+		true,
 	},
 }
 
@@ -447,6 +456,7 @@ func TestResetPassword(t *testing.T) {
 				},
 				WithTokenStorage(tokenStore),
 				WithUserStorage(userStore),
+				WithAutoVerifyUser(),
 			)
 
 			var tokenValue string
@@ -511,17 +521,24 @@ func TestResetPassword(t *testing.T) {
 }
 
 var refreshTokenTests = []struct {
-	description  string
-	inWrongToken bool
-	expectError  bool
+	description    string
+	inWrongToken   bool
+	inReuse        bool
+	inExpired      bool
+	inOtherSession bool
+	expectError    bool
 }{
-	{"success", false, false},
-	{"wrong token", true, true},
+	{"success", false, false, false, false, false},
+	{"wrong token", true, false, false, false, true},
+	{"reused token", false, true, false, false, true},
+	{"expired access token", false, false, true, false, false},
+	{"access token of another session", false, false, false, true, true},
 }
 
 func TestRefreshToken(t *testing.T) {
 	for _, testCase := range refreshTokenTests {
 		t.Run(testCase.description, func(t *testing.T) {
+			reuseDetected := false
 			tokenStore := inmem.NewTokens([]entity.Token{})
 			userStore := inmem.NewUsers([]entity.AuthUser{})
 			auth := New(
@@ -533,7 +550,15 @@ func TestRefreshToken(t *testing.T) {
 				},
 				WithTokenStorage(tokenStore),
 				WithUserStorage(userStore),
+				WithAutoVerifyUser(),
+				WithEventHandler(func(_ context.Context, _ Event) error {
+					reuseDetected = true
+					return nil
+				}, EventDeliverySync, EventRefreshTokenReused),
 			)
+			if testCase.inExpired {
+				auth.tokenExpirationTimes.Access = -time.Minute
+			}
 
 			email := "foo@bar.com"
 			password := "12345678"
@@ -543,6 +568,45 @@ func TestRefreshToken(t *testing.T) {
 			})
 			oldTokens, _ := auth.SignIn(context.Background(), email, password)
 
+			if testCase.inExpired {
+				_, err := auth.parseAccessTokenClaims(context.Background(), oldTokens.AccessToken)
+				if !errors.Is(err, ErrExpirationTime) {
+					t.Fatalf("expected: the access token to be expired and got %v", err)
+				}
+				auth.tokenExpirationTimes.Access = time.Hour
+			}
+
+			if testCase.inOtherSession {
+				other, _ := auth.SignIn(context.Background(), email, password)
+				oldTokens.AccessToken = other.AccessToken
+			}
+
+			if testCase.inReuse {
+				rotated, _ := auth.RefreshToken(
+					context.Background(),
+					oldTokens.AccessToken,
+					oldTokens.RefreshToken,
+				)
+
+				// presenting the rotated out token revokes the whole session
+				_, err := auth.RefreshToken(
+					context.Background(),
+					rotated.AccessToken,
+					oldTokens.RefreshToken,
+				)
+				if !errors.Is(err, ErrRefreshTokenReused) || !reuseDetected {
+					t.Fatalf("expected: reuse to be detected and got %v", err)
+				}
+
+				ok, _ := tokenStore.AreTokensRegistered(
+					context.Background(),
+					[]string{rotated.RefreshToken},
+				)
+				if ok {
+					t.Fatal("expected: session to be revoked")
+				}
+			}
+
 			if testCase.inWrongToken {
 				rawToken, _ := NewToken(
 					entity.TokenKindAccess, userID,
@@ -568,12 +632,16 @@ func TestRefreshToken(t *testing.T) {
 				t.Fatal("expected: error")
 			}
 
-			if newTokens.RefreshToken != oldTokens.RefreshToken {
-				t.Fatalf(
-					"expected: RefreshToken=%v\ngot: %v",
-					oldTokens.RefreshToken,
-					newTokens.RefreshToken,
-				)
+			if newTokens.RefreshToken == oldTokens.RefreshToken {
+				t.Fatal("expected: refresh token to be rotated")
+			}
+
+			ok, _ := tokenStore.AreTokensRegistered(
+				context.Background(),
+				[]string{oldTokens.RefreshToken},
+			)
+			if ok {
+				t.Fatal("expected: old refresh token to have been revoked")
 			}
 
 			tokenID, _ := ValidateTokenUserID(newTokens.AccessToken, auth.secrets.TokenAccess)
@@ -602,3 +670,147 @@ func TestRefreshToken(t *testing.T) {
 		})
 	}
 }
+
+var changePasswordTests = []struct {
+	description       string
+	inCurrentPassword string
+	inNewPassword     string
+	expectError       bool
+}{
+	{"success", "12345678", "87654321", false},
+	{"wrong current password", "wrong-password", "87654321", true},
+	{"new password too short", "12345678", "1234", true},
+}
+
+func TestChangePassword(t *testing.T) {
+	for _, testCase := range changePasswordTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			userStore := inmem.NewUsers([]entity.AuthUser{})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:  "1234",
+					TokenRefresh: "2345",
+					TokenVerify:  "3456",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithAutoVerifyUser(),
+			)
+
+			email := "foo@bar.com"
+			oldPassword := "12345678"
+			userID, _ := auth.SignUp(context.Background(), entity.AuthUser{
+				Email:    email,
+				Password: oldPassword,
+			})
+
+			current, _ := auth.SignIn(context.Background(), email, oldPassword)
+			other, _ := auth.SignIn(context.Background(), email, oldPassword)
+
+			ctx := context.WithValue(context.Background(), accessTokenKey, current.AccessToken)
+			ctx = context.WithValue(ctx, refreshTokenKey, current.RefreshToken)
+
+			err := auth.ChangePassword(
+				ctx, userID,
+				testCase.inCurrentPassword,
+				testCase.inNewPassword,
+			)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error and got %v", err)
+			}
+
+			if testCase.expectError {
+				t.Fatal("expected: error")
+			}
+
+			res, _ := auth.SignIn(context.Background(), email, testCase.inNewPassword)
+			if len(res.AccessToken) == 0 {
+				t.Fatal("expected: password to be changed per the new")
+			}
+
+			// the session of the request is kept and the others revoked
+			ok, _ := tokenStore.AreTokensRegistered(
+				context.Background(),
+				[]string{current.AccessToken, current.RefreshToken},
+			)
+			if !ok {
+				t.Fatal("expected: current session to be kept")
+			}
+
+			ok, _ = tokenStore.AreTokensRegistered(
+				context.Background(),
+				[]string{other.AccessToken},
+			)
+			if ok {
+				t.Fatal("expected: other sessions to be revoked")
+			}
+		})
+	}
+}
+
+var resendVerificationTests = []struct {
+	description  string
+	inVerified   bool
+	inCooldown   time.Duration
+	expectError  error
+	expectTokens int
+}{
+	{"success", false, 0, nil, 1},
+	{"cooldown", false, time.Hour, ErrRateLimited, 1},
+	{"already verified", true, 0, ErrUserAlreadyVerified, 0},
+}
+
+func TestResendVerification(t *testing.T) {
+	for _, testCase := range resendVerificationTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			userStore := inmem.NewUsers([]entity.AuthUser{})
+			notifications := &senderRecorder{}
+			opts := []optFn{
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithVerificationCooldown(testCase.inCooldown),
+				WithSender(notifications),
+			}
+			if testCase.inVerified {
+				opts = append(opts, WithAutoVerifyUser())
+			}
+			auth := New(AuthSecrets{TokenVerify: "3456"}, opts...)
+
+			email := "foo@bar.com"
+			userID, _ := auth.SignUp(context.Background(), entity.AuthUser{
+				Email:    email,
+				Password: "12345678",
+			})
+
+			err := auth.ResendVerification(context.Background(), email)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("expected: %v and got %v", testCase.expectError, err)
+			}
+
+			// the previous verification is replaced by the new one
+			tokens, _ := tokenStore.GetUserTokens(context.Background(), userID)
+			if len(tokens) != testCase.expectTokens {
+				t.Fatalf("expected: %d tokens and got %d", testCase.expectTokens, len(tokens))
+			}
+
+			if testCase.expectError != nil {
+				return
+			}
+
+			last := notifications.sent[len(notifications.sent)-1]
+			if tokens[0].Value != last["code"] {
+				t.Fatal("expected: new verification to be sent")
+			}
+
+			err = auth.SignUpVerify(context.Background(), last["code"])
+			if err != nil {
+				t.Fatalf("expected: non error on verify and got %v", err)
+			}
+		})
+	}
+}
@@ -1,9 +1,21 @@
 package goauth
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrEncryptionKeyRequired = errors.New("encryption key required")
+	ErrCipherTextInvalid     = errors.New("cipher text invalid")
+)
+
 func encryptPassword(password string) string {
 	if len(password) == 0 {
 		return password
@@ -21,3 +33,59 @@ func comparePassword(hash string, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+func newCipher(key string) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, ErrEncryptionKeyRequired
+	}
+
+	// derive a fixed size key so that any secret length can be used
+	hashedKey := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(hashedKey[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// encryptSecret encrypts values that need to be read back, for example
+// the totp secret, unlike passwords which are hashed
+func encryptSecret(key string, plain string) (string, error) {
+	gcm, err := newCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func decryptSecret(key string, encrypted string) (string, error) {
+	gcm, err := newCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	if len(raw) < gcm.NonceSize() {
+		return "", ErrCipherTextInvalid
+	}
+
+	nonce, sealed := raw[:gcm.NonceSize()], raw[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrCipherTextInvalid
+	}
+
+	return string(plain), nil
+}
@@ -57,3 +57,49 @@ func TestComparePassword(t *testing.T) {
 		})
 	}
 }
+
+var encryptSecretTests = []struct {
+	description  string
+	inKey        string
+	inDecryptKey string
+	expectError  bool
+}{
+	{"same key", "1234", "1234", false},
+	{"wrong key", "1234", "4321", true},
+	{"missing key", "", "", true},
+}
+
+func TestEncryptSecret(t *testing.T) {
+	for _, testCase := range encryptSecretTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			plain := "secret value"
+			encrypted, err := encryptSecret(testCase.inKey, plain)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error on encryptSecret and got %v", err)
+			}
+
+			if encrypted == plain {
+				t.Fatal("expected: value to be encrypted")
+			}
+
+			res, err := decryptSecret(testCase.inDecryptKey, encrypted)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error on decryptSecret and got %v", err)
+			}
+
+			if testCase.expectError {
+				t.Fatal("expected: error")
+			}
+
+			if res != plain {
+				t.Fatalf("expected: value=%v\ngot: %v", plain, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/sender"
+)
+
+// checkEmailAvailable makes sure no other user is registered with the email
+func (auth Auth) checkEmailAvailable(ctx context.Context, userID uuid.UUID, email string) error {
+	registeredUser, _ := auth.getUserByEmail(ctx, email)
+	if registeredUser.Email == email && registeredUser.ID != userID {
+		return ErrUserConflict
+	}
+
+	return nil
+}
+
+// RequestEmailChange sends a confirmation link to the new email and a link to
+// revert the change to the current one
+func (auth Auth) RequestEmailChange(ctx context.Context, userID uuid.UUID, newEmail string) error {
+	if auth.userStorage == nil || auth.tokenStorage == nil {
+		return ErrStorageRequired
+	}
+
+	if ok, err := validateEmail(newEmail); !ok {
+		return err
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Email == newEmail {
+		return ErrUserConflict
+	}
+
+	err = auth.checkEmailAvailable(ctx, user.ID, newEmail)
+	if err != nil {
+		return err
+	}
+
+	changeToken, err := auth.newKindToken(
+		entity.TokenKindEmailChange,
+		user.ID,
+		tokenClaims{Email: newEmail},
+	)
+	if err != nil {
+		return err
+	}
+
+	revertToken, err := auth.newKindToken(
+		entity.TokenKindEmailChangeRevert,
+		user.ID,
+		tokenClaims{Email: user.Email},
+	)
+	if err != nil {
+		return err
+	}
+
+	// only the latest request can be confirmed
+	err = auth.tokenStorage.RemoveUserTokensByKind(ctx, user.ID, entity.TokenKindEmailChange)
+	if err != nil {
+		return err
+	}
+
+	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{changeToken, revertToken})
+	if err != nil {
+		return err
+	}
+
+	newUser := user
+	newUser.Email = newEmail
+	errs := sender.SendBulk(
+		auth.senders,
+		sender.TemplateEmailChange,
+		mapUsersToNotificationData(
+			auth.baseURL,
+			[]entity.AuthUser{newUser},
+			map[string]string{"code": changeToken.Value, "newEmail": newEmail},
+		),
+	)
+	errs = append(errs, sender.SendBulk(
+		auth.senders,
+		sender.TemplateEmailChangeRevert,
+		mapUsersToNotificationData(
+			auth.baseURL,
+			[]entity.AuthUser{user},
+			map[string]string{"code": revertToken.Value, "newEmail": newEmail},
+		),
+	)...)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// ConfirmEmailChange takes the token sent to the new email by
+// RequestEmailChange and updates the user email
+func (auth Auth) ConfirmEmailChange(ctx context.Context, oneTimeToken string) error {
+	if auth.userStorage == nil || auth.tokenStorage == nil {
+		return ErrStorageRequired
+	}
+
+	ok, err := auth.tokenStorage.AreTokensRegistered(ctx, []string{oneTimeToken})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTokenNotRegistered
+	}
+
+	claims, err := auth.parseKindTokenClaims(ctx, entity.TokenKindEmailChange, oneTimeToken)
+	if err != nil {
+		return err
+	}
+
+	userID, err := claims.userID()
+	if err != nil {
+		return err
+	}
+
+	err = auth.checkEmailAvailable(ctx, userID, claims.Email)
+	if err != nil {
+		return err
+	}
+
+	err = auth.userStorage.UpdateUserEmail(ctx, userID, claims.Email)
+	if err != nil {
+		return err
+	}
+
+	err = auth.tokenStorage.RemoveUserToken(ctx, userID, oneTimeToken)
+	if err != nil {
+		return err
+	}
+
+	if !auth.revokeSessionsOnEmailChange {
+		return nil
+	}
+
+	return auth.revokeUserSessions(ctx, userID)
+}
+
+// RevertEmailChange takes the token sent to the previous email by
+// RequestEmailChange, it cancels the change, restores the previous email if
+// already confirmed and signs the user out of every session
+func (auth Auth) RevertEmailChange(ctx context.Context, oneTimeToken string) error {
+	if auth.userStorage == nil || auth.tokenStorage == nil {
+		return ErrStorageRequired
+	}
+
+	ok, err := auth.tokenStorage.AreTokensRegistered(ctx, []string{oneTimeToken})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTokenNotRegistered
+	}
+
+	claims, err := auth.parseKindTokenClaims(ctx, entity.TokenKindEmailChangeRevert, oneTimeToken)
+	if err != nil {
+		return err
+	}
+
+	userID, err := claims.userID()
+	if err != nil {
+		return err
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Email != claims.Email {
+		err = auth.checkEmailAvailable(ctx, user.ID, claims.Email)
+		if err != nil {
+			return err
+		}
+
+		err = auth.userStorage.UpdateUserEmail(ctx, user.ID, claims.Email)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the change wasn't requested by the user, as such, remove any pending
+	// change and sessions that might have been compromised
+	for _, kind := range []entity.TokenKind{entity.TokenKindEmailChange, entity.TokenKindEmailChangeRevert} {
+		err = auth.tokenStorage.RemoveUserTokensByKind(ctx, user.ID, kind)
+		if err != nil {
+			return err
+		}
+	}
+
+	return auth.revokeUserSessions(ctx, user.ID)
+}
@@ -0,0 +1,106 @@
+package goauth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var emailChangeTests = []struct {
+	description   string
+	inNewEmail    string
+	inRevert      bool
+	expectError   bool
+	expectedEmail string
+}{
+	{"confirm", "new@bar.com", false, false, "new@bar.com"},
+	{"revert", "new@bar.com", true, false, "foo@bar.com"},
+	{"email in use", "nofoo@bar.com", false, true, "foo@bar.com"},
+	{"invalid email", "newbar.com", false, true, "foo@bar.com"},
+}
+
+func TestEmailChange(t *testing.T) {
+	for _, testCase := range emailChangeTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			userID := uuid.New()
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			sessionStore := inmem.NewSessions([]entity.Session{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: uuid.New(), Email: "nofoo@bar.com"},
+				{ID: userID, Email: "foo@bar.com", Password: encryptPassword("1234"), IsVerified: true},
+			})
+			notifications := &senderRecorder{}
+			auth := New(
+				AuthSecrets{
+					TokenAccess:      "1234",
+					TokenRefresh:     "2345",
+					TokenEmailChange: "3456",
+					TokenEmailRevert: "4567",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithSessionStorage(sessionStore),
+				WithSender(notifications),
+			)
+
+			_, err := auth.SignIn(context.Background(), "foo@bar.com", "1234")
+			if err != nil {
+				t.Fatalf("expected: non error on sign in and got %v", err)
+			}
+
+			err = auth.RequestEmailChange(context.Background(), userID, testCase.inNewEmail)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error on request and got %v", err)
+			}
+
+			// the confirmation goes to the new email, the revert to the old
+			var confirmToken, revertToken string
+			for _, data := range notifications.sent {
+				if data["email"] == testCase.inNewEmail {
+					confirmToken = data["code"]
+				} else if data["email"] == "foo@bar.com" {
+					revertToken = data["code"]
+				}
+			}
+
+			err = auth.ConfirmEmailChange(context.Background(), confirmToken)
+			if err != nil {
+				t.Fatalf("expected: non error on confirm and got %v", err)
+			}
+
+			if testCase.inRevert {
+				err = auth.RevertEmailChange(context.Background(), revertToken)
+				if err != nil {
+					t.Fatalf("expected: non error on revert and got %v", err)
+				}
+			}
+
+			if testCase.expectError {
+				t.Fatal("expected: error")
+			}
+
+			user, _ := userStore.GetUserByID(context.Background(), userID)
+			if user.Email != testCase.expectedEmail {
+				t.Fatalf("expected: email=%v\ngot: %v", testCase.expectedEmail, user.Email)
+			}
+
+			// the sessions are revoked along with the change
+			sessions, _ := auth.ListSessions(context.Background(), userID)
+			if (len(sessions) == 0) != testCase.inRevert {
+				t.Fatalf("expected: sessions revoked=%v and got %d sessions", testCase.inRevert, len(sessions))
+			}
+
+			// check if the confirmation is single use
+			err = auth.ConfirmEmailChange(context.Background(), confirmToken)
+			if err == nil {
+				t.Fatal("expected: confirmation token to have been removed")
+			}
+		})
+	}
+}
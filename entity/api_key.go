@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a long lived key for machine access, only the hash of the key is
+// kept while the prefix is used to look it up
+type APIKey struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Name   string
+	Prefix string
+	Hash   string
+	Scopes []string
+	// ExpiresAt is zero on the keys that don't expire
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditRecord is an entry of the audit log, Hash chains it to the previous
+// record through PrevHash so that tampering is detectable
+type AuditRecord struct {
+	ID        int64
+	Action    string
+	ActorID   uuid.UUID
+	UserID    uuid.UUID
+	IP        string
+	UserAgent string
+	Outcome   string
+	Error     string
+	PrevHash  string
+	Hash      string
+	CreatedAt time.Time
+}
+
+// AuditFilter filters the audit log, the zero values are ignored
+type AuditFilter struct {
+	UserID uuid.UUID
+	Action string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
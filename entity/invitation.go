@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+)
+
+type Invitation struct {
+	ID        uuid.UUID
+	InviterID uuid.UUID
+	Email     string
+	// OrganizationID is uuid.Nil on the invitations to the app, the roles
+	// are the ones given within the organization once accepted
+	OrganizationID uuid.UUID
+	Roles          []string
+	// Meta is set on the user created upon the acceptance
+	Meta      map[string]string
+	Status    string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
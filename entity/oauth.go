@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClient is a third party registered to access the api on behalf of the
+// users, only the hash of the secret of the confidential clients is kept
+type OAuthClient struct {
+	ID             uuid.UUID
+	Name           string
+	SecretHash     string
+	RedirectURIs   []string
+	Scopes         []string
+	IsConfidential bool
+	CreatedAt      time.Time
+}
+
+// OAuthConsent is the scopes the user granted to the client
+type OAuthConsent struct {
+	UserID    uuid.UUID
+	ClientID  uuid.UUID
+	Scopes    []string
+	CreatedAt time.Time
+}
@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PhoneCode struct {
+	UserID    uuid.UUID
+	Code      string
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,13 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RecoveryCode struct {
+	UserID    uuid.UUID
+	Code      string
+	CreatedAt time.Time
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "github.com/google/uuid"
+
+// Role groups the permissions assigned to the users, the names of both are
+// emitted on the access tokens
+type Role struct {
+	Name        string
+	Permissions []string
+}
+
+// UserRole is the assignment of a role to an user
+type UserRole struct {
+	UserID uuid.UUID
+	Role   string
+}
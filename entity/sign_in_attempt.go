@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SignInAttempt keeps the failed sign ins of a user, Lockouts counts the
+// consecutive locks to back off the lock duration
+type SignInAttempt struct {
+	UserID        uuid.UUID
+	Attempts      int
+	Lockouts      int
+	WindowStartAt time.Time
+	LockedUntil   time.Time
+}
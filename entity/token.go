@@ -13,11 +13,49 @@ const (
 	TokenKindRefresh
 	TokenKindVerify
 	TokenKindResetPassword
+	TokenKindMFAChallenge
+	TokenKindPasskeyChallenge
+	TokenKindMagicLink
+	TokenKindEmailChange
+	TokenKindEmailChangeRevert
+	TokenKindAccountUnlock
+	TokenKindInvitation
+	TokenKindAuthorizationCode
+	TokenKindUserDeletion
 )
 
+var tokenKindNames = map[TokenKind]string{
+	TokenKindAccess:            "access",
+	TokenKindRefresh:           "refresh",
+	TokenKindVerify:            "verify",
+	TokenKindResetPassword:     "reset_password",
+	TokenKindMFAChallenge:      "mfa_challenge",
+	TokenKindPasskeyChallenge:  "passkey_challenge",
+	TokenKindMagicLink:         "magic_link",
+	TokenKindEmailChange:       "email_change",
+	TokenKindEmailChangeRevert: "email_change_revert",
+	TokenKindAccountUnlock:     "account_unlock",
+	TokenKindInvitation:        "invitation",
+	TokenKindAuthorizationCode: "authorization_code",
+	TokenKindUserDeletion:      "user_deletion",
+}
+
+// String is the name of the kind, set as the typ claim of the tokens
+func (kind TokenKind) String() string {
+	return tokenKindNames[kind]
+}
+
 type Token struct {
-	Kind      TokenKind
-	Value     string
-	UserID    uuid.UUID
+	Kind   TokenKind
+	Value  string
+	UserID uuid.UUID
+	// FamilyID groups the tokens of a session, the refresh tokens rotated
+	// from the same sign in share it
+	FamilyID uuid.UUID
+	// ClientID is the oauth client the token was issued to, the tokens of
+	// the client credentials have it as the user as well
+	ClientID  uuid.UUID
+	IsRevoked bool
 	ExpiresAt time.Time
+	CreatedAt time.Time
 }
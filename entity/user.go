@@ -7,13 +7,21 @@ import (
 )
 
 type AuthUser struct {
-	ID           uuid.UUID
-	Email        string
-	PhoneNumber  string
-	Password     string
-	IsVerified   bool
-	IsVerifiedAt time.Time
+	ID            uuid.UUID
+	Email         string
+	PhoneNumber   string
+	Password      string
+	IsVerified    bool
+	IsVerifiedAt  time.Time
+	TOTPSecret    string
+	IsTOTPEnabled bool
+	// TOTPLastStep is the time step of the last code accepted on sign in, so
+	// that a code isn't accepted twice
+	TOTPLastStep int64
 	Meta         map[string]string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	// TenantID is the tenant the user signed up on when the emails are
+	// unique per tenant, nil otherwise
+	TenantID  uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
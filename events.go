@@ -0,0 +1,111 @@
+package goauth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+)
+
+type EventKind string
+
+const (
+	EventUserSignedUp           EventKind = "user_signed_up"
+	EventUserVerified           EventKind = "user_verified"
+	EventSignedIn               EventKind = "signed_in"
+	EventSignInFailed           EventKind = "sign_in_failed"
+	EventSignedOut              EventKind = "signed_out"
+	EventPasswordResetRequested EventKind = "password_reset_requested"
+	EventPasswordReset          EventKind = "password_reset"
+	EventTokenRefreshed         EventKind = "token_refreshed"
+	EventRefreshTokenReused     EventKind = "refresh_token_reused"
+	EventOAuthClientRegistered  EventKind = "oauth_client_registered"
+	EventOAuthClientRemoved     EventKind = "oauth_client_removed"
+	EventOAuthConsentGranted    EventKind = "oauth_consent_granted"
+	EventOAuthConsentRevoked    EventKind = "oauth_consent_revoked"
+	// EventDeprecatedSecretUsed is a token accepted with one of the
+	// AuthSecrets.Deprecated, the secret can be retired once none is left
+	EventDeprecatedSecretUsed EventKind = "deprecated_secret_used"
+)
+
+// Event is delivered to the handlers set with WithEventHandler, the ip and
+// user agent are the ones set on the context by WithClientInfo
+type Event struct {
+	Kind      EventKind
+	UserID    uuid.UUID
+	Email     string
+	SessionID uuid.UUID
+	IP        string
+	UserAgent string
+	CreatedAt time.Time
+	// Err is the reason of the failure events
+	Err error
+	// TokenKind is the kind of the token of EventDeprecatedSecretUsed
+	TokenKind entity.TokenKind
+	// ClientID is the oauth client of the EventOAuth events
+	ClientID uuid.UUID
+}
+
+type EventHandler func(ctx context.Context, event Event) error
+
+type EventDelivery int
+
+const (
+	// EventDeliverySync runs the handler before the action is done, an
+	// error returned by the handler vetoes the action
+	EventDeliverySync EventDelivery = iota
+	// EventDeliveryAsync runs the handler on its own goroutine, the errors
+	// are ignored
+	EventDeliveryAsync
+)
+
+type eventSubscription struct {
+	handler  EventHandler
+	delivery EventDelivery
+	kinds    []EventKind
+}
+
+func (sub eventSubscription) isSubscribed(kind EventKind) bool {
+	if len(sub.kinds) == 0 {
+		return true
+	}
+
+	for _, k := range sub.kinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// emit delivers the event to the subscribed handlers, the error of the
+// first sync handler failing is returned and should veto the action
+func (auth Auth) emit(ctx context.Context, event Event) error {
+	if len(auth.eventSubscriptions) == 0 {
+		return nil
+	}
+
+	event.IP = GetContextClientIP(ctx)
+	event.UserAgent = GetContextUserAgent(ctx)
+	event.CreatedAt = time.Now()
+
+	for _, sub := range auth.eventSubscriptions {
+		if !sub.isSubscribed(event.Kind) {
+			continue
+		}
+
+		if sub.delivery == EventDeliveryAsync {
+			go sub.handler(context.WithoutCancel(ctx), event)
+			continue
+		}
+
+		err := sub.handler(ctx, event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
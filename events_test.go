@@ -0,0 +1,101 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var errVetoed = errors.New("vetoed")
+
+var eventsTests = []struct {
+	description string
+	inDelivery  EventDelivery
+	inVeto      bool
+	expectKinds []EventKind
+	expectUser  bool
+}{
+	{
+		"sync",
+		EventDeliverySync,
+		false,
+		[]EventKind{EventUserSignedUp, EventSignInFailed, EventSignedIn, EventSignedOut},
+		true,
+	},
+	{
+		"async",
+		EventDeliveryAsync,
+		false,
+		[]EventKind{EventUserSignedUp, EventSignInFailed, EventSignedIn, EventSignedOut},
+		true,
+	},
+	{"veto sign up", EventDeliverySync, true, []EventKind{EventUserSignedUp}, false},
+}
+
+func TestEvents(t *testing.T) {
+	for _, testCase := range eventsTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			events := make(chan Event, 10)
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			userStore := inmem.NewUsers([]entity.AuthUser{})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:  "1234",
+					TokenRefresh: "2345",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithAutoVerifyUser(),
+				WithEventHandler(func(_ context.Context, event Event) error {
+					events <- event
+					if testCase.inVeto {
+						return errVetoed
+					}
+					return nil
+				}, testCase.inDelivery),
+			)
+
+			ctx := context.WithValue(context.Background(), ClientIPKey, "10.0.0.1")
+			email := "foo@bar.com"
+			userID, err := auth.SignUp(ctx, entity.AuthUser{Email: email, Password: "12345678"})
+			if testCase.inVeto != errors.Is(err, errVetoed) {
+				t.Fatalf("expected: veto=%v and got %v", testCase.inVeto, err)
+			}
+
+			if !testCase.inVeto {
+				_, _ = auth.SignIn(ctx, email, "wrong-password")
+				_, _ = auth.SignIn(ctx, email, "12345678")
+				_ = auth.SignOut(ctx, userID)
+			}
+
+			// async handlers don't keep the order of the events
+			delivered := map[EventKind]bool{}
+			for range testCase.expectKinds {
+				select {
+				case event := <-events:
+					if event.IP != "10.0.0.1" || event.CreatedAt.IsZero() {
+						t.Fatalf("expected: request metadata on the event and got %v", event)
+					}
+					delivered[event.Kind] = true
+				case <-time.After(time.Second):
+					t.Fatal("expected: events to be delivered")
+				}
+			}
+
+			for _, kind := range testCase.expectKinds {
+				if !delivered[kind] {
+					t.Fatalf("expected: event %v to be delivered", kind)
+				}
+			}
+
+			users, _ := userStore.GetAll(context.Background())
+			if (len(users) > 0) != testCase.expectUser {
+				t.Fatalf("expected: user kept=%v and got %v", testCase.expectUser, users)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ require github.com/golang-jwt/jwt v3.2.2+incompatible
 
 require (
 	github.com/go-chi/jwtauth v1.2.0
+	github.com/mattn/go-sqlite3 v1.14.22
 	golang.org/x/crypto v0.24.0
 )
 
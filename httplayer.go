@@ -2,8 +2,13 @@ package goauth
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"maps"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -18,10 +23,21 @@ const (
 	refreshTokenKey      ctxKeyAuth = "rt"
 	accessTokenExpireKey ctxKeyAuth = "ate"
 	UserIDKey            ctxKeyAuth = "user_id"
+	OrgIDKey             ctxKeyAuth = "org_id"
+	TenantIDKey          ctxKeyAuth = "tenant_id"
+	ClientIPKey          ctxKeyAuth = "client_ip"
+	ClientUserAgentKey   ctxKeyAuth = "client_user_agent"
+	rolesKey             ctxKeyAuth = "roles"
+	permissionsKey       ctxKeyAuth = "permissions"
+	orgRolesKey          ctxKeyAuth = "org_roles"
+	apiKeyScopesKey      ctxKeyAuth = "api_key_scopes"
+	OAuthClientIDKey     ctxKeyAuth = "oauth_client_id"
+	scopesKey            ctxKeyAuth = "scopes"
 )
 
 var (
-	ErrAuthUserRequired = errors.New("authenticated user is required")
+	ErrAuthUserRequired     = errors.New("authenticated user is required")
+	ErrOrganizationRequired = errors.New("active organization is required")
 )
 
 // getAccessTokenFromHeader tries to retreive the token string from the header
@@ -34,6 +50,23 @@ func getAccessTokenFromHeader(r *http.Request) string {
 	return bearer
 }
 
+// getAPIKeyFromHeader tries to retrieve the api key from the configured
+// header or from the Authorization one with the ApiKey scheme
+func (auth Auth) getAPIKeyFromHeader(r *http.Request) string {
+	if len(auth.apiKeyHeader) > 0 {
+		if key := r.Header.Get(auth.apiKeyHeader); len(key) > 0 {
+			return key
+		}
+	}
+
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.ToUpper(header[0:6]) == "APIKEY" {
+		return header[7:]
+	}
+
+	return ""
+}
+
 func getAuthTokenFromCookies(
 	r *http.Request,
 ) (string, string) {
@@ -103,6 +136,165 @@ func GetContextUserID(ctx context.Context) *uuid.UUID {
 	return &userID
 }
 
+// GetContextOrgID returns the active organization of the access token set by
+// WithAuthUserID
+func GetContextOrgID(ctx context.Context) *uuid.UUID {
+	orgIDRaw, ok := ctx.Value(OrgIDKey).(string)
+	if !ok || len(orgIDRaw) == 0 {
+		return nil
+	}
+
+	orgID, err := uuid.Parse(orgIDRaw)
+	if err != nil {
+		return nil
+	}
+
+	return &orgID
+}
+
+// GetContextTenantID returns the tenant set on the context by the app, the
+// users are looked up within it with WithTenantScopedEmails
+func GetContextTenantID(ctx context.Context) *uuid.UUID {
+	tenantIDRaw, ok := ctx.Value(TenantIDKey).(string)
+	if !ok || len(tenantIDRaw) == 0 {
+		return nil
+	}
+
+	tenantID, err := uuid.Parse(tenantIDRaw)
+	if err != nil {
+		return nil
+	}
+
+	return &tenantID
+}
+
+// GetContextOrgRoles returns the roles of the user within the active
+// organization set by WithAuthUserID
+func GetContextOrgRoles(ctx context.Context) []string {
+	roles, _ := ctx.Value(orgRolesKey).([]string)
+	return roles
+}
+
+// GetContextAPIKeyScopes returns the scopes of the api key set by
+// WithAuthUserID, nil when the request wasn't made with one
+func GetContextAPIKeyScopes(ctx context.Context) []string {
+	scopes, _ := ctx.Value(apiKeyScopesKey).([]string)
+	return scopes
+}
+
+// GetContextOAuthClientID returns the oauth client of the access token set by
+// WithAuthUserID, nil when the token wasn't issued to a client
+func GetContextOAuthClientID(ctx context.Context) *uuid.UUID {
+	clientIDRaw, ok := ctx.Value(OAuthClientIDKey).(string)
+	if !ok || len(clientIDRaw) == 0 {
+		return nil
+	}
+
+	clientID, err := uuid.Parse(clientIDRaw)
+	if err != nil {
+		return nil
+	}
+
+	return &clientID
+}
+
+// GetContextScopes returns the scopes granted to the oauth client of the
+// access token set by WithAuthUserID
+func GetContextScopes(ctx context.Context) []string {
+	scopes, _ := ctx.Value(scopesKey).([]string)
+	return scopes
+}
+
+// GetContextRoles returns the roles of the access token set by
+// WithAuthUserID
+func GetContextRoles(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey).([]string)
+	return roles
+}
+
+// GetContextPermissions returns the permissions of the access token set by
+// WithAuthUserID
+func GetContextPermissions(ctx context.Context) []string {
+	permissions, _ := ctx.Value(permissionsKey).([]string)
+	return permissions
+}
+
+// GetContextClientIP returns the ip of the request set by WithClientInfo
+func GetContextClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(ClientIPKey).(string)
+	return ip
+}
+
+// GetContextUserAgent returns the user agent of the request set by
+// WithClientInfo
+func GetContextUserAgent(ctx context.Context) string {
+	userAgent, _ := ctx.Value(ClientUserAgentKey).(string)
+	return userAgent
+}
+
+// WithClientInfo sets the ip and user agent of the request on the context to
+// be used by the rate limiter and the sessions, behind a proxy the
+// ClientIPKey should be set with the forwarded ip instead
+func WithClientInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		ctx := context.WithValue(r.Context(), ClientIPKey, ip)
+		ctx = context.WithValue(ctx, ClientUserAgentKey, r.UserAgent())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ErrorHandler writes the status matching the error, it can be used as the
+// errorHandler of WithAuthUserID or on the handlers calling the client
+func ErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	var rateLimitErr RateLimitError
+	var lockedErr AccountLockedError
+
+	switch {
+	case errors.As(err, &rateLimitErr):
+		setRetryAfter(w, rateLimitErr.RetryAfter)
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.As(err, &lockedErr):
+		setRetryAfter(w, time.Until(lockedErr.Until))
+		http.Error(w, err.Error(), http.StatusLocked)
+	case errors.Is(err, ErrForbidden),
+		errors.Is(err, ErrNotOrganizationMember),
+		errors.Is(err, ErrOrganizationRequired):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrOAuthClientNotFound),
+		errors.Is(err, ErrRedirectURIInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrUserConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrStorageRequired):
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	}
+}
+
+// setRetryAfter sets the header in seconds, rounded up
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+// getContextTokens returns the tokens of the request set by WithAuthUserID
+func getContextTokens(ctx context.Context) (string, string) {
+	access, _ := ctx.Value(accessTokenKey).(string)
+	refresh, _ := ctx.Value(refreshTokenKey).(string)
+
+	return access, refresh
+}
+
 func (auth Auth) WithAuthUserID(
 	isUserRequired bool,
 	errorHandler func(http.ResponseWriter, *http.Request, error),
@@ -118,14 +310,28 @@ func (auth Auth) WithAuthUserID(
 				return
 			}
 
+			// machine access goes through the api keys instead of the tokens
+			if auth.apiKeyStorage != nil {
+				if key := auth.getAPIKeyFromHeader(r); len(key) > 0 {
+					ctx, err := auth.withAPIKeyContext(ctx, key)
+					if err != nil {
+						errorHandler(w, r, err)
+						return
+					}
+
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
+
 			// find the tokens
 			accessToken, refreshToken := getAuthTokenFromCookies(r)
 			headerAccessToken := getAccessTokenFromHeader(r)
-			if headerAccessToken == "" {
+			if headerAccessToken != "" {
 				accessToken = headerAccessToken
 			}
 
-			newUserID, err := ValidateTokenUserID(accessToken, auth.secrets.TokenAccess)
+			claims, err := auth.parseAccessTokenClaims(ctx, accessToken)
 			if err != nil {
 				if err.Error() != ErrExpirationTime.Error() {
 					errorHandler(w, r, err)
@@ -156,8 +362,350 @@ func (auth Auth) WithAuthUserID(
 				return
 			}
 
+			// the signed token outlives the revoked sessions, the store is the
+			// one that knows if it still stands
+			if auth.tokenStorage != nil {
+				ok, err := auth.tokenStorage.AreTokensRegistered(ctx, []string{accessToken})
+				if err != nil {
+					errorHandler(w, r, err)
+					return
+				}
+
+				if !ok {
+					errorHandler(w, r, ErrTokenNotRegistered)
+					return
+				}
+			}
+
+			// the tokens of the oauth clients carry the scopes granted to them
+			if len(claims.ClientID) > 0 {
+				ctx = context.WithValue(ctx, OAuthClientIDKey, claims.ClientID)
+				ctx = context.WithValue(ctx, scopesKey, strings.Fields(claims.Scope))
+
+				// the client credentials are issued to the client itself
+				if claims.subject() == claims.ClientID {
+					if isUserRequired {
+						errorHandler(w, r, ErrAuthUserRequired)
+						return
+					}
+
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
+
+			newUserID, err := claims.userID()
+			if err != nil {
+				errorHandler(w, r, err)
+				return
+			}
+
 			ctx = context.WithValue(ctx, UserIDKey, newUserID.String())
+			ctx = context.WithValue(ctx, rolesKey, claims.Roles)
+			ctx = context.WithValue(ctx, permissionsKey, claims.Permissions)
+			if len(claims.Org) > 0 {
+				ctx = context.WithValue(ctx, OrgIDKey, claims.Org)
+				ctx = context.WithValue(ctx, orgRolesKey, claims.OrgRoles)
+			}
+			ctx = context.WithValue(ctx, accessTokenKey, accessToken)
+			ctx = context.WithValue(ctx, refreshTokenKey, refreshToken)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// RequireRoles lets the request through when the user of the access token
+// has any of the roles, it is to be used after WithAuthUserID
+func RequireRoles(
+	errorHandler func(http.ResponseWriter, *http.Request, error),
+	roles ...string,
+) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if GetContextUserID(ctx) == nil {
+				errorHandler(w, r, ErrAuthUserRequired)
+				return
+			}
+
+			if !hasAny(GetContextRoles(ctx), roles) {
+				errorHandler(w, r, ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission lets the request through when the roles of the user of
+// the access token grant the permission, it is to be used after
+// WithAuthUserID
+func RequirePermission(
+	errorHandler func(http.ResponseWriter, *http.Request, error),
+	permission string,
+) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if GetContextUserID(ctx) == nil {
+				errorHandler(w, r, ErrAuthUserRequired)
+				return
+			}
+
+			if !hasAny(GetContextPermissions(ctx), []string{permission}) {
+				errorHandler(w, r, ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireOrganization lets the request through when the access token has an
+// active organization, and the user any of the roles within it when given.
+// It is to be used after WithAuthUserID
+func RequireOrganization(
+	errorHandler func(http.ResponseWriter, *http.Request, error),
+	roles ...string,
+) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if GetContextUserID(ctx) == nil {
+				errorHandler(w, r, ErrAuthUserRequired)
+				return
+			}
+
+			if GetContextOrgID(ctx) == nil {
+				errorHandler(w, r, ErrOrganizationRequired)
+				return
+			}
+
+			if len(roles) > 0 && !hasAny(GetContextOrgRoles(ctx), roles) {
+				errorHandler(w, r, ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// oauthErrorCode maps the error to the error code of the oauth spec and its
+// status
+func oauthErrorCode(err error) (string, int) {
+	switch {
+	case errors.Is(err, ErrOAuthClientNotFound), errors.Is(err, ErrOAuthClientInvalid):
+		return "invalid_client", http.StatusUnauthorized
+	case errors.Is(err, ErrGrantInvalid), errors.Is(err, ErrRefreshTokenReused):
+		return "invalid_grant", http.StatusBadRequest
+	case errors.Is(err, ErrScopeInvalid):
+		return "invalid_scope", http.StatusBadRequest
+	case errors.Is(err, ErrGrantTypeUnsupported):
+		return "unsupported_grant_type", http.StatusBadRequest
+	case errors.Is(err, ErrResponseTypeUnsupported):
+		return "unsupported_response_type", http.StatusBadRequest
+	case errors.Is(err, ErrRedirectURIInvalid), errors.Is(err, ErrCodeChallengeInvalid):
+		return "invalid_request", http.StatusBadRequest
+	}
+
+	return "server_error", http.StatusInternalServerError
+}
+
+// AuthorizeHandler is the authorization endpoint of the oauth server, it is
+// to be used after WithAuthUserID so that the user is signed in. Until the
+// user consents, the request is redirected to consentURL with the query of
+// the authorization for the app to call GrantOAuthConsent
+func (auth Auth) AuthorizeHandler(
+	consentURL string,
+	errorHandler func(http.ResponseWriter, *http.Request, error),
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := GetContextUserID(ctx)
+		if userID == nil {
+			errorHandler(w, r, ErrAuthUserRequired)
+			return
+		}
+
+		query := r.URL.Query()
+		req := AuthorizeRequest{
+			ResponseType:        query.Get("response_type"),
+			ClientID:            query.Get("client_id"),
+			RedirectURI:         query.Get("redirect_uri"),
+			Scope:               query.Get("scope"),
+			State:               query.Get("state"),
+			CodeChallenge:       query.Get("code_challenge"),
+			CodeChallengeMethod: query.Get("code_challenge_method"),
+			Nonce:               query.Get("nonce"),
+		}
+
+		redirectURL, err := auth.Authorize(ctx, *userID, req)
+		if err == nil {
+			http.Redirect(w, r, redirectURL, http.StatusFound)
+			return
+		}
+
+		if errors.Is(err, ErrConsentRequired) {
+			http.Redirect(w, r, withQuery(consentURL, query), http.StatusFound)
+			return
+		}
+
+		// the client is only sent the error once it is known to own the uri
+		_, redirectURI, clientErr := auth.getAuthorizeClient(ctx, req)
+		if clientErr != nil {
+			errorHandler(w, r, err)
+			return
+		}
+
+		code, _ := oauthErrorCode(err)
+		params := url.Values{"error": {code}, "error_description": {err.Error()}}
+		if len(req.State) > 0 {
+			params.Set("state", req.State)
+		}
+		http.Redirect(w, r, withQuery(redirectURI, params), http.StatusFound)
+	})
+}
+
+// TokenHandler is the token endpoint of the oauth server, the clients
+// authenticate through the basic auth or the client_id and client_secret of
+// the form
+func (auth Auth) TokenHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok {
+			clientID = r.PostFormValue("client_id")
+			clientSecret = r.PostFormValue("client_secret")
+		}
+
+		result, err := auth.OAuthToken(r.Context(), OAuthTokenRequest{
+			GrantType:    r.PostFormValue("grant_type"),
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Code:         r.PostFormValue("code"),
+			RedirectURI:  r.PostFormValue("redirect_uri"),
+			CodeVerifier: r.PostFormValue("code_verifier"),
+			RefreshToken: r.PostFormValue("refresh_token"),
+			Scope:        r.PostFormValue("scope"),
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err != nil {
+			code, status := oauthErrorCode(err)
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"error":             code,
+				"error_description": err.Error(),
+			})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(result)
+	})
+}
+
+// OIDCEndpoints are the paths, relative to the base url, where the handlers
+// of the openid provider are mounted, the defaults are used when left empty
+type OIDCEndpoints struct {
+	Authorization string
+	Token         string
+	UserInfo      string
+	JWKS          string
+}
+
+// DiscoveryHandler serves the openid configuration, usually mounted on
+// /.well-known/openid-configuration, for other services to find the keys
+// the tokens are verified with
+func (auth Auth) DiscoveryHandler(endpoints OIDCEndpoints) http.Handler {
+	withDefault := func(path string, fallback string) string {
+		if len(path) == 0 {
+			path = fallback
+		}
+
+		return strings.TrimSuffix(auth.baseURL, "/") + path
+	}
+
+	configuration := map[string]any{
+		"issuer":                                auth.issuer(),
+		"authorization_endpoint":                withDefault(endpoints.Authorization, "/oauth/authorize"),
+		"token_endpoint":                        withDefault(endpoints.Token, "/oauth/token"),
+		"userinfo_endpoint":                     withDefault(endpoints.UserInfo, "/oauth/userinfo"),
+		"jwks_uri":                              withDefault(endpoints.JWKS, "/.well-known/jwks.json"),
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{GrantTypeAuthorizationCode, GrantTypeRefreshToken, GrantTypeClientCredentials},
+		"subject_types_supported":               []string{"public"},
+		"scopes_supported":                      []string{ScopeOpenID, "email"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported":                      []string{"iss", "sub", "aud", "exp", "iat", "nonce", "email", "email_verified"},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the keys can change with their rotation
+		document := maps.Clone(configuration)
+		document["id_token_signing_alg_values_supported"] = auth.signingAlgorithms()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(document)
+	})
+}
+
+// JWKSHandler serves the public keys of WithKeyProvider, usually mounted on
+// /.well-known/jwks.json
+func (auth Auth) JWKSHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": auth.JSONWebKeys()})
+	})
+}
+
+// UserInfoHandler is the userinfo endpoint of the openid provider, it is to
+// be used after WithAuthUserID with the access token of the openid scope
+func (auth Auth) UserInfoHandler(
+	errorHandler func(http.ResponseWriter, *http.Request, error),
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := GetContextUserID(ctx)
+		if userID == nil {
+			errorHandler(w, r, ErrAuthUserRequired)
+			return
+		}
+
+		scopes := GetContextScopes(ctx)
+		if !hasAny(scopes, []string{ScopeOpenID}) {
+			errorHandler(w, r, ErrForbidden)
+			return
+		}
+
+		if auth.userStorage == nil {
+			errorHandler(w, r, ErrStorageRequired)
+			return
+		}
+
+		info := map[string]any{"sub": userID.String()}
+		if hasAny(scopes, []string{"email"}) {
+			user, err := auth.userStorage.GetUserByID(ctx, *userID)
+			if err != nil {
+				errorHandler(w, r, err)
+				return
+			}
+
+			info["email"] = user.Email
+			info["email_verified"] = user.IsVerified
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(info)
+	})
+}
@@ -0,0 +1,286 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/sender"
+	"github.com/iamajoe/goauth/storage"
+)
+
+var (
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrInvitationNotPending = errors.New("invitation not pending")
+)
+
+// InviteUser sends an invitation to the app, the user is created once
+// accepted with AcceptInvite
+func (auth Auth) InviteUser(
+	ctx context.Context,
+	inviterID uuid.UUID,
+	email string,
+	meta map[string]string,
+) (invitation entity.Invitation, err error) {
+	defer func() { err = auth.audit(ctx, AuditActionInviteUser, inviterID, err) }()
+
+	if auth.userStorage == nil || auth.invitationStorage == nil {
+		return entity.Invitation{}, ErrStorageRequired
+	}
+
+	// there is nothing to join for the users already registered
+	err = auth.checkEmailAvailable(ctx, uuid.Nil, email)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	return auth.inviteUser(ctx, entity.Invitation{
+		InviterID: inviterID,
+		Email:     email,
+		Meta:      meta,
+	}, "")
+}
+
+// InviteUserToOrganization sends an invitation to join the organization
+// with the roles, the inviter has to be an owner or an admin of it and can't
+// grant a role ranking above its own. The users already registered are added
+// to the organization once accepted
+func (auth Auth) InviteUserToOrganization(
+	ctx context.Context,
+	inviterID uuid.UUID,
+	orgID uuid.UUID,
+	email string,
+	roles ...string,
+) (invitation entity.Invitation, err error) {
+	defer func() { err = auth.audit(ctx, AuditActionInviteUser, inviterID, err) }()
+
+	if auth.userStorage == nil ||
+		auth.invitationStorage == nil ||
+		auth.organizationStorage == nil {
+		return entity.Invitation{}, ErrStorageRequired
+	}
+
+	org, err := auth.organizationStorage.GetOrganization(ctx, orgID)
+	if errors.Is(err, storage.ErrOrganizationNotFound) {
+		return entity.Invitation{}, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	inviter, err := auth.organizationStorage.GetOrganizationMember(ctx, orgID, inviterID)
+	if errors.Is(err, storage.ErrOrganizationMemberNotFound) {
+		return entity.Invitation{}, ErrNotOrganizationMember
+	}
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	err = checkOrganizationGrant(inviter, roles)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	return auth.inviteUser(ctx, entity.Invitation{
+		InviterID:      inviterID,
+		Email:          email,
+		OrganizationID: orgID,
+		Roles:          roles,
+	}, org.Name)
+}
+
+// inviteUser registers the invitation and sends its token, the token
+// carries the invitation id while the state is kept by the storage
+func (auth Auth) inviteUser(
+	ctx context.Context,
+	invitation entity.Invitation,
+	orgName string,
+) (entity.Invitation, error) {
+	if ok, err := validateEmail(invitation.Email); !ok {
+		return entity.Invitation{}, err
+	}
+
+	inviter, err := auth.userStorage.GetUserByID(ctx, invitation.InviterID)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	invitation.ID = uuid.New()
+	token, err := auth.newKindToken(
+		entity.TokenKindInvitation,
+		invitation.ID,
+		tokenClaims{Email: invitation.Email},
+	)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	invitation.Status = entity.InvitationStatusPending
+	invitation.ExpiresAt = token.ExpiresAt
+	invitation.CreatedAt = token.CreatedAt
+
+	err = auth.invitationStorage.CreateInvitation(ctx, invitation)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	data := mapUsersToNotificationData(
+		auth.baseURL,
+		[]entity.AuthUser{{Email: invitation.Email, Meta: invitation.Meta}},
+		map[string]string{
+			"code":         token.Value,
+			"inviterEmail": inviter.Email,
+			"organization": orgName,
+		},
+	)
+	errs := sender.SendBulk(auth.senders, sender.TemplateInvitation, data)
+	if len(errs) == 0 {
+		return invitation, nil
+	}
+
+	return invitation, errors.Join(errs...)
+}
+
+// AcceptInvite takes the token sent by InviteUser or InviteUserToOrganization,
+// the user is created and verified with the password, or the invitation is
+// attached to the user already registered with the email (the password is
+// then ignored and the one of an unverified user is cleared)
+func (auth Auth) AcceptInvite(
+	ctx context.Context,
+	oneTimeToken string,
+	password string,
+) (userID uuid.UUID, err error) {
+	defer func() { err = auth.audit(ctx, AuditActionAcceptInvite, userID, err) }()
+
+	if auth.userStorage == nil || auth.invitationStorage == nil {
+		return uuid.Nil, ErrStorageRequired
+	}
+
+	invitationID, err := auth.validateTokenOfKind(ctx, entity.TokenKindInvitation, oneTimeToken)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	invitation, err := auth.invitationStorage.GetInvitation(ctx, invitationID)
+	if errors.Is(err, storage.ErrInvitationNotFound) {
+		return uuid.Nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if invitation.Status != entity.InvitationStatusPending {
+		return uuid.Nil, ErrInvitationNotPending
+	}
+
+	if time.Now().After(invitation.ExpiresAt) {
+		return uuid.Nil, ErrExpirationTime
+	}
+
+	user, _ := auth.getUserByEmail(ctx, invitation.Email)
+	if user.Email != invitation.Email {
+		if ok, err := validatePassword(password); !ok {
+			return uuid.Nil, err
+		}
+
+		user = entity.AuthUser{
+			ID:       uuid.New(),
+			Email:    invitation.Email,
+			Password: encryptPassword(password),
+			Meta:     invitation.Meta,
+			TenantID: auth.getContextTenantID(ctx),
+		}
+		err = auth.createInvitedUser(ctx, user)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	} else if !user.IsVerified {
+		// the password of an unverified user could have been set by someone
+		// else ahead of the owner of the email, as such, it isn't kept
+		err = auth.userStorage.UpdateUserPassword(ctx, user.ID, "")
+		if err != nil {
+			return user.ID, err
+		}
+	}
+
+	// the invitation was sent to the email so it is verified
+	if !user.IsVerified {
+		err = auth.userStorage.VerifyUser(ctx, user.ID)
+		if err != nil {
+			return user.ID, err
+		}
+	}
+
+	if invitation.OrganizationID != uuid.Nil {
+		err = auth.AddOrganizationMember(ctx, invitation.OrganizationID, user.ID, invitation.Roles...)
+		if err != nil {
+			return user.ID, err
+		}
+	}
+
+	err = auth.invitationStorage.UpdateInvitationStatus(
+		ctx,
+		invitation.ID,
+		entity.InvitationStatusAccepted,
+	)
+
+	return user.ID, err
+}
+
+// createInvitedUser registers the user of the invitation as a sign up would
+func (auth Auth) createInvitedUser(ctx context.Context, user entity.AuthUser) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionSignUp, user.ID, err) }()
+
+	err = auth.userStorage.CreateUser(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	// a vetoed sign up doesn't keep the user
+	err = auth.emit(ctx, Event{Kind: EventUserSignedUp, UserID: user.ID, Email: user.Email})
+	if err != nil {
+		return errors.Join(err, auth.userStorage.DeleteUser(ctx, user.ID))
+	}
+
+	return nil
+}
+
+// RevokeInvitation cancels the invitation while it is pending
+func (auth Auth) RevokeInvitation(ctx context.Context, invitationID uuid.UUID) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRevokeInvitation, uuid.Nil, err) }()
+
+	if auth.invitationStorage == nil {
+		return ErrStorageRequired
+	}
+
+	invitation, err := auth.invitationStorage.GetInvitation(ctx, invitationID)
+	if errors.Is(err, storage.ErrInvitationNotFound) {
+		return ErrInvitationNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if invitation.Status != entity.InvitationStatusPending {
+		return ErrInvitationNotPending
+	}
+
+	return auth.invitationStorage.UpdateInvitationStatus(
+		ctx,
+		invitation.ID,
+		entity.InvitationStatusRevoked,
+	)
+}
+
+// GetInvitations returns the invitations to the organization, uuid.Nil
+// returns the ones to the app. The expired ones are kept, their ExpiresAt
+// has passed
+func (auth Auth) GetInvitations(ctx context.Context, orgID uuid.UUID) ([]entity.Invitation, error) {
+	if auth.invitationStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	return auth.invitationStorage.GetInvitations(ctx, orgID)
+}
@@ -0,0 +1,132 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var invitationsTests = []struct {
+	description   string
+	inEmail       string
+	inOrg         bool
+	inInviterRole string
+	inRole        string
+	inRevoke      bool
+	inExpire      bool
+	expectError   error
+}{
+	{"new user", "new@bar.com", false, "", "", false, false, nil},
+	{"new user to organization", "new@bar.com", true, OrganizationRoleOwner, "viewer", false, false, nil},
+	{"existing user to organization", "nofoo@bar.com", true, OrganizationRoleOwner, "viewer", false, false, nil},
+	{"unverified user to organization", "unverified@bar.com", true, OrganizationRoleOwner, "viewer", false, false, nil},
+	{"existing user to app", "nofoo@bar.com", false, "", "", false, false, ErrUserConflict},
+	{"admin inviting an admin", "new@bar.com", true, OrganizationRoleAdmin, OrganizationRoleAdmin, false, false, nil},
+	{"admin inviting an owner", "new@bar.com", true, OrganizationRoleAdmin, OrganizationRoleOwner, false, false, ErrForbidden},
+	{"member inviting an owner", "new@bar.com", true, "viewer", OrganizationRoleOwner, false, false, ErrForbidden},
+	{"member inviting a member", "new@bar.com", true, "viewer", "viewer", false, false, ErrForbidden},
+	{"revoked", "new@bar.com", false, "", "", true, false, ErrInvitationNotPending},
+	{"expired", "new@bar.com", false, "", "", false, true, ErrExpirationTime},
+}
+
+func TestInvitations(t *testing.T) {
+	for _, testCase := range invitationsTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			adminID := uuid.New()
+			invitationStore := inmem.NewInvitations([]entity.Invitation{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: adminID, Email: "foo@bar.com", Password: encryptPassword("1234"), IsVerified: true},
+				{ID: uuid.New(), Email: "nofoo@bar.com", Password: encryptPassword("4321"), IsVerified: true},
+				{ID: uuid.New(), Email: "unverified@bar.com", Password: encryptPassword("5432")},
+			})
+			notifications := &senderRecorder{}
+			auth := New(
+				AuthSecrets{
+					TokenAccess:     "1234",
+					TokenRefresh:    "2345",
+					TokenInvitation: "3456",
+				},
+				WithTokenStorage(inmem.NewTokens([]entity.Token{})),
+				WithUserStorage(userStore),
+				WithOrganizationStorage(inmem.NewOrganizations(
+					[]entity.Organization{},
+					[]entity.OrganizationMember{},
+				)),
+				WithInvitationStorage(invitationStore),
+				WithSender(notifications),
+			)
+
+			ctx := context.Background()
+			var invitation entity.Invitation
+			var orgID uuid.UUID
+			var err error
+			if testCase.inOrg {
+				org, _ := auth.CreateOrganization(ctx, "acme", adminID)
+				orgID = org.ID
+				_ = auth.AddOrganizationMember(ctx, orgID, adminID, testCase.inInviterRole)
+				invitation, err = auth.InviteUserToOrganization(ctx, adminID, orgID, testCase.inEmail, testCase.inRole)
+			} else {
+				invitation, err = auth.InviteUser(ctx, adminID, testCase.inEmail, map[string]string{"name": "new"})
+			}
+			if err != nil {
+				if !errors.Is(err, testCase.expectError) {
+					t.Fatalf("expected: %v and got %v", testCase.expectError, err)
+				}
+				return
+			}
+
+			if len(notifications.sent) != 1 || notifications.sent[0]["email"] != testCase.inEmail {
+				t.Fatalf("expected: the invitation to be sent and got %v", notifications.sent)
+			}
+
+			if testCase.inRevoke {
+				err = auth.RevokeInvitation(ctx, invitation.ID)
+				if err != nil {
+					t.Fatalf("expected: non error on revoke and got %v", err)
+				}
+			}
+
+			if testCase.inExpire {
+				invitations, _ := invitationStore.GetAll(ctx)
+				invitations[0].ExpiresAt = time.Now().Add(-time.Minute)
+			}
+
+			userID, err := auth.AcceptInvite(ctx, notifications.sent[0]["code"], "12345678")
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("expected: %v and got %v", testCase.expectError, err)
+			}
+
+			if testCase.expectError != nil {
+				return
+			}
+
+			user, _ := userStore.GetUserByID(ctx, userID)
+			if user.Email != testCase.inEmail || !user.IsVerified {
+				t.Fatalf("expected: verified %s and got %v", testCase.inEmail, user)
+			}
+
+			// the password set before the email was verified isn't kept
+			if testCase.inEmail == "unverified@bar.com" && len(user.Password) > 0 {
+				t.Fatal("expected: the password of the unverified user to be cleared")
+			}
+
+			if testCase.inOrg {
+				member, err := auth.organizationStorage.GetOrganizationMember(ctx, orgID, userID)
+				if err != nil || len(member.Roles) != 1 || member.Roles[0] != testCase.inRole {
+					t.Fatalf("expected: %s member and got %v, %v", testCase.inRole, member, err)
+				}
+			}
+
+			// the invitation can only be accepted once
+			_, err = auth.AcceptInvite(ctx, notifications.sent[0]["code"], "12345678")
+			if !errors.Is(err, ErrInvitationNotPending) {
+				t.Fatalf("expected: %v and got %v", ErrInvitationNotPending, err)
+			}
+		})
+	}
+}
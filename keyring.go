@@ -0,0 +1,289 @@
+package goauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+var ErrSigningKeyUnsupported = errors.New("signing key unsupported")
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+
+	rsaKeyBits = 2048
+)
+
+// SigningKey is a key the access and id tokens are signed with, the key is
+// a *rsa.PrivateKey, a *ecdsa.PrivateKey on the P-256 curve or a
+// ed25519.PrivateKey
+type SigningKey struct {
+	ID        string
+	Key       crypto.Signer
+	CreatedAt time.Time
+	// SignsFrom is when the key starts signing, until then it is only
+	// published for the verifiers to fetch it. Zero signs right away
+	SignsFrom time.Time
+	// RetiresAt is when the tokens signed with the key stop being accepted,
+	// zero while there is no date for it
+	RetiresAt time.Time
+}
+
+// Algorithm returns the jwt algorithm of the key
+func (k SigningKey) Algorithm() string {
+	method, err := k.method()
+	if err != nil {
+		return ""
+	}
+
+	return method.Alg()
+}
+
+func (k SigningKey) method() (jwt.SigningMethod, error) {
+	switch key := k.Key.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return nil, ErrSigningKeyUnsupported
+		}
+		return jwt.SigningMethodES256, nil
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+
+	return nil, ErrSigningKeyUnsupported
+}
+
+func (k SigningKey) sign(claims jwt.Claims) (string, error) {
+	method, err := k.method()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = k.ID
+
+	return token.SignedString(k.Key)
+}
+
+func (k SigningKey) isRetired(now time.Time) bool {
+	return !k.RetiresAt.IsZero() && !now.Before(k.RetiresAt)
+}
+
+func (k SigningKey) isSigning(now time.Time) bool {
+	return !now.Before(k.SignsFrom) && !k.isRetired(now)
+}
+
+// GenerateSigningKey generates a key of the algorithm with a random id, to be
+// kept in memory only, the tokens it signs don't survive a restart
+func GenerateSigningKey(algorithm string) (SigningKey, error) {
+	var key crypto.Signer
+	var err error
+
+	switch algorithm {
+	case AlgorithmRS256:
+		key, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return SigningKey{}, ErrSigningKeyUnsupported
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	return SigningKey{ID: uuid.NewString(), Key: key, CreatedAt: time.Now()}, nil
+}
+
+// ParseSigningKey parses the PEM private key, either PKCS #8, PKCS #1 for the
+// RSA keys or SEC 1 for the ECDSA ones
+func ParseSigningKey(kid string, rawPEM []byte) (SigningKey, error) {
+	block, _ := pem.Decode(rawPEM)
+	if block == nil {
+		return SigningKey{}, ErrSigningKeyUnsupported
+	}
+
+	var parsed any
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	key, ok := parsed.(crypto.Signer)
+	if !ok {
+		return SigningKey{}, ErrSigningKeyUnsupported
+	}
+
+	signingKey := SigningKey{ID: kid, Key: key, CreatedAt: time.Now()}
+	if _, err := signingKey.method(); err != nil {
+		return SigningKey{}, err
+	}
+
+	return signingKey, nil
+}
+
+// LoadSigningKey reads the PEM file of the key, see ParseSigningKey
+func LoadSigningKey(kid string, path string) (SigningKey, error) {
+	rawPEM, err := os.ReadFile(path)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	return ParseSigningKey(kid, rawPEM)
+}
+
+// KeyProvider provides the keys the access and id tokens are signed and
+// verified with
+type KeyProvider interface {
+	// SigningKey returns the key the new tokens are signed with
+	SigningKey() (SigningKey, error)
+	// VerificationKeys returns the keys the tokens are accepted with, the
+	// signing one included
+	VerificationKeys() []SigningKey
+}
+
+// KeyRing is a KeyProvider that keeps the keys in memory, the newest key that
+// started signing and isn't retired is the one signing
+type KeyRing struct {
+	mu   sync.RWMutex
+	keys []SigningKey
+}
+
+// NewKeyRing creates the ring with the keys, the first one being the newest
+func NewKeyRing(keys ...SigningKey) *KeyRing {
+	return &KeyRing{keys: keys}
+}
+
+func (r *KeyRing) SigningKey() (SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range r.keys {
+		if key.isSigning(now) {
+			return key, nil
+		}
+	}
+
+	return SigningKey{}, ErrSigningKeyRequired
+}
+
+func (r *KeyRing) VerificationKeys() []SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	keys := []SigningKey{}
+	for _, key := range r.keys {
+		if !key.isRetired(now) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// Add makes the key the one signing once its SignsFrom is reached, the others
+// are still accepted until they are retired
+func (r *KeyRing) Add(key SigningKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = append([]SigningKey{key}, r.keys...)
+}
+
+// Retire stops accepting the key at the date, when it is the one signing the
+// next key takes its place
+func (r *KeyRing) Retire(kid string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, key := range r.keys {
+		if key.ID == kid {
+			r.keys[i].RetiresAt = at
+		}
+	}
+}
+
+// Rotate adds a new key of the algorithm, it is published for the grace
+// period before it signs so that the verifiers caching the keys fetch it
+// first. The previous ones retire a grace period after the new key signs, it
+// should be at least the expiration of the access tokens and of the caches of
+// the keys so that no one is signed out by the rotation
+func (r *KeyRing) Rotate(algorithm string, gracePeriod time.Duration) (SigningKey, error) {
+	key, err := GenerateSigningKey(algorithm)
+	if err != nil {
+		return key, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	key.SignsFrom = now.Add(gracePeriod)
+	keys := []SigningKey{key}
+	for _, previous := range r.keys {
+		// the retired keys aren't of use anymore
+		if previous.isRetired(now) {
+			continue
+		}
+
+		if previous.RetiresAt.IsZero() {
+			previous.RetiresAt = key.SignsFrom.Add(gracePeriod)
+		}
+		keys = append(keys, previous)
+	}
+	r.keys = keys
+
+	return key, nil
+}
+
+// StartRotation rotates the keys every interval until the context is done,
+// a failed rotation keeps the current key until the next one
+func (r *KeyRing) StartRotation(
+	ctx context.Context,
+	algorithm string,
+	interval time.Duration,
+	gracePeriod time.Duration,
+) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, _ = r.Rotate(algorithm, gracePeriod)
+			}
+		}
+	}()
+}
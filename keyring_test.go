@@ -0,0 +1,171 @@
+package goauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+)
+
+var keyRingRotationTests = []struct {
+	description string
+	inAlgorithm string
+	expectKty   string
+}{
+	{"rsa", AlgorithmRS256, "RSA"},
+	{"ecdsa", AlgorithmES256, "EC"},
+	{"ed25519", AlgorithmEdDSA, "OKP"},
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	for _, testCase := range keyRingRotationTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			key, err := GenerateSigningKey(testCase.inAlgorithm)
+			if err != nil {
+				t.Fatalf("expected: non error generating the key and got %v", err)
+			}
+
+			ring := NewKeyRing(key)
+			auth := New(AuthSecrets{TokenAccess: "1234"}, WithKeyProvider(ring))
+			userID := uuid.New()
+
+			before, err := auth.newKindToken(entity.TokenKindAccess, userID, tokenClaims{})
+			if err != nil {
+				t.Fatalf("expected: non error signing and got %v", err)
+			}
+
+			parsed, _, _ := new(jwt.Parser).ParseUnverified(before.Value, &tokenClaims{})
+			if parsed.Method.Alg() != testCase.inAlgorithm || parsed.Header["kid"] != key.ID {
+				t.Fatalf("expected: %s with the kid %s and got %v", testCase.inAlgorithm, key.ID, parsed.Header)
+			}
+
+			jwks := auth.JSONWebKeys()
+			if len(jwks) != 1 || jwks[0].KeyType != testCase.expectKty || jwks[0].Algorithm != testCase.inAlgorithm {
+				t.Fatalf("expected: the %s key on the jwks and got %v", testCase.expectKty, jwks)
+			}
+
+			rotated, err := ring.Rotate(testCase.inAlgorithm, time.Hour)
+			if err != nil {
+				t.Fatalf("expected: non error rotating and got %v", err)
+			}
+
+			// the rotated key is published ahead of signing
+			if len(auth.JSONWebKeys()) != 2 {
+				t.Fatalf("expected: both keys on the jwks and got %v", auth.JSONWebKeys())
+			}
+
+			published, err := auth.newKindToken(entity.TokenKindAccess, userID, tokenClaims{})
+			if err != nil {
+				t.Fatalf("expected: non error signing and got %v", err)
+			}
+
+			parsed, _, _ = new(jwt.Parser).ParseUnverified(published.Value, &tokenClaims{})
+			if parsed.Header["kid"] != key.ID {
+				t.Fatalf("expected: the previous kid %s within the grace period and got %v", key.ID, parsed.Header)
+			}
+
+			// the grace period is over, the rotated key signs
+			ring.keys[0].SignsFrom = time.Now()
+			after, err := auth.newKindToken(entity.TokenKindAccess, userID, tokenClaims{})
+			if err != nil {
+				t.Fatalf("expected: non error signing and got %v", err)
+			}
+
+			parsed, _, _ = new(jwt.Parser).ParseUnverified(after.Value, &tokenClaims{})
+			if parsed.Header["kid"] != rotated.ID || rotated.ID == key.ID {
+				t.Fatalf("expected: the rotated kid %s and got %v", rotated.ID, parsed.Header)
+			}
+
+			// the tokens of the previous key are accepted until it retires
+			for _, token := range []string{before.Value, after.Value} {
+				id, err := auth.validateAccessTokenUserID(ctx, token)
+				if err != nil || id != userID {
+					t.Fatalf("expected: the token to be accepted after the rotation and got %v", err)
+				}
+			}
+
+			ring.Retire(key.ID, time.Now())
+			if _, err := auth.validateAccessTokenUserID(ctx, before.Value); err == nil {
+				t.Fatal("expected: the token of the retired key to be rejected")
+			}
+
+			if _, err := auth.validateAccessTokenUserID(ctx, after.Value); err != nil {
+				t.Fatalf("expected: the token of the current key to be accepted and got %v", err)
+			}
+
+			if len(auth.JSONWebKeys()) != 1 {
+				t.Fatalf("expected: only the current key on the jwks and got %v", auth.JSONWebKeys())
+			}
+
+			// the tokens signed with the secret are refused with a key provider
+			hmacToken, _ := NewToken(entity.TokenKindAccess, userID, "1234", time.Minute)
+			if _, err := auth.validateAccessTokenUserID(ctx, hmacToken.Value); err == nil {
+				t.Fatal("expected: the token of the secret to be refused")
+			}
+		})
+	}
+}
+
+func mustMarshalPKCS8(t *testing.T, key any) []byte {
+	raw, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("expected: non error marshaling the key and got %v", err)
+	}
+
+	return raw
+}
+
+func TestLoadSigningKey(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecRawKey, _ := x509.MarshalECPrivateKey(ecKey)
+	p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edKey, _ := GenerateSigningKey(AlgorithmEdDSA)
+
+	tests := []struct {
+		description     string
+		inType          string
+		inBytes         []byte
+		expectAlgorithm string
+		expectErr       error
+	}{
+		{"rsa pkcs1", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), AlgorithmRS256, nil},
+		{"rsa pkcs8", "PRIVATE KEY", mustMarshalPKCS8(t, rsaKey), AlgorithmRS256, nil},
+		{"ecdsa sec1", "EC PRIVATE KEY", ecRawKey, AlgorithmES256, nil},
+		{"ecdsa pkcs8", "PRIVATE KEY", mustMarshalPKCS8(t, ecKey), AlgorithmES256, nil},
+		{"ed25519 pkcs8", "PRIVATE KEY", mustMarshalPKCS8(t, edKey.Key), AlgorithmEdDSA, nil},
+		{"ecdsa p384", "PRIVATE KEY", mustMarshalPKCS8(t, p384Key), "", ErrSigningKeyUnsupported},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "key.pem")
+			rawPEM := pem.EncodeToMemory(&pem.Block{Type: testCase.inType, Bytes: testCase.inBytes})
+			if err := os.WriteFile(path, rawPEM, 0o600); err != nil {
+				t.Fatalf("expected: non error writing the key and got %v", err)
+			}
+
+			key, err := LoadSigningKey("test", path)
+			if !errors.Is(err, testCase.expectErr) {
+				t.Fatalf("expected: %v and got %v", testCase.expectErr, err)
+			}
+
+			if key.Algorithm() != testCase.expectAlgorithm {
+				t.Fatalf("expected: %s and got %s", testCase.expectAlgorithm, key.Algorithm())
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package goauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+)
+
+var ErrSigningKeyRequired = errors.New("signing key is required")
+
+// JSONWebKey is the public part of a signing key as published on the jwks
+type JSONWebKey struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+func newJSONWebKey(key SigningKey) JSONWebKey {
+	jwk := JSONWebKey{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm()}
+	encode := base64.RawURLEncoding.EncodeToString
+
+	switch publicKey := key.Key.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = encode(publicKey.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = publicKey.Curve.Params().Name
+		jwk.X = encode(publicKey.X.FillBytes(make([]byte, size)))
+		jwk.Y = encode(publicKey.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = encode(publicKey)
+	}
+
+	return jwk
+}
+
+// JSONWebKeys returns the public keys the tokens are verified with, none when
+// the tokens are only signed with the secrets
+func (auth Auth) JSONWebKeys() []JSONWebKey {
+	jwks := []JSONWebKey{}
+	if auth.keyProvider == nil {
+		return jwks
+	}
+
+	for _, key := range auth.keyProvider.VerificationKeys() {
+		jwks = append(jwks, newJSONWebKey(key))
+	}
+
+	return jwks
+}
+
+// signingAlgorithms returns the algorithms of the keys the tokens are
+// verified with
+func (auth Auth) signingAlgorithms() []string {
+	algorithms := []string{}
+	for _, jwk := range auth.JSONWebKeys() {
+		if !hasAny(algorithms, []string{jwk.Algorithm}) {
+			algorithms = append(algorithms, jwk.Algorithm)
+		}
+	}
+
+	return algorithms
+}
+
+// parseAccessTokenClaims verifies the access token against the key on its
+// header. Once a key provider is set the tokens signed with the secret are
+// refused, the secret isn't meant to vouch for access tokens anymore
+func (auth Auth) parseAccessTokenClaims(ctx context.Context, rawToken string) (tokenClaims, error) {
+	if auth.keyProvider == nil {
+		return auth.parseKindTokenClaims(ctx, entity.TokenKindAccess, rawToken)
+	}
+
+	claims, err := parseTokenClaimsWithKey(rawToken, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		for _, key := range auth.keyProvider.VerificationKeys() {
+			// the algorithm is the one of the key, not the one of the header
+			if key.ID == kid && key.Algorithm() == t.Method.Alg() {
+				return key.Key.Public(), nil
+			}
+		}
+
+		return nil, ErrTokenInvalid
+	})
+	if err != nil && !errors.Is(err, ErrExpirationTime) {
+		return claims, err
+	}
+
+	checkErr := auth.checkTokenClaims(entity.TokenKindAccess, claims)
+	if checkErr != nil {
+		return tokenClaims{}, checkErr
+	}
+
+	return claims, err
+}
+
+// validateAccessTokenUserID is ValidateTokenUserID for the access tokens
+func (auth Auth) validateAccessTokenUserID(ctx context.Context, rawToken string) (uuid.UUID, error) {
+	claims, err := auth.parseAccessTokenClaims(ctx, rawToken)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return claims.userID()
+}
+
+// idTokenClaims are the claims of the openid id token, the email ones are
+// only set with the email scope
+type idTokenClaims struct {
+	jwt.StandardClaims
+	Nonce         string `json:"nonce,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// newIDToken issues the openid id token of the user for the client
+func (auth Auth) newIDToken(
+	ctx context.Context,
+	clientID uuid.UUID,
+	userID uuid.UUID,
+	scope string,
+	nonce string,
+) (string, error) {
+	if auth.keyProvider == nil {
+		return "", ErrSigningKeyRequired
+	}
+
+	if auth.userStorage == nil {
+		return "", ErrStorageRequired
+	}
+
+	now := time.Now()
+	_, expiringTime := getTokenKindSecretAndExpire(
+		entity.TokenKindAccess,
+		auth.secrets,
+		auth.tokenExpirationTimes,
+	)
+	claims := idTokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    auth.issuer(),
+			Subject:   userID.String(),
+			Audience:  clientID.String(),
+			ExpiresAt: now.Add(expiringTime).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+		Nonce: nonce,
+	}
+
+	if hasAny(strings.Fields(scope), []string{"email"}) {
+		user, err := auth.userStorage.GetUserByID(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+
+		claims.Email = user.Email
+		claims.EmailVerified = &user.IsVerified
+	}
+
+	key, err := auth.keyProvider.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	return key.sign(&claims)
+}
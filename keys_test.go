@@ -0,0 +1,197 @@
+package goauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/iamajoe/goauth/providers"
+)
+
+func (s oauthTestServer) getJSON(t *testing.T, path string, accessToken string) (int, map[string]any) {
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+path, nil)
+	if len(accessToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected: non error on %s and got %v", path, err)
+	}
+	defer res.Body.Close()
+
+	body := map[string]any{}
+	_ = json.NewDecoder(res.Body).Decode(&body)
+
+	return res.StatusCode, body
+}
+
+// getPublishedKey builds the public key out of the jwks of the server
+func (s oauthTestServer) getPublishedKey(t *testing.T, kid string) *rsa.PublicKey {
+	_, body := s.getJSON(t, "/.well-known/jwks.json", "")
+	keys, _ := body["keys"].([]any)
+	for _, raw := range keys {
+		key, _ := raw.(map[string]any)
+		if key["kid"] != kid {
+			continue
+		}
+
+		n, _ := base64.RawURLEncoding.DecodeString(key["n"].(string))
+		e, _ := base64.RawURLEncoding.DecodeString(key["e"].(string))
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	t.Fatalf("expected: the key %s to be published and got %v", kid, body)
+	return nil
+}
+
+var signingKeyTests = []struct {
+	description   string
+	inSigningKey  bool
+	inScope       string
+	expectKid     string
+	expectIDToken bool
+	expectEmail   bool
+	expectError   string
+}{
+	{"signed with the secret", false, "users:read", secretKeyID("1234"), false, false, ""},
+	{"signed with the key", true, "users:read", "test", false, false, ""},
+	{"openid", true, "openid users:read", "test", true, false, ""},
+	{"openid with email", true, "openid email", "test", true, true, ""},
+	{"openid without the key", false, "openid", "", false, false, "server_error"},
+}
+
+func TestSigningKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("expected: non error generating the key and got %v", err)
+	}
+
+	for _, testCase := range signingKeyTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			opts := []optFn{}
+			if testCase.inSigningKey {
+				opts = append(opts, WithSigningKey("test", key))
+			}
+
+			s := newOAuthTestServer(t, opts...)
+			_, discovery := s.getJSON(t, "/.well-known/openid-configuration", "")
+			if discovery["issuer"] != "http://localhost" ||
+				discovery["jwks_uri"] != "http://localhost/.well-known/jwks.json" ||
+				discovery["token_endpoint"] != "http://localhost/token" {
+				t.Fatalf("expected: the endpoints on the discovery and got %v", discovery)
+			}
+
+			secret, client, err := s.auth.RegisterOAuthClient(
+				ctx,
+				"client",
+				[]string{"https://client.com/cb"},
+				[]string{"openid", "email", "users:read"},
+				true,
+			)
+			if err != nil {
+				t.Fatalf("expected: non error on register and got %v", err)
+			}
+
+			err = s.auth.GrantOAuthConsent(ctx, s.userID, client.ID, []string{"openid", "email", "users:read"})
+			if err != nil {
+				t.Fatalf("expected: non error on consent and got %v", err)
+			}
+
+			verifier, _ := providers.NewCodeVerifier()
+			location := s.authorize(t, url.Values{
+				"response_type":         {"code"},
+				"client_id":             {client.ID.String()},
+				"scope":                 {testCase.inScope},
+				"state":                 {"xyz"},
+				"nonce":                 {"abc"},
+				"code_challenge":        {providers.CodeChallenge(verifier)},
+				"code_challenge_method": {"S256"},
+			})
+			if location == nil || len(location.Query().Get("code")) == 0 {
+				t.Fatalf("expected: the code on the redirect and got %v", location)
+			}
+
+			_, body := s.token(t, url.Values{
+				"grant_type":    {GrantTypeAuthorizationCode},
+				"client_id":     {client.ID.String()},
+				"client_secret": {secret},
+				"code":          {location.Query().Get("code")},
+				"code_verifier": {verifier},
+			})
+			if testCase.expectError != "" {
+				if body["error"] != testCase.expectError {
+					t.Fatalf("expected: error %s and got %v", testCase.expectError, body)
+				}
+				return
+			}
+
+			accessToken, _ := body["access_token"].(string)
+			parsed, _, err := new(jwt.Parser).ParseUnverified(accessToken, &tokenClaims{})
+			if err != nil {
+				t.Fatalf("expected: non error parsing the access token and got %v", err)
+			}
+
+			if kid, _ := parsed.Header["kid"].(string); kid != testCase.expectKid {
+				t.Fatalf("expected: the kid %s on the access token and got %v", testCase.expectKid, parsed.Header)
+			}
+
+			if testCase.inSigningKey {
+				_, err = jwt.Parse(accessToken, func(*jwt.Token) (any, error) {
+					return s.getPublishedKey(t, testCase.expectKid), nil
+				})
+				if err != nil {
+					t.Fatalf("expected: the access token to verify with the published key and got %v", err)
+				}
+			}
+
+			claims := s.api(t, accessToken)
+			if claims["user"] != s.userID.String() {
+				t.Fatalf("expected: the user on the api and got %v", claims)
+			}
+
+			idToken, _ := body["id_token"].(string)
+			if !testCase.expectIDToken {
+				if len(idToken) > 0 {
+					t.Fatalf("expected: no id token and got %s", idToken)
+				}
+				return
+			}
+
+			idClaims := jwt.MapClaims{}
+			_, err = jwt.ParseWithClaims(idToken, idClaims, func(*jwt.Token) (any, error) {
+				return s.getPublishedKey(t, testCase.expectKid), nil
+			})
+			if err != nil {
+				t.Fatalf("expected: the id token to verify with the published key and got %v", err)
+			}
+
+			if idClaims["sub"] != s.userID.String() ||
+				idClaims["aud"] != client.ID.String() ||
+				idClaims["nonce"] != "abc" {
+				t.Fatalf("expected: the standard claims on the id token and got %v", idClaims)
+			}
+
+			_, info := s.getJSON(t, "/userinfo", accessToken)
+			if info["sub"] != s.userID.String() {
+				t.Fatalf("expected: the user on the userinfo and got %v", info)
+			}
+
+			if testCase.expectEmail != (idClaims["email"] == "foo@bar.com") ||
+				testCase.expectEmail != (idClaims["email_verified"] == true) ||
+				testCase.expectEmail != (info["email"] == "foo@bar.com") {
+				t.Fatalf("expected: the email only with the email scope and got %v %v", idClaims, info)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/sender"
+	"github.com/iamajoe/goauth/storage"
+)
+
+var ErrAccountLocked = errors.New("account locked")
+
+// AccountLockedError is returned while the account is locked, it matches
+// ErrAccountLocked with errors.Is
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (err AccountLockedError) Error() string {
+	return fmt.Sprintf("%s until %s", ErrAccountLocked, err.Until.UTC().Format(time.RFC3339))
+}
+
+func (err AccountLockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+// LockoutPolicy locks the account once MaxAttempts failures happen within
+// the Window, each consecutive lock doubles the Duration up to MaxDuration
+type LockoutPolicy struct {
+	MaxAttempts int
+	Window      time.Duration
+	Duration    time.Duration
+	MaxDuration time.Duration
+}
+
+// lockDuration backs off the lock duration per the previous lockouts
+func (policy LockoutPolicy) lockDuration(lockouts int) time.Duration {
+	duration := policy.Duration
+	for i := 0; i < lockouts; i++ {
+		duration *= 2
+		if duration >= policy.MaxDuration {
+			return policy.MaxDuration
+		}
+	}
+
+	return duration
+}
+
+// getSignInAttempt resolves the user attempts, defaulting when there is none
+func (auth Auth) getSignInAttempt(ctx context.Context, userID uuid.UUID) (entity.SignInAttempt, error) {
+	attempt, err := auth.signInAttemptStorage.GetSignInAttempt(ctx, userID)
+	if errors.Is(err, storage.ErrSignInAttemptNotFound) {
+		return entity.SignInAttempt{UserID: userID}, nil
+	}
+
+	return attempt, err
+}
+
+// checkAccountLock errors with AccountLockedError while the user is locked
+func (auth Auth) checkAccountLock(ctx context.Context, userID uuid.UUID) error {
+	if auth.signInAttemptStorage == nil {
+		return nil
+	}
+
+	attempt, err := auth.getSignInAttempt(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if time.Now().Before(attempt.LockedUntil) {
+		return AccountLockedError{Until: attempt.LockedUntil}
+	}
+
+	return nil
+}
+
+// registerFailedSignIn counts the failure and locks the account if the
+// policy is reached, the error to be returned to the caller is resolved
+func (auth Auth) registerFailedSignIn(ctx context.Context, user entity.AuthUser) error {
+	if auth.signInAttemptStorage == nil || auth.lockoutPolicy.MaxAttempts <= 0 {
+		return ErrWrongCredentials
+	}
+
+	attempt, err := auth.getSignInAttempt(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if now.Sub(attempt.WindowStartAt) > auth.lockoutPolicy.Window {
+		attempt.Attempts = 0
+		attempt.WindowStartAt = now
+	}
+	attempt.Attempts += 1
+
+	isLocked := attempt.Attempts >= auth.lockoutPolicy.MaxAttempts
+	if isLocked {
+		attempt.LockedUntil = now.Add(auth.lockoutPolicy.lockDuration(attempt.Lockouts))
+		attempt.Lockouts += 1
+		attempt.Attempts = 0
+		attempt.WindowStartAt = now
+	}
+
+	err = auth.signInAttemptStorage.SaveSignInAttempt(ctx, attempt)
+	if err != nil {
+		return err
+	}
+
+	if !isLocked {
+		return ErrWrongCredentials
+	}
+
+	if auth.sendUnlockEmail {
+		err = auth.sendAccountLocked(ctx, user, attempt.LockedUntil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return AccountLockedError{Until: attempt.LockedUntil}
+}
+
+// resetFailedSignIns clears the counters once the user signs in
+func (auth Auth) resetFailedSignIns(ctx context.Context, userID uuid.UUID) error {
+	if auth.signInAttemptStorage == nil {
+		return nil
+	}
+
+	return auth.signInAttemptStorage.RemoveSignInAttempt(ctx, userID)
+}
+
+// sendAccountLocked notifies the user with a one time unlock link
+func (auth Auth) sendAccountLocked(ctx context.Context, user entity.AuthUser, lockedUntil time.Time) error {
+	if auth.tokenStorage == nil {
+		return ErrStorageRequired
+	}
+
+	token, err := auth.newKindToken(entity.TokenKindAccountUnlock, user.ID, tokenClaims{})
+	if err != nil {
+		return err
+	}
+
+	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{token})
+	if err != nil {
+		return err
+	}
+
+	data := mapUsersToNotificationData(
+		auth.baseURL,
+		[]entity.AuthUser{user},
+		map[string]string{
+			"code":        token.Value,
+			"lockedUntil": lockedUntil.UTC().Format(time.RFC3339),
+		},
+	)
+	errs := sender.SendBulk(auth.senders, sender.TemplateAccountLocked, data)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// UnlockAccount takes the token sent once the account was locked and clears
+// the lock, the token can only be used once
+func (auth Auth) UnlockAccount(ctx context.Context, oneTimeToken string) error {
+	if auth.tokenStorage == nil || auth.signInAttemptStorage == nil {
+		return ErrStorageRequired
+	}
+
+	ok, err := auth.tokenStorage.AreTokensRegistered(ctx, []string{oneTimeToken})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTokenNotRegistered
+	}
+
+	userID, err := auth.validateTokenOfKind(ctx, entity.TokenKindAccountUnlock, oneTimeToken)
+	if err != nil {
+		return err
+	}
+
+	err = auth.tokenStorage.RemoveUserToken(ctx, userID, oneTimeToken)
+	if err != nil {
+		return err
+	}
+
+	return auth.signInAttemptStorage.RemoveSignInAttempt(ctx, userID)
+}
@@ -0,0 +1,124 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var lockDurationTests = []struct {
+	description string
+	inLockouts  int
+	expected    time.Duration
+}{
+	{"first lock", 0, 15 * time.Minute},
+	{"second lock", 1, 30 * time.Minute},
+	{"third lock", 2, time.Hour},
+	{"capped", 10, 2 * time.Hour},
+}
+
+func TestLockDuration(t *testing.T) {
+	policy := LockoutPolicy{
+		MaxAttempts: 3,
+		Window:      time.Hour,
+		Duration:    15 * time.Minute,
+		MaxDuration: 2 * time.Hour,
+	}
+
+	for _, testCase := range lockDurationTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			duration := policy.lockDuration(testCase.inLockouts)
+			if duration != testCase.expected {
+				t.Fatalf("expected: %v and got %v", testCase.expected, duration)
+			}
+		})
+	}
+}
+
+var signInLockoutTests = []struct {
+	description    string
+	inFailures     int
+	inPassword     string
+	inUnlock       bool
+	expectError    error
+	expectAttempts int
+}{
+	{"under the limit", 1, "wrong", false, ErrWrongCredentials, 2},
+	{"reset on success", 2, "1234", false, nil, 0},
+	{"locked", 3, "1234", false, ErrAccountLocked, 0},
+	{"unlocked by email", 3, "1234", true, nil, 0},
+}
+
+func TestSignInLockout(t *testing.T) {
+	for _, testCase := range signInLockoutTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			userID := uuid.New()
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			attemptStore := inmem.NewSignInAttempts([]entity.SignInAttempt{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: userID, Email: "foo@bar.com", Password: encryptPassword("1234"), IsVerified: true},
+			})
+			notifications := &senderRecorder{}
+			auth := New(
+				AuthSecrets{
+					TokenAccess:        "1234",
+					TokenRefresh:       "2345",
+					TokenAccountUnlock: "3456",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithSignInAttemptStorage(attemptStore),
+				WithLockoutPolicy(LockoutPolicy{
+					MaxAttempts: 3,
+					Window:      time.Hour,
+					Duration:    time.Hour,
+					MaxDuration: 24 * time.Hour,
+				}),
+				WithUnlockEmail(),
+				WithSender(notifications),
+			)
+
+			var err error
+			for i := 0; i < testCase.inFailures; i++ {
+				_, err = auth.SignIn(context.Background(), "foo@bar.com", "wrong")
+			}
+
+			if testCase.inFailures >= 3 {
+				var lockedErr AccountLockedError
+				if !errors.As(err, &lockedErr) || !lockedErr.Until.After(time.Now()) {
+					t.Fatalf("expected: account locked error and got %v", err)
+				}
+			}
+
+			if testCase.inUnlock {
+				if len(notifications.sent) != 1 {
+					t.Fatal("expected: unlock email to be sent")
+				}
+
+				err = auth.UnlockAccount(context.Background(), notifications.sent[0]["code"])
+				if err != nil {
+					t.Fatalf("expected: non error on unlock and got %v", err)
+				}
+			}
+
+			res, err := auth.SignIn(context.Background(), "foo@bar.com", testCase.inPassword)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("expected: %v and got %v", testCase.expectError, err)
+			}
+
+			if testCase.expectError == nil && len(res.AccessToken) == 0 {
+				t.Fatal("expected: user to be signed in")
+			}
+
+			attempt, _ := auth.getSignInAttempt(context.Background(), userID)
+			if attempt.Attempts != testCase.expectAttempts {
+				t.Fatalf("expected: %d attempts and got %d", testCase.expectAttempts, attempt.Attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/sender"
+)
+
+// RequestMagicLink sends an email with a one time link for the user to sign in
+func (auth Auth) RequestMagicLink(ctx context.Context, email string) (err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionRequestMagicLink, userID, err) }()
+
+	if auth.userStorage == nil || auth.tokenStorage == nil {
+		return ErrStorageRequired
+	}
+
+	err = auth.checkRateLimit(ctx, RateLimitActionMagicLink, email)
+	if err != nil {
+		return err
+	}
+
+	user, err := auth.getUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	userID = user.ID
+
+	token, err := auth.newKindToken(entity.TokenKindMagicLink, user.ID, tokenClaims{})
+	if err != nil {
+		return err
+	}
+
+	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{token})
+	if err != nil {
+		return err
+	}
+
+	data := mapUsersToNotificationData(
+		auth.baseURL,
+		[]entity.AuthUser{user},
+		map[string]string{"code": token.Value},
+	)
+	errs := sender.SendBulk(auth.senders, sender.TemplateMagicLink, data)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// SignInWithMagicLink takes the token generated by RequestMagicLink and signs
+// the user in, the token can only be used once, the user is verified and the
+// password an unverified user had is cleared
+func (auth Auth) SignInWithMagicLink(
+	ctx context.Context,
+	oneTimeToken string,
+) (result signInResult, err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionSignInMagicLink, userID, err) }()
+
+	if auth.userStorage == nil || auth.tokenStorage == nil {
+		return result, ErrStorageRequired
+	}
+
+	ok, err := auth.tokenStorage.AreTokensRegistered(ctx, []string{oneTimeToken})
+	if err != nil {
+		return result, err
+	}
+	if !ok {
+		return result, ErrTokenNotRegistered
+	}
+
+	userID, err = auth.validateTokenOfKind(ctx, entity.TokenKindMagicLink, oneTimeToken)
+	if err != nil {
+		return result, err
+	}
+
+	err = auth.tokenStorage.RemoveUserToken(ctx, userID, oneTimeToken)
+	if err != nil {
+		return result, err
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+
+	// the link was delivered to the user email, as such, the email is verified.
+	// The password of an unverified user could have been set by someone else
+	// ahead of the owner of the email, as such, it isn't kept
+	if !user.IsVerified {
+		err = auth.userStorage.UpdateUserPassword(ctx, user.ID, "")
+		if err != nil {
+			return result, err
+		}
+
+		err = auth.userStorage.VerifyUser(ctx, user.ID)
+		if err != nil {
+			return result, err
+		}
+		user.IsVerified = true
+	}
+
+	return auth.signInUser(ctx, user)
+}
@@ -0,0 +1,96 @@
+package goauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var signInWithMagicLinkTests = []struct {
+	description        string
+	inWrongToken       bool
+	inVerified         bool
+	expectError        bool
+	expectPasswordKept bool
+}{
+	{"success", false, false, false, false},
+	{"verified user", false, true, false, true},
+	{"wrong token", true, false, true, false},
+}
+
+func TestSignInWithMagicLink(t *testing.T) {
+	for _, testCase := range signInWithMagicLinkTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			email := "foo@bar.com"
+			userID := uuid.New()
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: userID, Email: email, Password: encryptPassword("12345678"), IsVerified: testCase.inVerified},
+			})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:    "1234",
+					TokenRefresh:   "2345",
+					TokenMagicLink: "3456",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+			)
+
+			err := auth.RequestMagicLink(context.Background(), email)
+			if err != nil {
+				t.Fatalf("expected: non error on request and got %v", err)
+			}
+
+			var tokenValue string
+			if testCase.inWrongToken {
+				rawToken, _ := NewToken(
+					entity.TokenKindAccess, userID,
+					auth.secrets.TokenAccess,
+					time.Hour,
+				)
+				tokenValue = rawToken.Value
+			} else {
+				tokens, _ := tokenStore.GetAll(context.Background())
+				for _, tok := range tokens {
+					if tok.UserID == userID && tok.Kind == entity.TokenKindMagicLink {
+						tokenValue = tok.Value
+						break
+					}
+				}
+			}
+
+			res, err := auth.SignInWithMagicLink(context.Background(), tokenValue)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error and got %v", err)
+			}
+
+			if testCase.expectError {
+				t.Fatal("expected: error")
+			}
+
+			if res.UserID != userID || len(res.AccessToken) == 0 {
+				t.Fatal("expected: an AccessToken for the user")
+			}
+
+			// the password set before the email was verified isn't kept
+			_, err = auth.SignIn(context.Background(), email, "12345678")
+			if (err == nil) != testCase.expectPasswordKept {
+				t.Fatalf("expected: password kept=%v and got %v", testCase.expectPasswordKept, err)
+			}
+
+			// check if the link is single use
+			_, err = auth.SignInWithMagicLink(context.Background(), tokenValue)
+			if err == nil {
+				t.Fatal("expected: magic link to have been removed")
+			}
+		})
+	}
+}
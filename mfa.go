@@ -0,0 +1,419 @@
+package goauth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+	ErrMFANotEnrolled    = errors.New("mfa not enrolled")
+	ErrWrongMFACode      = errors.New("wrong mfa code")
+)
+
+const (
+	recoveryCodesAmount  = 10
+	recoveryCodeLen      = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+type totpEnrollResult struct {
+	Secret string
+	URI    string
+}
+
+// newRecoveryCode generates a code in the format of xxxxx-xxxxx, the bytes
+// past the last multiple of the alphabet length are rejected so that every
+// character is as likely
+func newRecoveryCode() (string, error) {
+	limit := 256 - 256%len(recoveryCodeAlphabet)
+	raw := make([]byte, recoveryCodeLen)
+	code := make([]byte, 0, recoveryCodeLen)
+	for len(code) < recoveryCodeLen {
+		if _, err := rand.Read(raw); err != nil {
+			return "", err
+		}
+
+		for _, b := range raw {
+			if int(b) < limit && len(code) < recoveryCodeLen {
+				code = append(code, recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+			}
+		}
+	}
+
+	return string(code[:recoveryCodeLen/2]) + "-" + string(code[recoveryCodeLen/2:]), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(code, " ", ""))
+	if len(code) == recoveryCodeLen && !strings.Contains(code, "-") {
+		code = code[:recoveryCodeLen/2] + "-" + code[recoveryCodeLen/2:]
+	}
+
+	return code
+}
+
+// getUserTOTPSecret decrypts the totp secret stored for the user
+func (auth Auth) getUserTOTPSecret(user entity.AuthUser) (string, error) {
+	if len(user.TOTPSecret) == 0 {
+		return "", ErrMFANotEnrolled
+	}
+
+	return decryptSecret(auth.secrets.Encryption, user.TOTPSecret)
+}
+
+// EnrollTOTP generates a new totp secret for the user, the second factor is
+// only enabled once ConfirmTOTP is called with a valid code
+func (auth Auth) EnrollTOTP(
+	ctx context.Context,
+	userID uuid.UUID,
+) (result totpEnrollResult, err error) {
+	defer func() { err = auth.audit(ctx, AuditActionEnrollTOTP, userID, err) }()
+
+	if auth.userStorage == nil {
+		return result, ErrStorageRequired
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+
+	if user.IsTOTPEnabled {
+		return result, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return result, err
+	}
+
+	encrypted, err := encryptSecret(auth.secrets.Encryption, secret)
+	if err != nil {
+		return result, err
+	}
+
+	err = auth.userStorage.UpdateUserTOTP(ctx, user.ID, encrypted, false)
+	if err != nil {
+		return result, err
+	}
+
+	// the steps used with the previous secret don't hold for the new one
+	err = auth.userStorage.UpdateUserTOTPLastStep(ctx, user.ID, 0)
+	if err != nil {
+		return result, err
+	}
+
+	result.Secret = secret
+	result.URI = totpURI(auth.serviceName, user.Email, secret)
+
+	return result, nil
+}
+
+// ConfirmTOTP enables the second factor once the user proves the
+// authenticator app is set with a valid code, the recovery codes are
+// generated then so that an abandoned enrollment doesn't replace them
+func (auth Auth) ConfirmTOTP(
+	ctx context.Context,
+	userID uuid.UUID,
+	code string,
+) (recoveryCodes []string, err error) {
+	defer func() { err = auth.audit(ctx, AuditActionConfirmTOTP, userID, err) }()
+
+	if auth.userStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsTOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	// the code used to confirm can't be replayed to sign in
+	err = auth.verifyTOTPCode(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	err = auth.userStorage.UpdateUserTOTP(ctx, user.ID, user.TOTPSecret, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if auth.recoveryCodeStorage == nil {
+		return nil, nil
+	}
+
+	return auth.generateRecoveryCodes(ctx, user.ID)
+}
+
+// DisableTOTP removes the second factor, a valid code is required
+func (auth Auth) DisableTOTP(ctx context.Context, userID uuid.UUID, code string) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionDisableTOTP, userID, err) }()
+
+	if auth.userStorage == nil {
+		return ErrStorageRequired
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsTOTPEnabled {
+		return ErrMFANotEnrolled
+	}
+
+	err = auth.verifyTOTPCode(ctx, user, code)
+	if err != nil {
+		return err
+	}
+
+	if auth.recoveryCodeStorage != nil {
+		err = auth.recoveryCodeStorage.RemoveUserRecoveryCodes(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return auth.userStorage.UpdateUserTOTP(ctx, user.ID, "", false)
+}
+
+// SignInVerifyMFA takes the challenge returned by SignIn and the totp code
+// and issues the access and refresh tokens. The wrong codes count towards the
+// lockout of the account and a code is only accepted once
+func (auth Auth) SignInVerifyMFA(
+	ctx context.Context,
+	challenge string,
+	code string,
+) (result signInResult, err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionSignInVerifyMFA, userID, err) }()
+
+	if auth.userStorage == nil || auth.tokenStorage == nil {
+		return result, ErrStorageRequired
+	}
+
+	user, err := auth.getMFAChallengeUser(ctx, challenge)
+	if err != nil {
+		return result, err
+	}
+	userID = user.ID
+
+	err = auth.checkAccountLock(ctx, user.ID)
+	if err != nil {
+		return result, err
+	}
+
+	err = auth.verifyTOTPCode(ctx, user, code)
+	if errors.Is(err, ErrWrongMFACode) {
+		return result, auth.registerFailedMFA(ctx, user)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	err = auth.completeMFA(ctx, user.ID, challenge)
+	if err != nil {
+		return result, err
+	}
+
+	return auth.issueSignInTokens(ctx, user.ID)
+}
+
+// verifyTOTPCode checks the totp code of the user, the time step of the code
+// is kept so that a code is only accepted once
+func (auth Auth) verifyTOTPCode(ctx context.Context, user entity.AuthUser, code string) error {
+	secret, err := auth.getUserTOTPSecret(user)
+	if err != nil {
+		return err
+	}
+
+	step, ok := matchTOTPStep(secret, code, time.Now())
+	if !ok || step <= user.TOTPLastStep {
+		return ErrWrongMFACode
+	}
+
+	return auth.userStorage.UpdateUserTOTPLastStep(ctx, user.ID, step)
+}
+
+// registerFailedMFA counts the wrong code as a failed sign in, the error of
+// the wrong code is kept unless the account gets locked
+func (auth Auth) registerFailedMFA(ctx context.Context, user entity.AuthUser) error {
+	err := auth.registerFailedSignIn(ctx, user)
+	if errors.Is(err, ErrWrongCredentials) {
+		return ErrWrongMFACode
+	}
+
+	return err
+}
+
+// completeMFA burns the challenge and clears the failed attempts, these are
+// only cleared once the second factor passed
+func (auth Auth) completeMFA(ctx context.Context, userID uuid.UUID, challenge string) error {
+	err := auth.tokenStorage.RemoveUserToken(ctx, userID, challenge)
+	if err != nil {
+		return err
+	}
+
+	return auth.resetFailedSignIns(ctx, userID)
+}
+
+// SignInVerifyRecoveryCode takes the challenge returned by SignIn and one of
+// the recovery codes, the code is burnt once used
+func (auth Auth) SignInVerifyRecoveryCode(
+	ctx context.Context,
+	challenge string,
+	code string,
+) (result signInResult, err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionSignInRecoveryCode, userID, err) }()
+
+	if auth.userStorage == nil || auth.tokenStorage == nil || auth.recoveryCodeStorage == nil {
+		return result, ErrStorageRequired
+	}
+
+	user, err := auth.getMFAChallengeUser(ctx, challenge)
+	if err != nil {
+		return result, err
+	}
+	userID = user.ID
+
+	err = auth.checkAccountLock(ctx, user.ID)
+	if err != nil {
+		return result, err
+	}
+
+	codes, err := auth.recoveryCodeStorage.GetUserRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return result, err
+	}
+
+	code = normalizeRecoveryCode(code)
+	matched := ""
+	for _, c := range codes {
+		if comparePassword(c.Code, code) {
+			matched = c.Code
+			break
+		}
+	}
+
+	if len(matched) == 0 {
+		return result, auth.registerFailedMFA(ctx, user)
+	}
+
+	err = auth.recoveryCodeStorage.RemoveUserRecoveryCode(ctx, user.ID, matched)
+	if err != nil {
+		return result, err
+	}
+
+	err = auth.completeMFA(ctx, user.ID, challenge)
+	if err != nil {
+		return result, err
+	}
+
+	return auth.issueSignInTokens(ctx, user.ID)
+}
+
+// RegenerateRecoveryCodes invalidates the previous recovery codes and
+// returns a new set, these are only shown once
+func (auth Auth) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) (_ []string, err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRegenerateRecoveryCodes, userID, err) }()
+
+	if auth.userStorage == nil || auth.recoveryCodeStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsTOTPEnabled {
+		return nil, ErrMFANotEnrolled
+	}
+
+	return auth.generateRecoveryCodes(ctx, user.ID)
+}
+
+// CountRecoveryCodes returns how many recovery codes are still usable
+func (auth Auth) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	if auth.recoveryCodeStorage == nil {
+		return 0, ErrStorageRequired
+	}
+
+	codes, err := auth.recoveryCodeStorage.GetUserRecoveryCodes(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(codes), nil
+}
+
+// generateRecoveryCodes replaces the user codes, only the hashes are stored
+func (auth Auth) generateRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	err := auth.recoveryCodeStorage.RemoveUserRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	plain := make([]string, recoveryCodesAmount)
+	codes := make([]entity.RecoveryCode, recoveryCodesAmount)
+	for i := range plain {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		plain[i] = code
+		codes[i] = entity.RecoveryCode{
+			UserID:    userID,
+			Code:      encryptPassword(code),
+			CreatedAt: time.Now(),
+		}
+	}
+
+	err = auth.recoveryCodeStorage.CreateRecoveryCodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	return plain, nil
+}
+
+// getMFAChallengeUser validates the challenge and resolves its user
+func (auth Auth) getMFAChallengeUser(ctx context.Context, challenge string) (entity.AuthUser, error) {
+	ok, err := auth.tokenStorage.AreTokensRegistered(ctx, []string{challenge})
+	if err != nil {
+		return entity.AuthUser{}, err
+	}
+	if !ok {
+		return entity.AuthUser{}, ErrTokenNotRegistered
+	}
+
+	userID, err := auth.validateTokenOfKind(ctx, entity.TokenKindMFAChallenge, challenge)
+	if err != nil {
+		return entity.AuthUser{}, err
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return entity.AuthUser{}, err
+	}
+
+	if !user.IsTOTPEnabled {
+		return entity.AuthUser{}, ErrMFANotEnrolled
+	}
+
+	return user, nil
+}
@@ -0,0 +1,323 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var enrollTOTPTests = []struct {
+	description     string
+	inWrongCode     bool
+	inEncryptionKey string
+	expectError     bool
+}{
+	{"success", false, "5678", false},
+	{"wrong code", true, "5678", true},
+	{"missing encryption key", false, "", true},
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	for _, testCase := range enrollTOTPTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			userID := uuid.New()
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: userID, Email: "foo@bar.com", Password: encryptPassword("12345678")},
+			})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:       "1234",
+					TokenRefresh:      "2345",
+					TokenMFAChallenge: "3456",
+					Encryption:        testCase.inEncryptionKey,
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+			)
+
+			enrollment, err := auth.EnrollTOTP(context.Background(), userID)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error on enroll and got %v", err)
+			}
+
+			code, _ := generateTOTPCode(enrollment.Secret, time.Now())
+			if testCase.inWrongCode {
+				code = "000000"
+			}
+
+			_, err = auth.ConfirmTOTP(context.Background(), userID, code)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error on confirm and got %v", err)
+			}
+
+			if testCase.expectError {
+				t.Fatal("expected: error")
+			}
+
+			user, _ := userStore.GetUserByID(context.Background(), userID)
+			if !user.IsTOTPEnabled {
+				t.Fatal("expected: totp to be enabled")
+			}
+
+			if user.TOTPSecret == enrollment.Secret {
+				t.Fatal("expected: totp secret to be encrypted on storage")
+			}
+		})
+	}
+}
+
+var signInVerifyMFATests = []struct {
+	description   string
+	inWrongCode   bool
+	inConfirmCode bool
+	expectError   bool
+}{
+	{"success", false, false, false},
+	{"wrong code", true, false, true},
+	{"code used to confirm", false, true, true},
+}
+
+func TestSignInVerifyMFA(t *testing.T) {
+	for _, testCase := range signInVerifyMFATests {
+		t.Run(testCase.description, func(t *testing.T) {
+			email := "foo@bar.com"
+			password := "12345678"
+			userID := uuid.New()
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: userID, Email: email, Password: encryptPassword(password), IsVerified: true},
+			})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:       "1234",
+					TokenRefresh:      "2345",
+					TokenMFAChallenge: "3456",
+					Encryption:        "5678",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+			)
+
+			// the steps can't be used twice, the confirmation takes the previous one
+			enrollment, _ := auth.EnrollTOTP(context.Background(), userID)
+			confirmCode, _ := generateTOTPCode(enrollment.Secret, time.Now().Add(-totpPeriod))
+			_, _ = auth.ConfirmTOTP(context.Background(), userID, confirmCode)
+			code, _ := generateTOTPCode(enrollment.Secret, time.Now())
+
+			challenge, err := auth.SignIn(context.Background(), email, password)
+			if err != nil {
+				t.Fatalf("expected: non error on sign in and got %v", err)
+			}
+
+			if len(challenge.AccessToken) != 0 || len(challenge.MFAChallenge) == 0 {
+				t.Fatal("expected: a challenge instead of the tokens")
+			}
+
+			if testCase.inWrongCode {
+				code = "000000"
+			}
+			if testCase.inConfirmCode {
+				code = confirmCode
+			}
+
+			res, err := auth.SignInVerifyMFA(context.Background(), challenge.MFAChallenge, code)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error and got %v", err)
+			}
+
+			if testCase.expectError {
+				t.Fatal("expected: error")
+			}
+
+			if len(res.AccessToken) == 0 || len(res.RefreshToken) == 0 {
+				t.Fatal("expected: access and refresh tokens")
+			}
+
+			// check if the challenge can't be reused
+			_, err = auth.SignInVerifyMFA(context.Background(), challenge.MFAChallenge, code)
+			if err == nil {
+				t.Fatal("expected: challenge to have been removed")
+			}
+		})
+	}
+}
+
+var signInVerifyMFAAttemptsTests = []struct {
+	description  string
+	inWrongCodes int
+	inReplay     bool
+	expectError  error
+}{
+	{"under the limit", 1, false, nil},
+	{"locked", 2, false, ErrAccountLocked},
+	{"replayed code", 0, true, ErrWrongMFACode},
+}
+
+func TestSignInVerifyMFAAttempts(t *testing.T) {
+	for _, testCase := range signInVerifyMFAAttemptsTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			email := "foo@bar.com"
+			password := "12345678"
+			userID := uuid.New()
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			attemptStore := inmem.NewSignInAttempts([]entity.SignInAttempt{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: userID, Email: email, Password: encryptPassword(password), IsVerified: true},
+			})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:       "1234",
+					TokenRefresh:      "2345",
+					TokenMFAChallenge: "3456",
+					Encryption:        "5678",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithSignInAttemptStorage(attemptStore),
+				WithLockoutPolicy(LockoutPolicy{
+					MaxAttempts: 2,
+					Window:      time.Hour,
+					Duration:    time.Hour,
+					MaxDuration: 24 * time.Hour,
+				}),
+			)
+
+			// the steps can't be used twice, the confirmation takes the previous one
+			enrollment, _ := auth.EnrollTOTP(ctx, userID)
+			confirmCode, _ := generateTOTPCode(enrollment.Secret, time.Now().Add(-totpPeriod))
+			_, _ = auth.ConfirmTOTP(ctx, userID, confirmCode)
+			code, _ := generateTOTPCode(enrollment.Secret, time.Now())
+
+			challenge, err := auth.SignIn(ctx, email, password)
+			if err != nil {
+				t.Fatalf("expected: non error on sign in and got %v", err)
+			}
+
+			for i := 0; i < testCase.inWrongCodes; i++ {
+				_, _ = auth.SignInVerifyMFA(ctx, challenge.MFAChallenge, "000000")
+			}
+
+			if testCase.inReplay {
+				_, err = auth.SignInVerifyMFA(ctx, challenge.MFAChallenge, code)
+				if err != nil {
+					t.Fatalf("expected: non error on the first use and got %v", err)
+				}
+
+				challenge, err = auth.SignIn(ctx, email, password)
+				if err != nil {
+					t.Fatalf("expected: non error on sign in and got %v", err)
+				}
+			}
+
+			_, err = auth.SignInVerifyMFA(ctx, challenge.MFAChallenge, code)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("expected: %v and got %v", testCase.expectError, err)
+			}
+		})
+	}
+}
+
+var signInVerifyRecoveryCodeTests = []struct {
+	description string
+	inWrongCode bool
+	expectError bool
+}{
+	{"success", false, false},
+	{"wrong code", true, true},
+}
+
+func TestSignInVerifyRecoveryCode(t *testing.T) {
+	for _, testCase := range signInVerifyRecoveryCodeTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			email := "foo@bar.com"
+			password := "12345678"
+			userID := uuid.New()
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			recoveryCodeStore := inmem.NewRecoveryCodes([]entity.RecoveryCode{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: userID, Email: email, Password: encryptPassword(password), IsVerified: true},
+			})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:       "1234",
+					TokenRefresh:      "2345",
+					TokenMFAChallenge: "3456",
+					Encryption:        "5678",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithRecoveryCodeStorage(recoveryCodeStore),
+			)
+
+			enrollment, _ := auth.EnrollTOTP(context.Background(), userID)
+			code, _ := generateTOTPCode(enrollment.Secret, time.Now())
+			recoveryCodes, _ := auth.ConfirmTOTP(context.Background(), userID, code)
+
+			if len(recoveryCodes) != recoveryCodesAmount {
+				t.Fatalf(
+					"expected: recovery codes=%v\ngot: %v",
+					recoveryCodesAmount,
+					len(recoveryCodes),
+				)
+			}
+
+			recoveryCode := recoveryCodes[0]
+			if testCase.inWrongCode {
+				recoveryCode = "aaaaa-aaaaa"
+			}
+
+			challenge, _ := auth.SignIn(context.Background(), email, password)
+			res, err := auth.SignInVerifyRecoveryCode(
+				context.Background(),
+				challenge.MFAChallenge,
+				recoveryCode,
+			)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error and got %v", err)
+			}
+
+			if testCase.expectError {
+				t.Fatal("expected: error")
+			}
+
+			if len(res.AccessToken) == 0 {
+				t.Fatal("expected: an AccessToken")
+			}
+
+			count, _ := auth.CountRecoveryCodes(context.Background(), userID)
+			if count != recoveryCodesAmount-1 {
+				t.Fatalf("expected: remaining codes=%v\ngot: %v", recoveryCodesAmount-1, count)
+			}
+
+			// check if the code was burnt
+			challenge, _ = auth.SignIn(context.Background(), email, password)
+			_, err = auth.SignInVerifyRecoveryCode(
+				context.Background(),
+				challenge.MFAChallenge,
+				recoveryCode,
+			)
+			if err == nil {
+				t.Fatal("expected: recovery code to have been burnt")
+			}
+		})
+	}
+}
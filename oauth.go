@@ -0,0 +1,673 @@
+package goauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/providers"
+	"github.com/iamajoe/goauth/storage"
+)
+
+var (
+	ErrOAuthClientNotFound     = errors.New("oauth client not found")
+	ErrOAuthClientInvalid      = errors.New("oauth client invalid")
+	ErrRedirectURIInvalid      = errors.New("redirect uri invalid")
+	ErrScopeInvalid            = errors.New("scope invalid")
+	ErrConsentRequired         = errors.New("consent required")
+	ErrCodeChallengeInvalid    = errors.New("code challenge invalid")
+	ErrGrantInvalid            = errors.New("grant invalid")
+	ErrGrantTypeUnsupported    = errors.New("grant type unsupported")
+	ErrResponseTypeUnsupported = errors.New("response type unsupported")
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+
+	// ScopeOpenID asks for the id token of the user along the access token
+	ScopeOpenID = "openid"
+
+	oauthClientSecretBytes = 32
+)
+
+// AuthorizeRequest is the query of the authorization endpoint, only the code
+// response type with a S256 code challenge is supported
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce is set on the id token of the openid scope
+	Nonce string
+}
+
+// OAuthTokenRequest is the form of the token endpoint
+type OAuthTokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+}
+
+type OAuthTokenResult struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+func hashOAuthClientSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// hasAll checks that every target is within the values
+func hasAll(values []string, targets []string) bool {
+	for _, target := range targets {
+		if !hasAny(values, []string{target}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getRequestScopes returns the scopes requested out of the ones allowed, all
+// of the allowed when none is requested
+func getRequestScopes(allowed []string, scope string) ([]string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return allowed, nil
+	}
+
+	if !hasAll(allowed, scopes) {
+		return nil, ErrScopeInvalid
+	}
+
+	return scopes, nil
+}
+
+// RegisterOAuthClient registers a third party to access the api on behalf of
+// the users, the secret of the confidential clients is only returned here.
+// Public clients, for example mobile apps, can't keep a secret and rely on
+// the code challenge alone. The audit records the client as the user
+func (auth Auth) RegisterOAuthClient(
+	ctx context.Context,
+	name string,
+	redirectURIs []string,
+	scopes []string,
+	isConfidential bool,
+) (secret string, client entity.OAuthClient, err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRegisterOAuthClient, client.ID, err) }()
+
+	if auth.oauthStorage == nil {
+		return "", entity.OAuthClient{}, ErrStorageRequired
+	}
+
+	if len(redirectURIs) == 0 {
+		return "", entity.OAuthClient{}, ErrRedirectURIInvalid
+	}
+
+	for _, redirectURI := range redirectURIs {
+		parsed, err := url.Parse(redirectURI)
+		if err != nil || !parsed.IsAbs() || len(parsed.Fragment) > 0 {
+			return "", entity.OAuthClient{}, ErrRedirectURIInvalid
+		}
+	}
+
+	client = entity.OAuthClient{
+		ID:             uuid.New(),
+		Name:           name,
+		RedirectURIs:   redirectURIs,
+		Scopes:         scopes,
+		IsConfidential: isConfidential,
+		CreatedAt:      time.Now(),
+	}
+
+	if isConfidential {
+		raw := make([]byte, oauthClientSecretBytes)
+		if _, err := rand.Read(raw); err != nil {
+			return "", entity.OAuthClient{}, err
+		}
+
+		secret = base64.RawURLEncoding.EncodeToString(raw)
+		client.SecretHash = hashOAuthClientSecret(secret)
+	}
+
+	err = auth.emit(ctx, Event{Kind: EventOAuthClientRegistered, ClientID: client.ID})
+	if err != nil {
+		return "", entity.OAuthClient{}, err
+	}
+
+	err = auth.oauthStorage.CreateOAuthClient(ctx, client)
+	if err != nil {
+		return "", entity.OAuthClient{}, err
+	}
+
+	return secret, client, nil
+}
+
+// RemoveOAuthClient removes the client, the consents given to it and every
+// token issued to it. The audit records the client as the user
+func (auth Auth) RemoveOAuthClient(ctx context.Context, clientID uuid.UUID) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRemoveOAuthClient, clientID, err) }()
+
+	if auth.oauthStorage == nil {
+		return ErrStorageRequired
+	}
+
+	err = auth.emit(ctx, Event{Kind: EventOAuthClientRemoved, ClientID: clientID})
+	if err != nil {
+		return err
+	}
+
+	err = auth.oauthStorage.RemoveOAuthClient(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	// without a token storage there is no token issued to the client
+	if auth.tokenStorage == nil {
+		return nil
+	}
+
+	return auth.tokenStorage.RemoveClientTokens(ctx, clientID)
+}
+
+// GetOAuthClient returns the client, for example to show it on the consent
+func (auth Auth) GetOAuthClient(ctx context.Context, clientID uuid.UUID) (entity.OAuthClient, error) {
+	if auth.oauthStorage == nil {
+		return entity.OAuthClient{}, ErrStorageRequired
+	}
+
+	client, err := auth.oauthStorage.GetOAuthClient(ctx, clientID)
+	if errors.Is(err, storage.ErrOAuthClientNotFound) {
+		return client, ErrOAuthClientNotFound
+	}
+
+	return client, err
+}
+
+// getAuthorizeClient resolves the client of the request and the redirect uri
+// to send the user back to, the errors here can't be redirected
+func (auth Auth) getAuthorizeClient(
+	ctx context.Context,
+	req AuthorizeRequest,
+) (entity.OAuthClient, string, error) {
+	clientID, err := uuid.Parse(req.ClientID)
+	if err != nil {
+		return entity.OAuthClient{}, "", ErrOAuthClientNotFound
+	}
+
+	client, err := auth.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		return client, "", err
+	}
+
+	// the redirect uri can only be left out when there is no doubt about it
+	if len(req.RedirectURI) == 0 {
+		if len(client.RedirectURIs) != 1 {
+			return client, "", ErrRedirectURIInvalid
+		}
+
+		return client, client.RedirectURIs[0], nil
+	}
+
+	if !hasAny(client.RedirectURIs, []string{req.RedirectURI}) {
+		return client, "", ErrRedirectURIInvalid
+	}
+
+	return client, req.RedirectURI, nil
+}
+
+// Authorize issues the code for the client on behalf of the user and returns
+// where to redirect the user with it, ErrConsentRequired is returned until
+// the user grants the scopes through GrantOAuthConsent
+func (auth Auth) Authorize(ctx context.Context, userID uuid.UUID, req AuthorizeRequest) (string, error) {
+	if auth.oauthStorage == nil || auth.tokenStorage == nil {
+		return "", ErrStorageRequired
+	}
+
+	client, redirectURI, err := auth.getAuthorizeClient(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	if req.ResponseType != "code" {
+		return "", ErrResponseTypeUnsupported
+	}
+
+	if len(req.CodeChallenge) == 0 || req.CodeChallengeMethod != "S256" {
+		return "", ErrCodeChallengeInvalid
+	}
+
+	scopes, err := getRequestScopes(client.Scopes, req.Scope)
+	if err != nil {
+		return "", err
+	}
+
+	consent, err := auth.oauthStorage.GetOAuthConsent(ctx, userID, client.ID)
+	if errors.Is(err, storage.ErrOAuthConsentNotFound) {
+		return "", ErrConsentRequired
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !hasAll(consent.Scopes, scopes) {
+		return "", ErrConsentRequired
+	}
+
+	code, err := auth.newKindToken(
+		entity.TokenKindAuthorizationCode,
+		userID,
+		tokenClaims{
+			ClientID:      client.ID.String(),
+			Scope:         strings.Join(scopes, " "),
+			RedirectURI:   req.RedirectURI,
+			CodeChallenge: req.CodeChallenge,
+			Nonce:         req.Nonce,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{code})
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{"code": {code.Value}}
+	if len(req.State) > 0 {
+		params.Set("state", req.State)
+	}
+
+	return withQuery(redirectURI, params), nil
+}
+
+// withQuery adds the params to the query of the url
+func withQuery(rawURL string, params url.Values) string {
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+
+	return rawURL + separator + params.Encode()
+}
+
+// GrantOAuthConsent records the user granting the scopes to the client, the
+// previously granted ones are kept
+func (auth Auth) GrantOAuthConsent(
+	ctx context.Context,
+	userID uuid.UUID,
+	clientID uuid.UUID,
+	scopes []string,
+) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionGrantOAuthConsent, userID, err) }()
+
+	if auth.oauthStorage == nil {
+		return ErrStorageRequired
+	}
+
+	client, err := auth.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	if !hasAll(client.Scopes, scopes) {
+		return ErrScopeInvalid
+	}
+
+	consent, err := auth.oauthStorage.GetOAuthConsent(ctx, userID, clientID)
+	if err != nil && !errors.Is(err, storage.ErrOAuthConsentNotFound) {
+		return err
+	}
+
+	granted := consent.Scopes
+	for _, scope := range scopes {
+		if !hasAny(granted, []string{scope}) {
+			granted = append(granted, scope)
+		}
+	}
+
+	err = auth.emit(ctx, Event{Kind: EventOAuthConsentGranted, UserID: userID, ClientID: clientID})
+	if err != nil {
+		return err
+	}
+
+	return auth.oauthStorage.SaveOAuthConsent(ctx, entity.OAuthConsent{
+		UserID:    userID,
+		ClientID:  clientID,
+		Scopes:    granted,
+		CreatedAt: time.Now(),
+	})
+}
+
+// RevokeOAuthConsent removes the consent of the user and the tokens the
+// client holds on behalf of the user
+func (auth Auth) RevokeOAuthConsent(
+	ctx context.Context,
+	userID uuid.UUID,
+	clientID uuid.UUID,
+) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRevokeOAuthConsent, userID, err) }()
+
+	if auth.oauthStorage == nil {
+		return ErrStorageRequired
+	}
+
+	err = auth.emit(ctx, Event{Kind: EventOAuthConsentRevoked, UserID: userID, ClientID: clientID})
+	if err != nil {
+		return err
+	}
+
+	err = auth.oauthStorage.RemoveOAuthConsent(ctx, userID, clientID)
+	if err != nil {
+		return err
+	}
+
+	// without a token storage there is no token issued to the client
+	if auth.tokenStorage == nil {
+		return nil
+	}
+
+	return auth.tokenStorage.RemoveUserClientTokens(ctx, userID, clientID)
+}
+
+// ListOAuthConsents returns the clients the user granted access to
+func (auth Auth) ListOAuthConsents(ctx context.Context, userID uuid.UUID) ([]entity.OAuthConsent, error) {
+	if auth.oauthStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	return auth.oauthStorage.GetUserOAuthConsents(ctx, userID)
+}
+
+// authenticateOAuthClient resolves the client of the token request, the
+// confidential ones have to present their secret
+func (auth Auth) authenticateOAuthClient(
+	ctx context.Context,
+	rawClientID string,
+	secret string,
+) (entity.OAuthClient, error) {
+	clientID, err := uuid.Parse(rawClientID)
+	if err != nil {
+		return entity.OAuthClient{}, ErrOAuthClientNotFound
+	}
+
+	client, err := auth.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		return client, err
+	}
+
+	if !client.IsConfidential {
+		return client, nil
+	}
+
+	hash := hashOAuthClientSecret(secret)
+	if subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hash)) != 1 {
+		return client, ErrOAuthClientInvalid
+	}
+
+	return client, nil
+}
+
+// OAuthToken is the token endpoint of the authorization server, it takes the
+// authorization_code, refresh_token and client_credentials grants
+func (auth Auth) OAuthToken(ctx context.Context, req OAuthTokenRequest) (OAuthTokenResult, error) {
+	if auth.oauthStorage == nil || auth.tokenStorage == nil {
+		return OAuthTokenResult{}, ErrStorageRequired
+	}
+
+	client, err := auth.authenticateOAuthClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		return auth.exchangeAuthorizationCode(ctx, client, req)
+	case GrantTypeRefreshToken:
+		return auth.refreshOAuthToken(ctx, client, req)
+	case GrantTypeClientCredentials:
+		return auth.issueClientCredentialsToken(ctx, client, req)
+	}
+
+	return OAuthTokenResult{}, ErrGrantTypeUnsupported
+}
+
+// getGrantToken resolves the registered token of the grant, the code or the
+// refresh token, and its claims
+func (auth Auth) getGrantToken(
+	ctx context.Context,
+	kind entity.TokenKind,
+	value string,
+) (entity.Token, tokenClaims, error) {
+	token, err := auth.tokenStorage.GetToken(ctx, value)
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		return token, tokenClaims{}, ErrGrantInvalid
+	}
+	if err != nil {
+		return token, tokenClaims{}, err
+	}
+
+	if token.Kind != kind {
+		return token, tokenClaims{}, ErrGrantInvalid
+	}
+
+	claims, err := auth.parseKindTokenClaims(ctx, kind, value)
+	if err != nil {
+		return token, claims, ErrGrantInvalid
+	}
+
+	return token, claims, nil
+}
+
+func (auth Auth) exchangeAuthorizationCode(
+	ctx context.Context,
+	client entity.OAuthClient,
+	req OAuthTokenRequest,
+) (OAuthTokenResult, error) {
+	code, claims, err := auth.getGrantToken(ctx, entity.TokenKindAuthorizationCode, req.Code)
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	// the code is single use, even when the exchange fails
+	err = auth.tokenStorage.RemoveUserToken(ctx, code.UserID, req.Code)
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	if claims.ClientID != client.ID.String() || claims.RedirectURI != req.RedirectURI {
+		return OAuthTokenResult{}, ErrGrantInvalid
+	}
+
+	challenge := providers.CodeChallenge(req.CodeVerifier)
+	if subtle.ConstantTimeCompare([]byte(challenge), []byte(claims.CodeChallenge)) != 1 {
+		return OAuthTokenResult{}, ErrGrantInvalid
+	}
+
+	scopes := strings.Fields(claims.Scope)
+	result, err := auth.issueOAuthTokens(ctx, client, code.UserID, scopes, uuid.New())
+	if err != nil || !hasAny(scopes, []string{ScopeOpenID}) {
+		return result, err
+	}
+
+	result.IDToken, err = auth.newIDToken(ctx, client.ID, code.UserID, claims.Scope, claims.Nonce)
+	return result, err
+}
+
+func (auth Auth) refreshOAuthToken(
+	ctx context.Context,
+	client entity.OAuthClient,
+	req OAuthTokenRequest,
+) (OAuthTokenResult, error) {
+	refreshToken, claims, err := auth.getGrantToken(ctx, entity.TokenKindRefresh, req.RefreshToken)
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	if claims.ClientID != client.ID.String() {
+		return OAuthTokenResult{}, ErrGrantInvalid
+	}
+
+	// the token was already rotated, someone other than the client has it
+	if refreshToken.IsRevoked {
+		err = auth.tokenStorage.RemoveTokenFamily(ctx, refreshToken.FamilyID)
+		if err != nil {
+			return OAuthTokenResult{}, err
+		}
+
+		return OAuthTokenResult{}, ErrRefreshTokenReused
+	}
+
+	scopes, err := getRequestScopes(strings.Fields(claims.Scope), req.Scope)
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	// the user may have revoked the access of the client since
+	consent, err := auth.oauthStorage.GetOAuthConsent(ctx, refreshToken.UserID, client.ID)
+	if errors.Is(err, storage.ErrOAuthConsentNotFound) {
+		return OAuthTokenResult{}, ErrGrantInvalid
+	}
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	if !hasAll(consent.Scopes, scopes) {
+		return OAuthTokenResult{}, ErrGrantInvalid
+	}
+
+	// the old refresh token is kept as revoked to detect its reuse
+	err = auth.tokenStorage.RevokeToken(ctx, req.RefreshToken)
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	return auth.issueOAuthTokens(ctx, client, refreshToken.UserID, scopes, refreshToken.FamilyID)
+}
+
+// issueOAuthTokens issues the tokens of the client on behalf of the user, the
+// permissions are the ones of the user within the scopes
+func (auth Auth) issueOAuthTokens(
+	ctx context.Context,
+	client entity.OAuthClient,
+	userID uuid.UUID,
+	scopes []string,
+	familyID uuid.UUID,
+) (OAuthTokenResult, error) {
+	claims, err := auth.getAccessClaims(ctx, userID, uuid.Nil)
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	permissions := []string{}
+	for _, permission := range claims.Permissions {
+		if hasAny(scopes, []string{permission}) {
+			permissions = append(permissions, permission)
+		}
+	}
+	claims.Permissions = permissions
+	// the scopes only grant permissions, the roles and the custom claims of
+	// the user would let the client through checks it wasn't granted
+	claims.Roles = nil
+	claims.OrgRoles = nil
+	claims.Custom = nil
+	claims.ClientID = client.ID.String()
+	claims.Scope = strings.Join(scopes, " ")
+
+	accessToken, err := auth.newKindToken(entity.TokenKindAccess, userID, claims)
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	refreshToken, err := auth.newKindToken(
+		entity.TokenKindRefresh,
+		userID,
+		tokenClaims{ClientID: claims.ClientID, Scope: claims.Scope},
+	)
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+	accessToken.FamilyID = familyID
+	refreshToken.FamilyID = familyID
+	accessToken.ClientID = client.ID
+	refreshToken.ClientID = client.ID
+
+	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{accessToken, refreshToken})
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	return OAuthTokenResult{
+		AccessToken:  accessToken.Value,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(auth.tokenExpirationTimes.Access.Seconds()),
+		RefreshToken: refreshToken.Value,
+		Scope:        claims.Scope,
+	}, nil
+}
+
+// issueClientCredentialsToken issues the token of the client acting on its
+// own behalf, the client is set as the subject instead of an user
+func (auth Auth) issueClientCredentialsToken(
+	ctx context.Context,
+	client entity.OAuthClient,
+	req OAuthTokenRequest,
+) (OAuthTokenResult, error) {
+	if !client.IsConfidential {
+		return OAuthTokenResult{}, ErrOAuthClientInvalid
+	}
+
+	scopes, err := getRequestScopes(client.Scopes, req.Scope)
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	accessToken, err := auth.newKindToken(
+		entity.TokenKindAccess,
+		client.ID,
+		tokenClaims{ClientID: client.ID.String(), Scope: strings.Join(scopes, " ")},
+	)
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+	accessToken.ClientID = client.ID
+
+	// registered like the other access tokens so that removing the client
+	// revokes it
+	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{accessToken})
+	if err != nil {
+		return OAuthTokenResult{}, err
+	}
+
+	return OAuthTokenResult{
+		AccessToken: accessToken.Value,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(auth.tokenExpirationTimes.Access.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
@@ -0,0 +1,474 @@
+package goauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/providers"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+type oauthTestServer struct {
+	auth   *Auth
+	server *httptest.Server
+	userID uuid.UUID
+}
+
+func newOAuthTestServer(t *testing.T, opts ...optFn) oauthTestServer {
+	userID := uuid.New()
+	auth := New(
+		AuthSecrets{
+			TokenAccess:            "1234",
+			TokenRefresh:           "2345",
+			TokenAuthorizationCode: "3456",
+		},
+		append([]optFn{
+			WithTokenStorage(inmem.NewTokens([]entity.Token{})),
+			WithUserStorage(inmem.NewUsers([]entity.AuthUser{
+				{ID: userID, Email: "foo@bar.com", IsVerified: true},
+			})),
+			WithOAuthStorage(inmem.NewOAuth([]entity.OAuthClient{}, []entity.OAuthConsent{})),
+			WithRoleStorage(inmem.NewRoles(
+				[]entity.Role{{Name: "admin", Permissions: []string{"users:read", "users:write"}}},
+				[]entity.UserRole{{UserID: userID, Role: "admin"}},
+			)),
+		}, opts...)...,
+	)
+
+	// the user is taken as signed in on the authorization endpoint
+	signedIn := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), UserIDKey, userID.String())
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/authorize", signedIn(auth.AuthorizeHandler("/consent", ErrorHandler)))
+	mux.Handle("/token", auth.TokenHandler())
+	mux.Handle("/userinfo", auth.WithAuthUserID(true, ErrorHandler)(auth.UserInfoHandler(ErrorHandler)))
+	mux.Handle("/.well-known/openid-configuration", auth.DiscoveryHandler(OIDCEndpoints{
+		Authorization: "/authorize",
+		Token:         "/token",
+		UserInfo:      "/userinfo",
+	}))
+	mux.Handle("/.well-known/jwks.json", auth.JWKSHandler())
+	mux.Handle("/api", auth.WithAuthUserID(false, ErrorHandler)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"user":        GetContextUserID(ctx),
+				"client":      GetContextOAuthClientID(ctx),
+				"scopes":      GetContextScopes(ctx),
+				"permissions": GetContextPermissions(ctx),
+				"roles":       GetContextRoles(ctx),
+			})
+		}),
+	))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return oauthTestServer{auth: auth, server: server, userID: userID}
+}
+
+// authorize requests the authorization endpoint and returns the redirect, if
+// any
+func (s oauthTestServer) authorize(t *testing.T, query url.Values) *url.URL {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	res, err := client.Get(s.server.URL + "/authorize?" + query.Encode())
+	if err != nil {
+		t.Fatalf("expected: non error on authorize and got %v", err)
+	}
+	defer res.Body.Close()
+
+	// the errors that can't be redirected are answered right away
+	location, err := res.Location()
+	if err != nil {
+		return nil
+	}
+
+	return location
+}
+
+func (s oauthTestServer) token(t *testing.T, form url.Values) (int, map[string]any) {
+	res, err := http.PostForm(s.server.URL+"/token", form)
+	if err != nil {
+		t.Fatalf("expected: non error on token and got %v", err)
+	}
+	defer res.Body.Close()
+
+	body := map[string]any{}
+	_ = json.NewDecoder(res.Body).Decode(&body)
+
+	return res.StatusCode, body
+}
+
+func (s oauthTestServer) api(t *testing.T, accessToken string) map[string]any {
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+"/api", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected: non error on the api and got %v", err)
+	}
+	defer res.Body.Close()
+
+	body := map[string]any{}
+	_ = json.NewDecoder(res.Body).Decode(&body)
+
+	return body
+}
+
+var oauthAuthorizationCodeTests = []struct {
+	description     string
+	inConfidential  bool
+	inConsent       []string
+	inScope         string
+	inRedirectURI   string
+	inWrongVerifier bool
+	inWrongSecret   bool
+	inReuseCode     bool
+	expectRedirect  string
+	expectError     string
+}{
+	{"confidential client", true, []string{"users:read"}, "users:read", "", false, false, false, "https://client.com/cb", ""},
+	{"public client", false, []string{"users:read"}, "users:read", "", false, false, false, "https://client.com/cb", ""},
+	{"consent required", true, nil, "users:read", "", false, false, false, "/consent", ""},
+	{"consent of other scopes", true, []string{"users:read"}, "users:write", "", false, false, false, "/consent", ""},
+	{"scope not allowed", true, []string{"users:read"}, "admin", "", false, false, false, "https://client.com/cb", "invalid_scope"},
+	{"unknown redirect uri", true, []string{"users:read"}, "users:read", "https://evil.com/cb", false, false, false, "", ""},
+	{"wrong verifier", true, []string{"users:read"}, "users:read", "", true, false, false, "https://client.com/cb", "invalid_grant"},
+	{"wrong secret", true, []string{"users:read"}, "users:read", "", false, true, false, "https://client.com/cb", "invalid_client"},
+	{"reused code", true, []string{"users:read"}, "users:read", "", false, false, true, "https://client.com/cb", "invalid_grant"},
+}
+
+func TestOAuthAuthorizationCode(t *testing.T) {
+	for _, testCase := range oauthAuthorizationCodeTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			s := newOAuthTestServer(t)
+			secret, client, err := s.auth.RegisterOAuthClient(
+				ctx,
+				"client",
+				[]string{"https://client.com/cb"},
+				[]string{"users:read", "users:write"},
+				testCase.inConfidential,
+			)
+			if err != nil {
+				t.Fatalf("expected: non error on register and got %v", err)
+			}
+
+			if testCase.inConsent != nil {
+				err = s.auth.GrantOAuthConsent(ctx, s.userID, client.ID, testCase.inConsent)
+				if err != nil {
+					t.Fatalf("expected: non error on consent and got %v", err)
+				}
+			}
+
+			verifier, _ := providers.NewCodeVerifier()
+			location := s.authorize(t, url.Values{
+				"response_type":         {"code"},
+				"client_id":             {client.ID.String()},
+				"redirect_uri":          {testCase.inRedirectURI},
+				"scope":                 {testCase.inScope},
+				"state":                 {"xyz"},
+				"code_challenge":        {providers.CodeChallenge(verifier)},
+				"code_challenge_method": {"S256"},
+			})
+			if testCase.expectRedirect == "" {
+				if location != nil {
+					t.Fatalf("expected: no redirect and got %v", location)
+				}
+				return
+			}
+
+			if location == nil {
+				t.Fatalf("expected: redirect to %s and got none", testCase.expectRedirect)
+			}
+
+			redirect := location.Scheme + "://" + location.Host + location.Path
+			if strings.HasPrefix(redirect, s.server.URL) {
+				redirect = location.Path
+			}
+			if redirect != testCase.expectRedirect {
+				t.Fatalf("expected: redirect to %s and got %v", testCase.expectRedirect, location)
+			}
+
+			query := location.Query()
+			if len(query.Get("error")) > 0 || redirect == "/consent" {
+				if query.Get("error") != testCase.expectError {
+					t.Fatalf("expected: error %s and got %v", testCase.expectError, query)
+				}
+				return
+			}
+
+			if query.Get("state") != "xyz" || len(query.Get("code")) == 0 {
+				t.Fatalf("expected: the code and state on the redirect and got %v", query)
+			}
+
+			if testCase.inWrongVerifier {
+				verifier = "wrong"
+			}
+			if testCase.inWrongSecret {
+				secret = "wrong"
+			}
+			form := url.Values{
+				"grant_type":    {GrantTypeAuthorizationCode},
+				"client_id":     {client.ID.String()},
+				"client_secret": {secret},
+				"code":          {query.Get("code")},
+				"code_verifier": {verifier},
+			}
+			if testCase.inReuseCode {
+				_, _ = s.token(t, form)
+			}
+
+			status, body := s.token(t, form)
+			if testCase.expectError != "" {
+				if body["error"] != testCase.expectError {
+					t.Fatalf("expected: error %s and got %d %v", testCase.expectError, status, body)
+				}
+				return
+			}
+
+			if status != http.StatusOK || body["scope"] != testCase.inScope {
+				t.Fatalf("expected: the tokens and got %d %v", status, body)
+			}
+
+			claims := s.api(t, body["access_token"].(string))
+			if claims["user"] != s.userID.String() || claims["client"] != client.ID.String() {
+				t.Fatalf("expected: the user and client on the api and got %v", claims)
+			}
+
+			permissions, _ := claims["permissions"].([]any)
+			if len(permissions) != 1 || permissions[0] != "users:read" {
+				t.Fatalf("expected: the permissions within the scopes and got %v", claims)
+			}
+
+			if roles, _ := claims["roles"].([]any); len(roles) != 0 {
+				t.Fatalf("expected: no roles on the client token and got %v", claims)
+			}
+
+			// the refresh token can't be used as the session of the user
+			_, err = s.auth.RefreshToken(ctx, body["access_token"].(string), body["refresh_token"].(string))
+			if err == nil {
+				t.Fatal("expected: the client refresh token to be rejected by RefreshToken")
+			}
+
+			form = url.Values{
+				"grant_type":    {GrantTypeRefreshToken},
+				"client_id":     {client.ID.String()},
+				"client_secret": {secret},
+				"refresh_token": {body["refresh_token"].(string)},
+			}
+			status, refreshed := s.token(t, form)
+			if status != http.StatusOK || refreshed["access_token"] == body["access_token"] {
+				t.Fatalf("expected: refreshed tokens and got %d %v", status, refreshed)
+			}
+
+			// the rotated refresh token can't be used again
+			status, reused := s.token(t, form)
+			if status != http.StatusBadRequest || reused["error"] != "invalid_grant" {
+				t.Fatalf("expected: invalid_grant on reuse and got %d %v", status, reused)
+			}
+		})
+	}
+}
+
+var oauthClientCredentialsTests = []struct {
+	description    string
+	inConfidential bool
+	inWrongSecret  bool
+	inScope        string
+	expectStatus   int
+}{
+	{"confidential client", true, false, "reports:read", http.StatusOK},
+	{"public client", false, false, "", http.StatusUnauthorized},
+	{"wrong secret", true, true, "", http.StatusUnauthorized},
+	{"scope not allowed", true, false, "users:write", http.StatusBadRequest},
+}
+
+func TestOAuthClientCredentials(t *testing.T) {
+	for _, testCase := range oauthClientCredentialsTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			s := newOAuthTestServer(t)
+			secret, client, err := s.auth.RegisterOAuthClient(
+				ctx,
+				"service",
+				[]string{"https://service.com/cb"},
+				[]string{"reports:read"},
+				testCase.inConfidential,
+			)
+			if err != nil {
+				t.Fatalf("expected: non error on register and got %v", err)
+			}
+
+			if testCase.inWrongSecret {
+				secret = "wrong"
+			}
+
+			req, _ := http.NewRequest(
+				http.MethodPost,
+				s.server.URL+"/token",
+				strings.NewReader(url.Values{
+					"grant_type": {GrantTypeClientCredentials},
+					"scope":      {testCase.inScope},
+				}.Encode()),
+			)
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.SetBasicAuth(client.ID.String(), secret)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("expected: non error on token and got %v", err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != testCase.expectStatus {
+				t.Fatalf("expected: %d and got %d", testCase.expectStatus, res.StatusCode)
+			}
+
+			if testCase.expectStatus != http.StatusOK {
+				return
+			}
+
+			body := map[string]any{}
+			_ = json.NewDecoder(res.Body).Decode(&body)
+			if _, ok := body["refresh_token"]; ok {
+				t.Fatalf("expected: no refresh token and got %v", body)
+			}
+
+			claims := s.api(t, body["access_token"].(string))
+			if claims["user"] != nil || claims["client"] != client.ID.String() {
+				t.Fatalf("expected: only the client on the api and got %v", claims)
+			}
+		})
+	}
+}
+
+var oauthRevokeTests = []struct {
+	description           string
+	inClientCredentials   bool
+	inRemoveClient        bool
+	expectEvent           EventKind
+	expectAction          AuditAction
+	expectRefreshRejected bool
+}{
+	{"revoke consent", false, false, EventOAuthConsentRevoked, AuditActionRevokeOAuthConsent, true},
+	{"remove client", false, true, EventOAuthClientRemoved, AuditActionRemoveOAuthClient, true},
+	{"remove client credentials client", true, true, EventOAuthClientRemoved, AuditActionRemoveOAuthClient, false},
+}
+
+func TestOAuthRevoke(t *testing.T) {
+	for _, testCase := range oauthRevokeTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			events := []EventKind{}
+			s := newOAuthTestServer(
+				t,
+				WithAuditStorage(inmem.NewAuditRecords([]entity.AuditRecord{})),
+				WithEventHandler(func(_ context.Context, event Event) error {
+					events = append(events, event.Kind)
+					return nil
+				}, EventDeliverySync),
+			)
+			secret, client, err := s.auth.RegisterOAuthClient(
+				ctx,
+				"client",
+				[]string{"https://client.com/cb"},
+				[]string{"users:read"},
+				true,
+			)
+			if err != nil {
+				t.Fatalf("expected: non error on register and got %v", err)
+			}
+
+			req := OAuthTokenRequest{
+				GrantType:    GrantTypeClientCredentials,
+				ClientID:     client.ID.String(),
+				ClientSecret: secret,
+			}
+			if !testCase.inClientCredentials {
+				err = s.auth.GrantOAuthConsent(ctx, s.userID, client.ID, []string{"users:read"})
+				if err != nil {
+					t.Fatalf("expected: non error on consent and got %v", err)
+				}
+
+				verifier, _ := providers.NewCodeVerifier()
+				location := s.authorize(t, url.Values{
+					"response_type":         {"code"},
+					"client_id":             {client.ID.String()},
+					"scope":                 {"users:read"},
+					"code_challenge":        {providers.CodeChallenge(verifier)},
+					"code_challenge_method": {"S256"},
+				})
+				if location == nil {
+					t.Fatal("expected: redirect with the code and got none")
+				}
+
+				req.GrantType = GrantTypeAuthorizationCode
+				req.Code = location.Query().Get("code")
+				req.CodeVerifier = verifier
+			}
+
+			result, err := s.auth.OAuthToken(ctx, req)
+			if err != nil {
+				t.Fatalf("expected: non error on token and got %v", err)
+			}
+
+			if claims := s.api(t, result.AccessToken); claims["client"] != client.ID.String() {
+				t.Fatalf("expected: the client on the api and got %v", claims)
+			}
+
+			if testCase.inRemoveClient {
+				err = s.auth.RemoveOAuthClient(ctx, client.ID)
+			} else {
+				err = s.auth.RevokeOAuthConsent(ctx, s.userID, client.ID)
+			}
+			if err != nil {
+				t.Fatalf("expected: non error on revoke and got %v", err)
+			}
+
+			if claims := s.api(t, result.AccessToken); claims["client"] != nil {
+				t.Fatalf("expected: the access token to be revoked and got %v", claims)
+			}
+
+			if testCase.expectRefreshRejected {
+				_, err = s.auth.OAuthToken(ctx, OAuthTokenRequest{
+					GrantType:    GrantTypeRefreshToken,
+					ClientID:     client.ID.String(),
+					ClientSecret: secret,
+					RefreshToken: result.RefreshToken,
+				})
+				if err == nil {
+					t.Fatal("expected: the refresh token to be revoked")
+				}
+			}
+
+			if !slices.Contains(events, testCase.expectEvent) {
+				t.Fatalf("expected: event %v and got %v", testCase.expectEvent, events)
+			}
+
+			records, err := s.auth.ListAuditRecords(ctx, entity.AuditFilter{
+				Action: string(testCase.expectAction),
+			})
+			if err != nil {
+				t.Fatalf("expected: non error on list and got %v", err)
+			}
+
+			if len(records) != 1 || records[0].Outcome != AuditOutcomeSuccess {
+				t.Fatalf("expected: 1 successful record and got %v", records)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrNotOrganizationMember = errors.New("not an organization member")
+)
+
+const (
+	// OrganizationRoleOwner is given to the user creating the organization
+	OrganizationRoleOwner = "owner"
+	// OrganizationRoleAdmin manages the members of the organization along
+	// with the owners
+	OrganizationRoleAdmin = "admin"
+)
+
+// organizationRoleRank ranks the roles managing the organization, the other
+// roles rank 0
+var organizationRoleRank = map[string]int{
+	OrganizationRoleOwner: 2,
+	OrganizationRoleAdmin: 1,
+}
+
+// getOrganizationRank returns the highest rank of the roles
+func getOrganizationRank(roles []string) int {
+	rank := 0
+	for _, role := range roles {
+		rank = max(rank, organizationRoleRank[role])
+	}
+
+	return rank
+}
+
+// checkOrganizationGrant errors with ErrForbidden unless the member manages
+// the organization and ranks at least as high as each of the roles granted
+func checkOrganizationGrant(member entity.OrganizationMember, roles []string) error {
+	rank := getOrganizationRank(member.Roles)
+	if rank == 0 {
+		return ErrForbidden
+	}
+
+	for _, role := range roles {
+		if organizationRoleRank[role] > rank {
+			return ErrForbidden
+		}
+	}
+
+	return nil
+}
+
+// getContextTenantID is the tenant of the users of the request, nil unless the
+// emails are unique per tenant
+func (auth Auth) getContextTenantID(ctx context.Context) uuid.UUID {
+	if !auth.tenantScopedEmails {
+		return uuid.Nil
+	}
+
+	tenantID := GetContextTenantID(ctx)
+	if tenantID == nil {
+		return uuid.Nil
+	}
+
+	return *tenantID
+}
+
+// getUserByEmail looks the user up within the tenant of the request once
+// the emails are unique per tenant, across every user otherwise
+func (auth Auth) getUserByEmail(ctx context.Context, email string) (entity.AuthUser, error) {
+	if !auth.tenantScopedEmails {
+		return auth.userStorage.GetUserByEmail(ctx, email)
+	}
+
+	return auth.userStorage.GetTenantUserByEmail(ctx, auth.getContextTenantID(ctx), email)
+}
+
+// CreateOrganization registers the organization with the user as its owner
+func (auth Auth) CreateOrganization(
+	ctx context.Context,
+	name string,
+	ownerID uuid.UUID,
+) (org entity.Organization, err error) {
+	defer func() { err = auth.audit(ctx, AuditActionCreateOrganization, ownerID, err) }()
+
+	if auth.organizationStorage == nil {
+		return entity.Organization{}, ErrStorageRequired
+	}
+
+	org = entity.Organization{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+
+	err = auth.organizationStorage.CreateOrganization(ctx, org)
+	if err != nil {
+		return org, err
+	}
+
+	err = auth.organizationStorage.SaveOrganizationMember(ctx, entity.OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         ownerID,
+		Roles:          []string{OrganizationRoleOwner},
+		CreatedAt:      org.CreatedAt,
+	})
+
+	return org, err
+}
+
+// AddOrganizationMember adds the user to the organization or replaces the
+// roles of the member, the user gets them on the next refresh
+func (auth Auth) AddOrganizationMember(
+	ctx context.Context,
+	orgID uuid.UUID,
+	userID uuid.UUID,
+	roles ...string,
+) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionAddOrganizationMember, userID, err) }()
+
+	if auth.organizationStorage == nil {
+		return ErrStorageRequired
+	}
+
+	_, err = auth.organizationStorage.GetOrganization(ctx, orgID)
+	if errors.Is(err, storage.ErrOrganizationNotFound) {
+		return ErrOrganizationNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return auth.organizationStorage.SaveOrganizationMember(ctx, entity.OrganizationMember{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Roles:          roles,
+		CreatedAt:      time.Now(),
+	})
+}
+
+// RemoveOrganizationMember removes the user from the organization, the
+// organization is dropped from the tokens of the user on the next refresh
+func (auth Auth) RemoveOrganizationMember(
+	ctx context.Context,
+	orgID uuid.UUID,
+	userID uuid.UUID,
+) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRemoveOrganizationMember, userID, err) }()
+
+	if auth.organizationStorage == nil {
+		return ErrStorageRequired
+	}
+
+	return auth.organizationStorage.RemoveOrganizationMember(ctx, orgID, userID)
+}
+
+// GetOrganizationMembers returns the members of the organization
+func (auth Auth) GetOrganizationMembers(
+	ctx context.Context,
+	orgID uuid.UUID,
+) ([]entity.OrganizationMember, error) {
+	if auth.organizationStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	return auth.organizationStorage.GetOrganizationMembers(ctx, orgID)
+}
+
+// GetUserOrganizations returns the memberships of the user
+func (auth Auth) GetUserOrganizations(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]entity.OrganizationMember, error) {
+	if auth.organizationStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	return auth.organizationStorage.GetUserOrganizationMembers(ctx, userID)
+}
+
+// SwitchOrganization reissues the tokens of the session of the request (set
+// by WithAuthUserID) with the organization as the active one, uuid.Nil
+// leaves the session without an active organization
+func (auth Auth) SwitchOrganization(
+	ctx context.Context,
+	userID uuid.UUID,
+	orgID uuid.UUID,
+) (result signInResult, err error) {
+	defer func() { err = auth.audit(ctx, AuditActionSwitchOrganization, userID, err) }()
+
+	result = signInResult{UserID: userID}
+
+	if auth.tokenStorage == nil || auth.organizationStorage == nil {
+		return result, ErrStorageRequired
+	}
+
+	if orgID != uuid.Nil {
+		_, err = auth.organizationStorage.GetOrganizationMember(ctx, orgID, userID)
+		if errors.Is(err, storage.ErrOrganizationMemberNotFound) {
+			return result, ErrNotOrganizationMember
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	err = auth.checkContextTokensUser(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+
+	familyID, err := auth.getContextSessionID(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	// the previous tokens are replaced, the refresh one is kept as revoked
+	// to detect its reuse
+	accessToken, refreshToken := getContextTokens(ctx)
+	if len(accessToken) > 0 {
+		err = auth.tokenStorage.RemoveUserToken(ctx, userID, accessToken)
+		if err != nil {
+			return result, err
+		}
+	}
+	if len(refreshToken) > 0 {
+		err = auth.tokenStorage.RevokeToken(ctx, refreshToken)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if familyID == uuid.Nil {
+		familyID = uuid.New()
+		err = auth.createSession(ctx, userID, familyID)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return auth.issueSessionTokens(ctx, userID, familyID, orgID)
+}
+
+// checkContextTokensUser refuses the tokens of the request (set by
+// WithAuthUserID) issued to another user, as they are the ones replaced
+func (auth Auth) checkContextTokensUser(ctx context.Context, userID uuid.UUID) error {
+	accessToken, refreshToken := getContextTokens(ctx)
+	for _, value := range []string{accessToken, refreshToken} {
+		if len(value) == 0 {
+			continue
+		}
+
+		token, err := auth.tokenStorage.GetToken(ctx, value)
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if token.UserID != userID {
+			return ErrWrongUser
+		}
+	}
+
+	return nil
+}
+
+// getOrganizationClaims resolves the organization claims, the organization is
+// dropped once the user isn't a member anymore
+func (auth Auth) getOrganizationClaims(
+	ctx context.Context,
+	userID uuid.UUID,
+	orgID uuid.UUID,
+) (tokenClaims, error) {
+	claims := tokenClaims{}
+	if orgID == uuid.Nil || auth.organizationStorage == nil {
+		return claims, nil
+	}
+
+	member, err := auth.organizationStorage.GetOrganizationMember(ctx, orgID, userID)
+	if errors.Is(err, storage.ErrOrganizationMemberNotFound) {
+		return claims, nil
+	}
+	if err != nil {
+		return claims, err
+	}
+
+	claims.Org = orgID.String()
+	claims.OrgRoles = append([]string{}, member.Roles...)
+	sort.Strings(claims.OrgRoles)
+
+	return claims, nil
+}
@@ -0,0 +1,186 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var organizationsTests = []struct {
+	description  string
+	inMember     bool
+	inRoles      []string
+	inRemove     bool
+	inOtherUser  bool
+	expectError  error
+	expectStatus int
+}{
+	{"switch", true, []string{"admin"}, false, false, nil, http.StatusOK},
+	{"missing role", true, []string{"viewer"}, false, false, nil, http.StatusForbidden},
+	{"removed member", true, []string{"admin"}, true, false, nil, http.StatusForbidden},
+	{"not a member", false, nil, false, false, ErrNotOrganizationMember, 0},
+	{"tokens of another user", true, []string{"admin"}, false, true, ErrWrongUser, 0},
+}
+
+func TestOrganizations(t *testing.T) {
+	for _, testCase := range organizationsTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			userID := uuid.New()
+			ownerID := uuid.New()
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:  "1234",
+					TokenRefresh: "2345",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(inmem.NewUsers([]entity.AuthUser{
+					{ID: userID, Email: "foo@bar.com", Password: encryptPassword("1234"), IsVerified: true},
+				})),
+				WithOrganizationStorage(inmem.NewOrganizations(
+					[]entity.Organization{},
+					[]entity.OrganizationMember{},
+				)),
+			)
+
+			ctx := context.Background()
+			org, err := auth.CreateOrganization(ctx, "acme", ownerID)
+			if err != nil {
+				t.Fatalf("expected: non error on create and got %v", err)
+			}
+
+			if testCase.inMember {
+				err = auth.AddOrganizationMember(ctx, org.ID, userID, testCase.inRoles...)
+				if err != nil {
+					t.Fatalf("expected: non error on add member and got %v", err)
+				}
+			}
+
+			signIn, _ := auth.SignIn(ctx, "foo@bar.com", "1234")
+			reqCtx := context.WithValue(ctx, accessTokenKey, signIn.AccessToken)
+			reqCtx = context.WithValue(reqCtx, refreshTokenKey, signIn.RefreshToken)
+
+			// the owner is a member as well, the tokens of the request aren't its
+			switchUserID := userID
+			if testCase.inOtherUser {
+				switchUserID = ownerID
+			}
+
+			result, err := auth.SwitchOrganization(reqCtx, switchUserID, org.ID)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("expected: %v and got %v", testCase.expectError, err)
+			}
+
+			if testCase.expectError != nil {
+				return
+			}
+
+			// the previous refresh token is rotated
+			ok, _ := tokenStore.AreTokensRegistered(ctx, []string{signIn.RefreshToken})
+			if ok {
+				t.Fatal("expected: previous refresh token to be revoked")
+			}
+
+			if testCase.inRemove {
+				_ = auth.RemoveOrganizationMember(ctx, org.ID, userID)
+				result, err = auth.RefreshToken(ctx, result.AccessToken, result.RefreshToken)
+				if err != nil {
+					t.Fatalf("expected: non error on refresh and got %v", err)
+				}
+			}
+
+			var orgID *uuid.UUID
+			handler := auth.WithAuthUserID(true, ErrorHandler)(RequireOrganization(ErrorHandler, "admin")(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					orgID = GetContextOrgID(r.Context())
+					w.WriteHeader(http.StatusOK)
+				}),
+			))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Bearer "+result.AccessToken)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != testCase.expectStatus {
+				t.Fatalf("expected: %d and got %d", testCase.expectStatus, w.Code)
+			}
+
+			if w.Code == http.StatusOK && (orgID == nil || *orgID != org.ID) {
+				t.Fatalf("expected: %v on the context and got %v", org.ID, orgID)
+			}
+		})
+	}
+}
+
+var tenantScopedEmailsTests = []struct {
+	description    string
+	inScoped       bool
+	inSameTenant   bool
+	expectError    error
+	expectOwnUsers bool
+}{
+	{"other tenant", true, false, nil, true},
+	{"same tenant", true, true, ErrUserConflict, false},
+	{"not scoped", false, false, ErrUserConflict, false},
+}
+
+func TestTenantScopedEmails(t *testing.T) {
+	for _, testCase := range tenantScopedEmailsTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			opts := []optFn{
+				WithTokenStorage(inmem.NewTokens([]entity.Token{})),
+				WithUserStorage(inmem.NewUsers([]entity.AuthUser{})),
+				WithAutoVerifyUser(),
+			}
+			if testCase.inScoped {
+				opts = append(opts, WithTenantScopedEmails())
+			}
+			auth := New(AuthSecrets{TokenAccess: "1234", TokenRefresh: "2345"}, opts...)
+
+			tenantCtx := context.WithValue(context.Background(), TenantIDKey, uuid.NewString())
+			otherCtx := tenantCtx
+			if !testCase.inSameTenant {
+				otherCtx = context.WithValue(context.Background(), TenantIDKey, uuid.NewString())
+			}
+
+			user := entity.AuthUser{Email: "foo@bar.com", Password: "12345678"}
+			userID, err := auth.SignUp(tenantCtx, user)
+			if err != nil {
+				t.Fatalf("expected: non error on the first sign up and got %v", err)
+			}
+
+			user.Password = "87654321"
+			otherUserID, err := auth.SignUp(otherCtx, user)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("expected: %v and got %v", testCase.expectError, err)
+			}
+
+			if !testCase.expectOwnUsers {
+				return
+			}
+
+			// each tenant signs in its own user with its own password
+			signIns := []struct {
+				ctx      context.Context
+				password string
+				userID   uuid.UUID
+			}{
+				{tenantCtx, "12345678", userID},
+				{otherCtx, "87654321", otherUserID},
+			}
+			for _, signIn := range signIns {
+				res, err := auth.SignIn(signIn.ctx, "foo@bar.com", signIn.password)
+				if err != nil || res.UserID != signIn.userID {
+					t.Fatalf("expected: the user %v of the tenant and got %v %v", signIn.userID, res.UserID, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package goauth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/sender"
+	"github.com/iamajoe/goauth/storage"
+)
+
+var (
+	ErrPhoneRequired     = errors.New("phone number required")
+	ErrPhoneCodeAttempts = errors.New("too many attempts for the phone code")
+)
+
+const (
+	phoneCodeDigits      = 6
+	phoneCodeMaxAttempts = 5
+)
+
+// newPhoneCode generates a random numeric code
+func newPhoneCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < phoneCodeDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", phoneCodeDigits, n.Int64()), nil
+}
+
+// RequestPhoneCode sends an sms with a one time code for the user to sign in,
+// a new request replaces the previous code once the cooldown has passed and
+// keeps its attempts until it expires
+func (auth Auth) RequestPhoneCode(ctx context.Context, phone string) (err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionRequestPhoneCode, userID, err) }()
+
+	if auth.userStorage == nil || auth.phoneCodeStorage == nil {
+		return ErrStorageRequired
+	}
+
+	if len(phone) == 0 {
+		return ErrPhoneRequired
+	}
+
+	err = auth.checkRateLimit(ctx, RateLimitActionPhoneCode, phone)
+	if err != nil {
+		return err
+	}
+
+	user, err := auth.userStorage.GetUserByPhone(ctx, phone)
+	if err != nil {
+		return err
+	}
+	userID = user.ID
+
+	// a new code doesn't give more guesses than the previous one had left
+	attempts := 0
+	previous, err := auth.phoneCodeStorage.GetPhoneCode(ctx, user.ID)
+	if err != nil && !errors.Is(err, storage.ErrPhoneCodeNotFound) {
+		return err
+	}
+	if err == nil && time.Now().Before(previous.ExpiresAt) {
+		retryAfter := time.Until(previous.CreatedAt.Add(auth.verificationCooldown))
+		if retryAfter > 0 {
+			return RateLimitError{RetryAfter: retryAfter}
+		}
+
+		if previous.Attempts >= phoneCodeMaxAttempts {
+			return ErrPhoneCodeAttempts
+		}
+		attempts = previous.Attempts
+	}
+
+	code, err := newPhoneCode()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = auth.phoneCodeStorage.CreatePhoneCode(ctx, entity.PhoneCode{
+		UserID:    user.ID,
+		Code:      encryptPassword(code),
+		Attempts:  attempts,
+		ExpiresAt: now.Add(auth.tokenExpirationTimes.PhoneCode),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	data := mapUsersToNotificationData(
+		auth.baseURL,
+		[]entity.AuthUser{user},
+		map[string]string{"code": code},
+	)
+	errs := sender.SendBulk(auth.senders, sender.TemplatePhoneCode, data)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// SignInWithPhoneCode takes the code sent by RequestPhoneCode and signs the
+// user in, the code is removed once used or expired. The code out of
+// attempts is kept until it expires so that no new one is issued before
+func (auth Auth) SignInWithPhoneCode(
+	ctx context.Context,
+	phone string,
+	code string,
+) (result signInResult, err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionSignInPhoneCode, userID, err) }()
+
+	if auth.userStorage == nil || auth.tokenStorage == nil || auth.phoneCodeStorage == nil {
+		return result, ErrStorageRequired
+	}
+
+	if len(phone) == 0 {
+		return result, ErrPhoneRequired
+	}
+
+	user, err := auth.userStorage.GetUserByPhone(ctx, phone)
+	if err != nil {
+		return result, err
+	}
+	userID = user.ID
+
+	phoneCode, err := auth.phoneCodeStorage.GetPhoneCode(ctx, user.ID)
+	if err != nil {
+		return result, err
+	}
+
+	if time.Now().After(phoneCode.ExpiresAt) {
+		return result, errors.Join(
+			ErrExpirationTime,
+			auth.phoneCodeStorage.RemovePhoneCode(ctx, user.ID),
+		)
+	}
+
+	if phoneCode.Attempts >= phoneCodeMaxAttempts {
+		return result, ErrPhoneCodeAttempts
+	}
+
+	if ok := comparePassword(phoneCode.Code, code); !ok {
+		return result, errors.Join(
+			ErrWrongCredentials,
+			auth.phoneCodeStorage.IncrementPhoneCodeAttempts(ctx, user.ID),
+		)
+	}
+
+	err = auth.phoneCodeStorage.RemovePhoneCode(ctx, user.ID)
+	if err != nil {
+		return result, err
+	}
+
+	return auth.signInUser(ctx, user)
+}
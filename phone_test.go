@@ -0,0 +1,175 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/sender"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+// senderRecorder keeps the notifications so that tests can read the codes
+type senderRecorder struct {
+	templates []sender.Template
+	sent      []map[string]string
+}
+
+func (s *senderRecorder) SupportsTemplate(kind sender.Template) bool {
+	if len(s.templates) == 0 {
+		return true
+	}
+
+	for _, t := range s.templates {
+		if t == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *senderRecorder) SendBulk(kind sender.Template, list []map[string]string) error {
+	s.sent = append(s.sent, list...)
+	return nil
+}
+
+var signInWithPhoneCodeTests = []struct {
+	description   string
+	inWrongCode   bool
+	inAttempts    int
+	inUnverified  bool
+	inLocked      bool
+	expectError   error
+	expectRemoved bool
+}{
+	{"success", false, 0, false, false, nil, true},
+	{"wrong code", true, 0, false, false, ErrWrongCredentials, false},
+	{"too many attempts", false, phoneCodeMaxAttempts, false, false, ErrPhoneCodeAttempts, false},
+	{"not verified", false, 0, true, false, ErrUserNotVerified, true},
+	{"locked", false, 0, false, true, ErrAccountLocked, true},
+}
+
+func TestSignInWithPhoneCode(t *testing.T) {
+	for _, testCase := range signInWithPhoneCodeTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			phone := "+351910000000"
+			userID := uuid.New()
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			phoneCodeStore := inmem.NewPhoneCodes([]entity.PhoneCode{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: uuid.New(), Email: "nofoo@bar.com"},
+				{ID: userID, Email: "foo@bar.com", PhoneNumber: phone, IsVerified: !testCase.inUnverified},
+			})
+			attempts := []entity.SignInAttempt{}
+			if testCase.inLocked {
+				attempts = append(attempts, entity.SignInAttempt{UserID: userID, LockedUntil: time.Now().Add(time.Hour)})
+			}
+			smsSender := &senderRecorder{templates: []sender.Template{sender.TemplatePhoneCode}}
+			auth := New(
+				AuthSecrets{TokenAccess: "1234", TokenRefresh: "2345"},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithPhoneCodeStorage(phoneCodeStore),
+				WithSignInAttemptStorage(inmem.NewSignInAttempts(attempts)),
+				WithSender(smsSender),
+			)
+
+			err := auth.RequestPhoneCode(context.Background(), phone)
+			if err != nil {
+				t.Fatalf("expected: non error on request and got %v", err)
+			}
+
+			if len(smsSender.sent) != 1 || smsSender.sent[0]["phone"] != phone {
+				t.Fatal("expected: code to be sent to the phone")
+			}
+
+			code := smsSender.sent[0]["code"]
+			if testCase.inWrongCode {
+				code = "abcdef"
+			}
+
+			for i := 0; i < testCase.inAttempts; i++ {
+				_ = phoneCodeStore.IncrementPhoneCodeAttempts(context.Background(), userID)
+			}
+
+			res, err := auth.SignInWithPhoneCode(context.Background(), phone, code)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("expected: error=%v\ngot: %v", testCase.expectError, err)
+			}
+
+			_, err = phoneCodeStore.GetPhoneCode(context.Background(), userID)
+			if testCase.expectRemoved && err == nil {
+				t.Fatal("expected: phone code to have been removed")
+			}
+
+			if testCase.expectError != nil {
+				return
+			}
+
+			if res.UserID != userID || len(res.AccessToken) == 0 {
+				t.Fatal("expected: an AccessToken for the user")
+			}
+		})
+	}
+}
+
+var requestPhoneCodeTests = []struct {
+	description    string
+	inCooldown     time.Duration
+	inAttempts     int
+	inRateLimit    bool
+	expectError    error
+	expectAttempts int
+}{
+	{"new code keeps the attempts", 0, 2, false, nil, 2},
+	{"cooldown", time.Hour, 0, false, ErrRateLimited, 0},
+	{"out of attempts", 0, phoneCodeMaxAttempts, false, ErrPhoneCodeAttempts, phoneCodeMaxAttempts},
+	{"rate limited", 0, 0, true, ErrRateLimited, 0},
+}
+
+func TestRequestPhoneCode(t *testing.T) {
+	for _, testCase := range requestPhoneCodeTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			phone := "+351910000000"
+			userID := uuid.New()
+			phoneCodeStore := inmem.NewPhoneCodes([]entity.PhoneCode{})
+			opts := []optFn{
+				WithTokenStorage(inmem.NewTokens([]entity.Token{})),
+				WithUserStorage(inmem.NewUsers([]entity.AuthUser{
+					{ID: userID, Email: "foo@bar.com", PhoneNumber: phone},
+				})),
+				WithPhoneCodeStorage(phoneCodeStore),
+				WithSender(&senderRecorder{}),
+				WithVerificationCooldown(testCase.inCooldown),
+			}
+			if testCase.inRateLimit {
+				opts = append(opts, WithRateLimiter(inmem.NewRateLimiter(1, time.Hour)))
+			}
+			auth := New(AuthSecrets{}, opts...)
+
+			err := auth.RequestPhoneCode(ctx, phone)
+			if err != nil {
+				t.Fatalf("expected: non error on the first request and got %v", err)
+			}
+
+			for i := 0; i < testCase.inAttempts; i++ {
+				_, _ = auth.SignInWithPhoneCode(ctx, phone, "abcdef")
+			}
+
+			err = auth.RequestPhoneCode(ctx, phone)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("expected: %v and got %v", testCase.expectError, err)
+			}
+
+			phoneCode, _ := phoneCodeStore.GetPhoneCode(ctx, userID)
+			if phoneCode.Attempts != testCase.expectAttempts {
+				t.Fatalf("expected: %d attempts and got %d", testCase.expectAttempts, phoneCode.Attempts)
+			}
+		})
+	}
+}
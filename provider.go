@@ -0,0 +1,269 @@
+package goauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/providers"
+	"github.com/iamajoe/goauth/storage"
+)
+
+var (
+	ErrProviderNotFound         = errors.New("provider not found")
+	ErrProviderStateInvalid     = errors.New("provider state invalid")
+	ErrProviderEmailNotVerified = errors.New("provider email not verified")
+)
+
+// providerSessionExpiration is how long the user has to come back from the
+// provider
+const providerSessionExpiration = 10 * time.Minute
+
+// ProviderAuthorization is the start of the sign in with a provider, the user
+// is redirected to URL while Session is kept by the client, for example on a
+// cookie, until the callback
+type ProviderAuthorization struct {
+	URL     string
+	Session string
+}
+
+// providerSession is what is needed to finish the flow on the callback, it is
+// sealed so that the client can't change it
+type providerSession struct {
+	Provider     string    `json:"provider"`
+	State        string    `json:"state"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"verifier"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+func (auth Auth) getProvider(name string) (providers.Provider, error) {
+	p, ok := auth.providers[name]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+
+	return p, nil
+}
+
+// AuthorizeWithProvider starts the sign in with the provider registered with
+// WithProvider
+func (auth Auth) AuthorizeWithProvider(ctx context.Context, name string) (ProviderAuthorization, error) {
+	p, err := auth.getProvider(name)
+	if err != nil {
+		return ProviderAuthorization{}, err
+	}
+
+	state, err := providers.NewState()
+	if err != nil {
+		return ProviderAuthorization{}, err
+	}
+
+	nonce, err := providers.NewState()
+	if err != nil {
+		return ProviderAuthorization{}, err
+	}
+
+	codeVerifier, err := providers.NewCodeVerifier()
+	if err != nil {
+		return ProviderAuthorization{}, err
+	}
+
+	rawSession, err := json.Marshal(providerSession{
+		Provider:     name,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(providerSessionExpiration),
+	})
+	if err != nil {
+		return ProviderAuthorization{}, err
+	}
+
+	session, err := encryptSecret(auth.secrets.Encryption, string(rawSession))
+	if err != nil {
+		return ProviderAuthorization{}, err
+	}
+
+	return ProviderAuthorization{
+		URL:     p.AuthCodeURL(state, nonce, providers.CodeChallenge(codeVerifier)),
+		Session: session,
+	}, nil
+}
+
+// openProviderSession unseals the session and checks it against the state of
+// the callback
+func (auth Auth) openProviderSession(name string, session string, state string) (providerSession, error) {
+	rawSession, err := decryptSecret(auth.secrets.Encryption, session)
+	if err != nil {
+		return providerSession{}, ErrProviderStateInvalid
+	}
+
+	opened := providerSession{}
+	err = json.Unmarshal([]byte(rawSession), &opened)
+	if err != nil {
+		return providerSession{}, ErrProviderStateInvalid
+	}
+
+	if opened.Provider != name || time.Now().After(opened.ExpiresAt) {
+		return providerSession{}, ErrProviderStateInvalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(opened.State), []byte(state)) != 1 {
+		return providerSession{}, ErrProviderStateInvalid
+	}
+
+	return opened, nil
+}
+
+// SignInWithProvider finishes the sign in on the callback of the provider,
+// session is the one of AuthorizeWithProvider while state and code are the
+// query of the callback. The user is found through the linked identity or
+// by the verified email, and created otherwise
+func (auth Auth) SignInWithProvider(
+	ctx context.Context,
+	name string,
+	session string,
+	state string,
+	code string,
+) (result signInResult, err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionSignInProvider, userID, err) }()
+
+	if auth.userStorage == nil || auth.tokenStorage == nil || auth.identityStorage == nil {
+		return result, ErrStorageRequired
+	}
+
+	p, err := auth.getProvider(name)
+	if err != nil {
+		return result, err
+	}
+
+	opened, err := auth.openProviderSession(name, session, state)
+	if err != nil {
+		return result, err
+	}
+
+	identity, err := p.Exchange(ctx, code, opened.CodeVerifier, opened.Nonce)
+	if err != nil {
+		return result, err
+	}
+
+	user, err := auth.getProviderUser(ctx, name, identity)
+	if err != nil {
+		return result, err
+	}
+	userID = user.ID
+
+	err = auth.checkAccountLock(ctx, user.ID)
+	if err != nil {
+		return result, err
+	}
+
+	return auth.signInUser(ctx, user)
+}
+
+// getProviderUser returns the user linked to the identity, linking it first
+// to the user with the same email or to a new user
+func (auth Auth) getProviderUser(
+	ctx context.Context,
+	name string,
+	identity providers.Identity,
+) (entity.AuthUser, error) {
+	if len(identity.Subject) == 0 {
+		return entity.AuthUser{}, providers.ErrIDTokenInvalid
+	}
+
+	linked, err := auth.identityStorage.GetIdentity(ctx, name, identity.Subject)
+	if err == nil {
+		return auth.userStorage.GetUserByID(ctx, linked.UserID)
+	}
+	if !errors.Is(err, storage.ErrIdentityNotFound) {
+		return entity.AuthUser{}, err
+	}
+
+	// an unverified email could belong to someone else
+	if !identity.EmailVerified {
+		return entity.AuthUser{}, ErrProviderEmailNotVerified
+	}
+
+	if ok, err := validateEmail(identity.Email); !ok {
+		return entity.AuthUser{}, err
+	}
+
+	user, _ := auth.getUserByEmail(ctx, identity.Email)
+	if user.Email != identity.Email {
+		user, err = auth.createProviderUser(ctx, identity.Email)
+		if err != nil {
+			return user, err
+		}
+	} else if !user.IsVerified {
+		// the password of an unverified user could have been set by someone
+		// else ahead of the owner of the email, as such, it isn't kept
+		err = auth.userStorage.UpdateUserPassword(ctx, user.ID, "")
+		if err != nil {
+			return user, err
+		}
+
+		err = auth.userStorage.VerifyUser(ctx, user.ID)
+		if err != nil {
+			return user, err
+		}
+		user.IsVerified = true
+	}
+
+	err = auth.identityStorage.CreateIdentity(ctx, entity.UserIdentity{
+		UserID:    user.ID,
+		Provider:  name,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+// createProviderUser registers a verified user without a password, one can be
+// set later through the reset password
+func (auth Auth) createProviderUser(ctx context.Context, email string) (entity.AuthUser, error) {
+	user := entity.AuthUser{
+		ID:       uuid.New(),
+		Email:    email,
+		TenantID: auth.getContextTenantID(ctx),
+	}
+
+	err := auth.userStorage.CreateUser(ctx, user)
+	if err != nil {
+		return user, err
+	}
+
+	// a vetoed sign up doesn't keep the user
+	err = auth.emit(ctx, Event{Kind: EventUserSignedUp, UserID: user.ID, Email: user.Email})
+	if err != nil {
+		return user, errors.Join(err, auth.userStorage.DeleteUser(ctx, user.ID))
+	}
+
+	err = auth.userStorage.VerifyUser(ctx, user.ID)
+	if err != nil {
+		return user, err
+	}
+	user.IsVerified = true
+
+	return user, nil
+}
+
+// GetUserIdentities lists the external identities linked to the user
+func (auth Auth) GetUserIdentities(ctx context.Context, userID uuid.UUID) ([]entity.UserIdentity, error) {
+	if auth.identityStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	return auth.identityStorage.GetUserIdentities(ctx, userID)
+}
@@ -0,0 +1,224 @@
+package goauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/providers"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+// fakeIdentityProvider is an openid provider issuing a code per authorization
+type fakeIdentityProvider struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	emailVerified bool
+	wrongNonce    bool
+	// codes keeps the challenge and nonce of each authorization
+	codes map[string][2]string
+}
+
+func newFakeIdentityProvider(t *testing.T, emailVerified bool, wrongNonce bool) *fakeIdentityProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("expected: non error generating the key and got %v", err)
+	}
+
+	idp := &fakeIdentityProvider{
+		key:           key,
+		emailVerified: emailVerified,
+		wrongNonce:    wrongNonce,
+		codes:         map[string][2]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kid": "test",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		authorization, ok := idp.codes[r.FormValue("code")]
+		if !ok || providers.CodeChallenge(r.FormValue("code_verifier")) != authorization[0] {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		nonce := authorization[1]
+		if idp.wrongNonce {
+			nonce = "wrong"
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            idp.server.URL,
+			"aud":            "client",
+			"sub":            "subject",
+			"email":          "foo@bar.com",
+			"email_verified": idp.emailVerified,
+			"nonce":          nonce,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		token.Header["kid"] = "test"
+		idToken, _ := token.SignedString(key)
+
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access",
+			"id_token":     idToken,
+			"token_type":   "Bearer",
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+// authorize plays the user consenting on the provider, it returns the code
+// and state of the callback
+func (idp *fakeIdentityProvider) authorize(t *testing.T, rawURL string) (string, string) {
+	authURL, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("expected: non error parsing the authorization url and got %v", err)
+	}
+
+	query := authURL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "client" {
+		t.Fatalf("expected: pkce and client on the authorization url and got %v", query)
+	}
+
+	code := uuid.NewString()
+	idp.codes[code] = [2]string{query.Get("code_challenge"), query.Get("nonce")}
+
+	return code, query.Get("state")
+}
+
+var providersTests = []struct {
+	description     string
+	inExistingUser  bool
+	inLinked        bool
+	inEmailVerified bool
+	inWrongState    bool
+	inWrongNonce    bool
+	expectErr       error
+	expectUsers     int
+}{
+	{"new user", false, false, true, false, false, nil, 1},
+	{"existing user", true, false, true, false, false, nil, 1},
+	{"linked identity", false, true, true, false, false, nil, 1},
+	{"email not verified", false, false, false, false, false, ErrProviderEmailNotVerified, 0},
+	{"wrong state", false, false, true, true, false, ErrProviderStateInvalid, 0},
+	{"wrong nonce", false, false, true, false, true, providers.ErrNonceInvalid, 0},
+}
+
+func TestSignInWithProvider(t *testing.T) {
+	for _, testCase := range providersTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			idp := newFakeIdentityProvider(t, testCase.inEmailVerified, testCase.inWrongNonce)
+			provider, err := providers.NewOIDC(ctx, "fake", idp.server.URL, providers.Config{
+				ClientID:     "client",
+				ClientSecret: "secret",
+				RedirectURL:  "http://localhost/callback",
+			})
+			if err != nil {
+				t.Fatalf("expected: non error on discovery and got %v", err)
+			}
+
+			users := []entity.AuthUser{}
+			identities := []entity.UserIdentity{}
+			userID := uuid.New()
+			if testCase.inExistingUser || testCase.inLinked {
+				users = append(users, entity.AuthUser{
+					ID:         userID,
+					Email:      "foo@bar.com",
+					IsVerified: testCase.inLinked,
+				})
+			}
+			if testCase.inLinked {
+				identities = append(identities, entity.UserIdentity{
+					UserID:   userID,
+					Provider: "fake",
+					Subject:  "subject",
+				})
+			}
+
+			userStore := inmem.NewUsers(users)
+			identityStore := inmem.NewUserIdentities(identities)
+			auth := New(
+				AuthSecrets{
+					TokenAccess:  "1234",
+					TokenRefresh: "2345",
+					Encryption:   "3456",
+				},
+				WithTokenStorage(inmem.NewTokens([]entity.Token{})),
+				WithUserStorage(userStore),
+				WithIdentityStorage(identityStore),
+				WithProvider(provider),
+			)
+
+			authorization, err := auth.AuthorizeWithProvider(ctx, "fake")
+			if err != nil {
+				t.Fatalf("expected: non error on authorize and got %v", err)
+			}
+
+			code, state := idp.authorize(t, authorization.URL)
+			if testCase.inWrongState {
+				state = "wrong"
+			}
+
+			result, err := auth.SignInWithProvider(ctx, "fake", authorization.Session, state, code)
+			if !errors.Is(err, testCase.expectErr) {
+				t.Fatalf("expected: %v and got %v", testCase.expectErr, err)
+			}
+
+			registeredUsers, _ := userStore.GetAll(ctx)
+			if len(registeredUsers) != testCase.expectUsers {
+				t.Fatalf("expected: %d users and got %v", testCase.expectUsers, registeredUsers)
+			}
+
+			if testCase.expectErr != nil {
+				return
+			}
+
+			if len(result.AccessToken) == 0 || len(result.RefreshToken) == 0 {
+				t.Fatalf("expected: the session tokens and got %v", result)
+			}
+
+			if !registeredUsers[0].IsVerified || result.UserID != registeredUsers[0].ID {
+				t.Fatalf("expected: the verified user to be signed in and got %v", registeredUsers[0])
+			}
+
+			linked, _ := identityStore.GetAll(ctx)
+			if len(linked) != 1 || linked[0].UserID != result.UserID {
+				t.Fatalf("expected: the identity to be linked and got %v", linked)
+			}
+		})
+	}
+}
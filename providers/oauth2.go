@@ -0,0 +1,205 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type Endpoints struct {
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+}
+
+// IdentityFn resolves the identity of the user with the access token
+type IdentityFn func(ctx context.Context, client *http.Client, accessToken string) (Identity, error)
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	TokenType        string `json:"token_type"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type oauth2Provider struct {
+	name      string
+	config    Config
+	endpoints Endpoints
+	identity  IdentityFn
+}
+
+// NewOAuth2 builds a plain OAuth2 provider, the identity is resolved through
+// identity with the access token of the exchange. The nonce isn't used
+func NewOAuth2(name string, config Config, endpoints Endpoints, identity IdentityFn) Provider {
+	if identity == nil {
+		identity = UserInfoIdentity(endpoints.UserInfoURL)
+	}
+
+	return &oauth2Provider{
+		name:      name,
+		config:    config,
+		endpoints: endpoints,
+		identity:  identity,
+	}
+}
+
+func (p *oauth2Provider) Name() string {
+	return p.name
+}
+
+func (p *oauth2Provider) AuthCodeURL(state string, _ string, codeChallenge string) string {
+	return authCodeURL(p.endpoints.AuthURL, p.config, url.Values{
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	})
+}
+
+func (p *oauth2Provider) Exchange(
+	ctx context.Context,
+	code string,
+	codeVerifier string,
+	_ string,
+) (Identity, error) {
+	tokens, err := exchangeCode(ctx, p.endpoints.TokenURL, p.config, code, codeVerifier)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	return p.identity(ctx, p.config.httpClient(), tokens.AccessToken)
+}
+
+func authCodeURL(authURL string, config Config, params url.Values) string {
+	params.Set("response_type", "code")
+	params.Set("client_id", config.ClientID)
+	params.Set("redirect_uri", config.RedirectURL)
+	if len(config.Scopes) > 0 {
+		params.Set("scope", strings.Join(config.Scopes, " "))
+	}
+
+	separator := "?"
+	if strings.Contains(authURL, "?") {
+		separator = "&"
+	}
+
+	return authURL + separator + params.Encode()
+}
+
+// exchangeCode trades the code for the tokens on the token endpoint
+func exchangeCode(
+	ctx context.Context,
+	tokenURL string,
+	config Config,
+	code string,
+	codeVerifier string,
+) (tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {config.RedirectURL},
+		"client_id":     {config.ClientID},
+		"client_secret": {config.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		tokenURL,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	tokens := tokenResponse{}
+	err = doJSON(config.httpClient(), req, &tokens)
+	if len(tokens.Error) > 0 {
+		return tokens, fmt.Errorf("%w: %s %s", ErrExchangeFailed, tokens.Error, tokens.ErrorDescription)
+	}
+	if err != nil {
+		return tokens, err
+	}
+
+	if len(tokens.AccessToken) == 0 {
+		return tokens, ErrExchangeFailed
+	}
+
+	return tokens, nil
+}
+
+// getJSON requests the url with the access token, if any, and decodes the
+// response into target
+func getJSON(ctx context.Context, client *http.Client, rawURL string, accessToken string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if len(accessToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	return doJSON(client, req, target)
+}
+
+// doJSON decodes the body even on failed responses as the token endpoints
+// explain the errors in it
+func doJSON(client *http.Client, req *http.Request, target any) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	_ = json.Unmarshal(body, target)
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("%w: %s responded with %d", ErrExchangeFailed, req.URL.Host, res.StatusCode)
+	}
+
+	return json.Unmarshal(body, target)
+}
+
+// UserInfoIdentity resolves the identity through an OIDC userinfo endpoint
+func UserInfoIdentity(userInfoURL string) IdentityFn {
+	return func(ctx context.Context, client *http.Client, accessToken string) (Identity, error) {
+		info := map[string]any{}
+		err := getJSON(ctx, client, userInfoURL, accessToken, &info)
+		if err != nil {
+			return Identity{}, err
+		}
+
+		return claimsToIdentity(info, "email_verified"), nil
+	}
+}
+
+// claimsToIdentity maps the standard claims, the providers differ on the
+// claim flagging the email as verified
+func claimsToIdentity(claims map[string]any, emailVerifiedClaim string) Identity {
+	identity := Identity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+
+	switch verified := claims[emailVerifiedClaim].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	return identity
+}
@@ -0,0 +1,252 @@
+package providers
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var defaultOIDCScopes = []string{"openid", "email", "profile"}
+
+// jwksRefetchInterval is the least time between two fetches of the keys, the
+// tokens with an unknown kid can't make the provider hammer the issuer
+const jwksRefetchInterval = time.Minute
+
+// discovery is the subset of the openid configuration used by the provider
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type oidcProvider struct {
+	name      string
+	config    Config
+	discovery discovery
+	// emailVerifiedClaim is the claim flagging the email as verified
+	emailVerifiedClaim string
+
+	keysMu        *sync.Mutex
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewOIDC builds the provider out of the openid configuration of the issuer,
+// the id token of the exchange is verified against the keys of the issuer
+func NewOIDC(ctx context.Context, name string, issuer string, config Config) (Provider, error) {
+	return newOIDC(ctx, name, issuer, config, "email_verified")
+}
+
+func newOIDC(
+	ctx context.Context,
+	name string,
+	issuer string,
+	config Config,
+	emailVerifiedClaim string,
+) (*oidcProvider, error) {
+	if len(config.Scopes) == 0 {
+		config.Scopes = defaultOIDCScopes
+	}
+
+	doc := discovery{}
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	err := getJSON(ctx, config.httpClient(), discoveryURL, "", &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oidcProvider{
+		name:               name,
+		config:             config,
+		discovery:          doc,
+		emailVerifiedClaim: emailVerifiedClaim,
+		keysMu:             &sync.Mutex{},
+		keys:               map[string]*rsa.PublicKey{},
+	}, nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) AuthCodeURL(state string, nonce string, codeChallenge string) string {
+	return authCodeURL(p.discovery.AuthorizationEndpoint, p.config, url.Values{
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	})
+}
+
+func (p *oidcProvider) Exchange(
+	ctx context.Context,
+	code string,
+	codeVerifier string,
+	nonce string,
+) (Identity, error) {
+	tokens, err := exchangeCode(ctx, p.discovery.TokenEndpoint, p.config, code, codeVerifier)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	claims, err := p.verifyIDToken(ctx, tokens.IDToken, nonce)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	identity := claimsToIdentity(claims, p.emailVerifiedClaim)
+
+	// the email isn't always on the id token but it is on the userinfo
+	if len(identity.Email) == 0 && len(p.discovery.UserInfoEndpoint) > 0 {
+		info, err := UserInfoIdentity(p.discovery.UserInfoEndpoint)(
+			ctx,
+			p.config.httpClient(),
+			tokens.AccessToken,
+		)
+		if err != nil {
+			return Identity{}, err
+		}
+
+		if info.Subject != identity.Subject {
+			return Identity{}, ErrIDTokenInvalid
+		}
+
+		identity.Email = info.Email
+		identity.EmailVerified = info.EmailVerified
+		if len(identity.Name) == 0 {
+			identity.Name = info.Name
+		}
+	}
+
+	return identity, nil
+}
+
+func (p *oidcProvider) verifyIDToken(
+	ctx context.Context,
+	rawToken string,
+	nonce string,
+) (jwt.MapClaims, error) {
+	if len(rawToken) == 0 {
+		return nil, ErrIDTokenInvalid
+	}
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(rawToken, claims, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, ErrIDTokenInvalid
+		}
+
+		kid, _ := t.Header["kid"].(string)
+		return p.getKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrIDTokenInvalid, err.Error())
+	}
+
+	if !token.Valid {
+		return nil, ErrIDTokenInvalid
+	}
+
+	// multi tenant issuers have the tenant of the user as a placeholder
+	issuer := p.discovery.Issuer
+	if tenantID, ok := claims["tid"].(string); ok {
+		issuer = strings.ReplaceAll(issuer, "{tenantid}", tenantID)
+	}
+
+	if !claims.VerifyIssuer(issuer, true) || !claims.VerifyAudience(p.config.ClientID, true) {
+		return nil, ErrIDTokenInvalid
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, ErrIDTokenInvalid
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, ErrNonceInvalid
+	}
+
+	return claims, nil
+}
+
+// getKey returns the key of the issuer with the kid, the keys are fetched
+// again when the kid is unknown as the issuers rotate them, at most once
+// every jwksRefetchInterval
+func (p *oidcProvider) getKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.keysMu.Lock()
+	defer p.keysMu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	// the waiting callers find the keys just fetched instead of fetching them
+	// again, the failed fetches count as well
+	if time.Since(p.keysFetchedAt) < jwksRefetchInterval {
+		return nil, ErrIDTokenInvalid
+	}
+	p.keysFetchedAt = time.Now()
+
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	err := getJSON(ctx, p.config.httpClient(), p.discovery.JWKSURI, "", &set)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		key, err := parseRSAKey(k)
+		if err != nil {
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+	p.keys = keys
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, ErrIDTokenInvalid
+	}
+
+	return key, nil
+}
+
+func parseRSAKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
@@ -0,0 +1,91 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const (
+	googleIssuer      = "https://accounts.google.com"
+	microsoftIssuer   = "https://login.microsoftonline.com/%s/v2.0"
+	githubAuthURL     = "https://github.com/login/oauth/authorize"
+	githubTokenURL    = "https://github.com/login/oauth/access_token"
+	githubUserURL     = "https://api.github.com/user"
+	githubUserMailURL = "https://api.github.com/user/emails"
+)
+
+// NewGoogle builds the Google provider, the discovery is requested on build
+func NewGoogle(ctx context.Context, config Config) (Provider, error) {
+	return NewOIDC(ctx, "google", googleIssuer, config)
+}
+
+// NewMicrosoft builds the Microsoft identity platform provider, tenant is
+// either the id of the tenant or one of common, organizations and consumers.
+// Microsoft only flags the email as verified through the optional xms_edov
+// claim that has to be enabled on the app registration
+func NewMicrosoft(ctx context.Context, tenant string, config Config) (Provider, error) {
+	return newOIDC(ctx, "microsoft", fmt.Sprintf(microsoftIssuer, tenant), config, "xms_edov")
+}
+
+// NewGitHub builds the GitHub provider, GitHub isn't OIDC so the identity is
+// requested to the api with the access token
+func NewGitHub(config Config) Provider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"read:user", "user:email"}
+	}
+
+	return NewOAuth2(
+		"github",
+		config,
+		Endpoints{
+			AuthURL:     githubAuthURL,
+			TokenURL:    githubTokenURL,
+			UserInfoURL: githubUserURL,
+		},
+		gitHubIdentity(githubUserURL, githubUserMailURL),
+	)
+}
+
+func gitHubIdentity(userURL string, emailsURL string) IdentityFn {
+	return func(ctx context.Context, client *http.Client, accessToken string) (Identity, error) {
+		user := struct {
+			ID    int64  `json:"id"`
+			Login string `json:"login"`
+			Name  string `json:"name"`
+		}{}
+		err := getJSON(ctx, client, userURL, accessToken, &user)
+		if err != nil {
+			return Identity{}, err
+		}
+
+		// the public email of the profile isn't flagged as verified
+		emails := []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}{}
+		err = getJSON(ctx, client, emailsURL, accessToken, &emails)
+		if err != nil {
+			return Identity{}, err
+		}
+
+		identity := Identity{
+			Subject: fmt.Sprintf("%d", user.ID),
+			Name:    user.Name,
+		}
+		if len(identity.Name) == 0 {
+			identity.Name = user.Login
+		}
+
+		for _, email := range emails {
+			if email.Primary {
+				identity.Email = email.Email
+				identity.EmailVerified = email.Verified
+				break
+			}
+		}
+
+		return identity, nil
+	}
+}
@@ -0,0 +1,74 @@
+package providers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrExchangeFailed = errors.New("code exchange failed")
+	ErrIDTokenInvalid = errors.New("id token invalid")
+	ErrNonceInvalid   = errors.New("nonce invalid")
+)
+
+// Identity is the user as known by the provider, Subject is the stable id of
+// the user within the provider
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is an authorization code flow with PKCE
+type Provider interface {
+	Name() string
+	// AuthCodeURL is where the user is sent to sign in with the provider
+	AuthCodeURL(state string, nonce string, codeChallenge string) string
+	// Exchange trades the code of the callback for the identity of the user,
+	// the nonce is checked against the one of the id token when there is one
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (Identity, error)
+}
+
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes replaces the default scopes of the provider
+	Scopes []string
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+func (config Config) httpClient() *http.Client {
+	if config.HTTPClient == nil {
+		return http.DefaultClient
+	}
+
+	return config.HTTPClient
+}
+
+// NewState returns a random value to be used as the state or the nonce
+func NewState() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// NewCodeVerifier returns a random PKCE code verifier
+func NewCodeVerifier() (string, error) {
+	return NewState()
+}
+
+// CodeChallenge derives the S256 PKCE challenge of the verifier
+func CodeChallenge(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
@@ -0,0 +1,73 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrRateLimited = errors.New("rate limited")
+
+// RateLimitError is returned once the limiter denies an action, it matches
+// ErrRateLimited with errors.Is
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (err RateLimitError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrRateLimited, err.RetryAfter)
+}
+
+func (err RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+type RateLimitAction string
+
+const (
+	RateLimitActionSignIn        RateLimitAction = "sign_in"
+	RateLimitActionSignUp        RateLimitAction = "sign_up"
+	RateLimitActionResetPassword RateLimitAction = "reset_password"
+	RateLimitActionPhoneCode     RateLimitAction = "phone_code"
+	RateLimitActionMagicLink     RateLimitAction = "magic_link"
+)
+
+// rateLimiter resolves if the key is allowed to proceed, when it isn't the
+// returned duration is how long until it is
+type rateLimiter interface {
+	Allow(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+// rateLimitKey builds the key per action, identifier (for example the email)
+// and the ip of the request set by WithClientInfo
+func rateLimitKey(ctx context.Context, action RateLimitAction, identifier string) string {
+	return strings.Join([]string{
+		string(action),
+		strings.ToLower(identifier),
+		GetContextClientIP(ctx),
+	}, ":")
+}
+
+// checkRateLimit errors with RateLimitError if the action isn't allowed
+func (auth Auth) checkRateLimit(
+	ctx context.Context,
+	action RateLimitAction,
+	identifier string,
+) error {
+	if auth.rateLimiter == nil {
+		return nil
+	}
+
+	ok, retryAfter, err := auth.rateLimiter.Allow(ctx, rateLimitKey(ctx, action, identifier))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return RateLimitError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
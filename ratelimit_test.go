@@ -0,0 +1,78 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var rateLimitTests = []struct {
+	description string
+	inRequests  int
+	inOtherIP   bool
+	inMagicLink bool
+	expectError error
+}{
+	{"under the limit", 2, false, false, nil},
+	{"over the limit", 3, false, false, ErrRateLimited},
+	{"other ip", 3, true, false, nil},
+	{"magic link over the limit", 3, false, true, ErrRateLimited},
+}
+
+func TestRateLimit(t *testing.T) {
+	for _, testCase := range rateLimitTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: uuid.New(), Email: "foo@bar.com", Password: encryptPassword("1234")},
+			})
+			auth := New(
+				AuthSecrets{TokenResetPassword: "1234", TokenMagicLink: "2345"},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithRateLimiter(inmem.NewRateLimiter(2, time.Hour)),
+			)
+
+			ctx := context.WithValue(context.Background(), ClientIPKey, "10.0.0.1")
+
+			var err error
+			for i := 0; i < testCase.inRequests; i++ {
+				if testCase.inOtherIP && i == testCase.inRequests-1 {
+					ctx = context.WithValue(ctx, ClientIPKey, "10.0.0.2")
+				}
+
+				if testCase.inMagicLink {
+					err = auth.RequestMagicLink(ctx, "foo@bar.com")
+				} else {
+					err = auth.RequestResetPassword(ctx, "foo@bar.com")
+				}
+			}
+
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("expected: %v and got %v", testCase.expectError, err)
+			}
+
+			if testCase.expectError == nil {
+				return
+			}
+
+			// the handler maps the error to a 429 with the retry after
+			w := httptest.NewRecorder()
+			ErrorHandler(w, httptest.NewRequest(http.MethodPost, "/reset", nil), err)
+			if w.Code != http.StatusTooManyRequests {
+				t.Fatalf("expected: status %d and got %d", http.StatusTooManyRequests, w.Code)
+			}
+
+			if w.Header().Get("Retry-After") != "3600" {
+				t.Fatalf("expected: retry after of 3600 and got %s", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// SaveRole creates the role or replaces its permissions, the users get the
+// change on their next refresh
+func (auth Auth) SaveRole(ctx context.Context, role entity.Role) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionSaveRole, uuid.Nil, err) }()
+
+	if auth.roleStorage == nil {
+		return ErrStorageRequired
+	}
+
+	return auth.roleStorage.SaveRole(ctx, role)
+}
+
+// RemoveRole removes the role and its assignments
+func (auth Auth) RemoveRole(ctx context.Context, name string) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRemoveRole, uuid.Nil, err) }()
+
+	if auth.roleStorage == nil {
+		return ErrStorageRequired
+	}
+
+	return auth.roleStorage.RemoveRole(ctx, name)
+}
+
+// AssignRole gives the role to the user, the user gets it on the next
+// refresh
+func (auth Auth) AssignRole(ctx context.Context, userID uuid.UUID, role string) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionAssignRole, userID, err) }()
+
+	if auth.roleStorage == nil {
+		return ErrStorageRequired
+	}
+
+	_, err = auth.roleStorage.GetRole(ctx, role)
+	if errors.Is(err, storage.ErrRoleNotFound) {
+		return ErrRoleNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return auth.roleStorage.AssignUserRole(ctx, userID, role)
+}
+
+// UnassignRole takes the role from the user, the user loses it on the next
+// refresh
+func (auth Auth) UnassignRole(ctx context.Context, userID uuid.UUID, role string) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionUnassignRole, userID, err) }()
+
+	if auth.roleStorage == nil {
+		return ErrStorageRequired
+	}
+
+	return auth.roleStorage.UnassignUserRole(ctx, userID, role)
+}
+
+// GetUserRoles returns the roles assigned to the user
+func (auth Auth) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	if auth.roleStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	return auth.roleStorage.GetUserRoles(ctx, userID)
+}
+
+// getAccessClaims resolves the roles and permissions of the user, and the
+// ones within the organization, to be set on the access token
+func (auth Auth) getAccessClaims(
+	ctx context.Context,
+	userID uuid.UUID,
+	orgID uuid.UUID,
+) (tokenClaims, error) {
+	claims, err := auth.getOrganizationClaims(ctx, userID, orgID)
+	if err != nil {
+		return claims, err
+	}
+
+	claims.Custom, err = auth.getCustomClaims(ctx, userID)
+	if err != nil || auth.roleStorage == nil {
+		return claims, err
+	}
+
+	roles, err := auth.roleStorage.GetUserRoles(ctx, userID)
+	if err != nil {
+		return claims, err
+	}
+
+	permissions := map[string]bool{}
+	for _, role := range roles {
+		claims.Roles = append(claims.Roles, role.Name)
+		for _, permission := range role.Permissions {
+			if permissions[permission] {
+				continue
+			}
+
+			permissions[permission] = true
+			claims.Permissions = append(claims.Permissions, permission)
+		}
+	}
+
+	sort.Strings(claims.Roles)
+	sort.Strings(claims.Permissions)
+
+	return claims, nil
+}
+
+// getCustomClaims returns the claims of WithClaimsEnricher for the user
+func (auth Auth) getCustomClaims(ctx context.Context, userID uuid.UUID) (map[string]any, error) {
+	if auth.claimsEnricher == nil {
+		return nil, nil
+	}
+
+	if auth.userStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.claimsEnricher(ctx, user), nil
+}
+
+func hasAny(values []string, targets []string) bool {
+	for _, value := range values {
+		for _, target := range targets {
+			if value == target {
+				return true
+			}
+		}
+	}
+
+	return false
+}
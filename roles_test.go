@@ -0,0 +1,86 @@
+package goauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var rolesTests = []struct {
+	description  string
+	inAssign     string
+	inRole       string
+	inPermission string
+	expectStatus int
+}{
+	{"role", "admin", "admin", "", http.StatusOK},
+	{"permission", "admin", "", "users:write", http.StatusOK},
+	{"missing role", "viewer", "admin", "", http.StatusForbidden},
+	{"missing permission", "viewer", "", "users:write", http.StatusForbidden},
+	{"unassigned", "", "admin", "", http.StatusForbidden},
+}
+
+func TestRoles(t *testing.T) {
+	for _, testCase := range rolesTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			userID := uuid.New()
+			auth := New(
+				AuthSecrets{
+					TokenAccess:  "1234",
+					TokenRefresh: "2345",
+				},
+				WithTokenStorage(inmem.NewTokens([]entity.Token{})),
+				WithUserStorage(inmem.NewUsers([]entity.AuthUser{
+					{ID: userID, Email: "foo@bar.com", Password: encryptPassword("1234"), IsVerified: true},
+				})),
+				WithRoleStorage(inmem.NewRoles([]entity.Role{
+					{Name: "admin", Permissions: []string{"users:read", "users:write"}},
+					{Name: "viewer", Permissions: []string{"users:read"}},
+				}, []entity.UserRole{})),
+			)
+
+			ctx := context.Background()
+			result, err := auth.SignIn(ctx, "foo@bar.com", "1234")
+			if err != nil {
+				t.Fatalf("expected: non error on sign in and got %v", err)
+			}
+
+			if len(testCase.inAssign) > 0 {
+				err = auth.AssignRole(ctx, userID, testCase.inAssign)
+				if err != nil {
+					t.Fatalf("expected: non error on assign and got %v", err)
+				}
+			}
+
+			// the assignment takes effect on the refresh
+			result, err = auth.RefreshToken(ctx, result.AccessToken, result.RefreshToken)
+			if err != nil {
+				t.Fatalf("expected: non error on refresh and got %v", err)
+			}
+
+			middleware := RequireRoles(ErrorHandler, testCase.inRole)
+			if len(testCase.inPermission) > 0 {
+				middleware = RequirePermission(ErrorHandler, testCase.inPermission)
+			}
+			handler := auth.WithAuthUserID(true, ErrorHandler)(middleware(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}),
+			))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Bearer "+result.AccessToken)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != testCase.expectStatus {
+				t.Fatalf("expected: %d and got %d", testCase.expectStatus, w.Code)
+			}
+		})
+	}
+}
@@ -5,12 +5,27 @@ type Template int
 const (
 	TemplateSignUp Template = iota
 	TemplateResetPassword
+	TemplateMagicLink
+	TemplatePhoneCode
+	TemplateEmailChange
+	TemplateEmailChangeRevert
+	TemplatePasswordChanged
+	TemplateUserDeleted
+	TemplateAccountLocked
+	TemplateInvitation
+	TemplateUserDeletion
 )
 
 type Sender interface {
 	SendBulk(kind Template, list []map[string]string) error
 }
 
+// TemplateSupporter is implemented by the senders that only deliver some of
+// the templates, for example an sms sender, the others are skipped
+type TemplateSupporter interface {
+	SupportsTemplate(kind Template) bool
+}
+
 // SendBulk send a bulk of notifications
 // data is a slice of notifications with the data needed for templates
 //
@@ -26,6 +41,10 @@ func SendBulk(list []Sender, kind Template, data []map[string]string) []error {
 	errors := []error{}
 
 	for _, sender := range list {
+		if supporter, ok := sender.(TemplateSupporter); ok && !supporter.SupportsTemplate(kind) {
+			continue
+		}
+
 		err := sender.SendBulk(kind, data)
 		if err != nil {
 			errors = append(errors, err)
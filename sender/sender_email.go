@@ -28,6 +28,90 @@ const (
 		<p><a href="{{ .baseURL }}/reset/verify/{{ .code }}">Reset Password</a></p>
 	</body>
 	`
+
+	SenderEmailSubjectMagicLinkTmpl = "Your sign in link"
+	SenderEmailBodyMagicLinkTmpl    = `
+	<body style="padding: 30px;">
+		<h2>Sign in</h2>
+
+		<p>Follow this link to sign in, the link can only be used once:</p>
+		<p><a href="{{ .baseURL }}/signin/magic/{{ .code }}">Sign in</a></p>
+	</body>
+	`
+
+	SenderEmailSubjectEmailChangeTmpl = "Confirm your new email"
+	SenderEmailBodyEmailChangeTmpl    = `
+	<body style="padding: 30px;">
+		<h2>Confirm your new email</h2>
+
+		<p>Follow this link to use {{ .newEmail }} on your user:</p>
+		<p><a href="{{ .baseURL }}/email/verify/{{ .code }}">Confirm your mail</a></p>
+	</body>
+	`
+
+	SenderEmailSubjectEmailChangeRevertTmpl = "Your email is being changed"
+	SenderEmailBodyEmailChangeRevertTmpl    = `
+	<body style="padding: 30px;">
+		<h2>Your email is being changed</h2>
+
+		<p>A change of your email to {{ .newEmail }} was requested.</p>
+		<p>Was this not you? Follow this link to keep your current email:</p>
+		<p><a href="{{ .baseURL }}/email/revert/{{ .code }}">Revert the change</a></p>
+	</body>
+	`
+
+	SenderEmailSubjectPasswordChangedTmpl = "Your password was changed"
+	SenderEmailBodyPasswordChangedTmpl    = `
+	<body style="padding: 30px;">
+		<h2>Your password was changed</h2>
+
+		<p>The password of your user was changed and other sessions were signed out.</p>
+		<p>Was this not you? Follow this link to reset your password:</p>
+		<p><a href="{{ .baseURL }}/reset">Reset Password</a></p>
+	</body>
+	`
+
+	SenderEmailSubjectUserDeletedTmpl = "Your user was deleted"
+	SenderEmailBodyUserDeletedTmpl    = `
+	<body style="padding: 30px;">
+		<h2>Your user was deleted</h2>
+
+		<p>Your user and the data associated with it were removed as requested.</p>
+	</body>
+	`
+
+	SenderEmailSubjectAccountLockedTmpl = "Your user was locked"
+	SenderEmailBodyAccountLockedTmpl    = `
+	<body style="padding: 30px;">
+		<h2>Your user was locked</h2>
+
+		<p>There were too many failed sign in attempts on your user, it was locked until {{ .lockedUntil }}.</p>
+		<p>Was this you? Follow this link to unlock your user:</p>
+		<p><a href="{{ .baseURL }}/unlock/verify/{{ .code }}">Unlock</a></p>
+	</body>
+	`
+
+	SenderEmailSubjectInvitationTmpl = "You were invited"
+	SenderEmailBodyInvitationTmpl    = `
+	<body style="padding: 30px;">
+		<h2>You were invited</h2>
+
+		<p>{{ .inviterEmail }} invited you to join{{ if .organization }} {{ .organization }}{{ end }}.</p>
+		<p>Follow this link to accept the invitation:</p>
+		<p><a href="{{ .baseURL }}/invite/accept/{{ .code }}">Accept</a></p>
+	</body>
+	`
+
+	SenderEmailSubjectUserDeletionTmpl = "Confirm the deletion of your user"
+	SenderEmailBodyUserDeletionTmpl    = `
+	<body style="padding: 30px;">
+		<h2>Confirm the deletion of your user</h2>
+
+		<p>The deletion of your user and the data associated with it was requested.</p>
+		<p>Follow this link to confirm it:</p>
+		<p><a href="{{ .baseURL }}/user/delete/{{ .code }}">Delete</a></p>
+	</body>
+	`
 )
 
 type senderEmail struct {
@@ -69,12 +153,28 @@ func NewSenderEmail(
 	// set defaults for the templates
 	sender = WithEmailTemplates(
 		map[Template]string{
-			TemplateSignUp:        SenderEmailSubjectSignUpTmpl,
-			TemplateResetPassword: SenderEmailSubjectResetPasswordTmpl,
+			TemplateSignUp:            SenderEmailSubjectSignUpTmpl,
+			TemplateResetPassword:     SenderEmailSubjectResetPasswordTmpl,
+			TemplateMagicLink:         SenderEmailSubjectMagicLinkTmpl,
+			TemplateEmailChange:       SenderEmailSubjectEmailChangeTmpl,
+			TemplateEmailChangeRevert: SenderEmailSubjectEmailChangeRevertTmpl,
+			TemplatePasswordChanged:   SenderEmailSubjectPasswordChangedTmpl,
+			TemplateUserDeleted:       SenderEmailSubjectUserDeletedTmpl,
+			TemplateAccountLocked:     SenderEmailSubjectAccountLockedTmpl,
+			TemplateInvitation:        SenderEmailSubjectInvitationTmpl,
+			TemplateUserDeletion:      SenderEmailSubjectUserDeletionTmpl,
 		},
 		map[Template]string{
-			TemplateSignUp:        SenderEmailBodySignUpTmpl,
-			TemplateResetPassword: SenderEmailBodyResetPasswordTmpl,
+			TemplateSignUp:            SenderEmailBodySignUpTmpl,
+			TemplateResetPassword:     SenderEmailBodyResetPasswordTmpl,
+			TemplateMagicLink:         SenderEmailBodyMagicLinkTmpl,
+			TemplateEmailChange:       SenderEmailBodyEmailChangeTmpl,
+			TemplateEmailChangeRevert: SenderEmailBodyEmailChangeRevertTmpl,
+			TemplatePasswordChanged:   SenderEmailBodyPasswordChangedTmpl,
+			TemplateUserDeleted:       SenderEmailBodyUserDeletedTmpl,
+			TemplateAccountLocked:     SenderEmailBodyAccountLockedTmpl,
+			TemplateInvitation:        SenderEmailBodyInvitationTmpl,
+			TemplateUserDeletion:      SenderEmailBodyUserDeletionTmpl,
 		},
 	)(sender)
 
@@ -148,6 +248,12 @@ func (i *senderEmail) SendBulk(kind Template, list []map[string]string) error {
 	return nil
 }
 
+// SupportsTemplate checks if the sender has a template for the kind
+func (sender *senderEmail) SupportsTemplate(kind Template) bool {
+	_, ok := sender.bodyTemplates[kind]
+	return ok
+}
+
 func (sender *senderEmail) buildMessage(mail senderEmailMail) string {
 	msg := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\r\n"
 	msg += fmt.Sprintf("From: %s\r\n", mail.Sender)
@@ -0,0 +1,171 @@
+package sender
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+const (
+	SenderSMSDefaultEndpoint = "https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json"
+
+	SenderSMSBodyPhoneCodeTmpl = "Your sign in code is {{ .code }}"
+)
+
+type senderSMS struct {
+	endpoint   string
+	accountSID string
+	authToken  string
+	fromNumber string
+	client     *http.Client
+
+	bodyTemplates map[Template]*template.Template
+}
+
+type senderSMSOptFn func(*senderSMS) *senderSMS
+
+// NewSenderSMS creates a sender that delivers through a twilio compatible
+// messages api, templates without an sms body are skipped
+func NewSenderSMS(
+	accountSID string,
+	authToken string,
+	fromNumber string,
+	opts ...senderSMSOptFn,
+) *senderSMS {
+	sender := &senderSMS{
+		endpoint:   fmt.Sprintf(SenderSMSDefaultEndpoint, accountSID),
+		accountSID: accountSID,
+		authToken:  authToken,
+		fromNumber: fromNumber,
+		client:     http.DefaultClient,
+	}
+
+	// set defaults for the templates
+	sender = WithSMSTemplates(map[Template]string{
+		TemplatePhoneCode: SenderSMSBodyPhoneCodeTmpl,
+	})(sender)
+
+	return sender.SetOpts(opts...)
+}
+
+// SetOpts gives a simple way upon creation to change some of the options
+func (sender *senderSMS) SetOpts(opts ...senderSMSOptFn) *senderSMS {
+	for _, opt := range opts {
+		sender = opt(sender)
+	}
+
+	return sender
+}
+
+// SupportsTemplate checks if the sender has a template for the kind
+func (sender *senderSMS) SupportsTemplate(kind Template) bool {
+	_, ok := sender.bodyTemplates[kind]
+	return ok
+}
+
+// Send sends the templated content to a single recipient
+func (sender *senderSMS) Send(kind Template, userData map[string]string) error {
+	if len(sender.accountSID) == 0 {
+		return errors.New("account sid is required")
+	}
+
+	if len(sender.authToken) == 0 {
+		return errors.New("auth token is required")
+	}
+
+	if len(sender.fromNumber) == 0 {
+		return errors.New("from number is required")
+	}
+
+	toNumber := userData["phone"]
+	if len(toNumber) == 0 {
+		return errors.New("phone is required")
+	}
+
+	bodyTmpl, ok := sender.bodyTemplates[kind]
+	if !ok {
+		return fmt.Errorf("template %d not supported", kind)
+	}
+	body := new(strings.Builder)
+	err := bodyTmpl.Execute(body, userData)
+	if err != nil {
+		return err
+	}
+
+	return sender.write(toNumber, body.String())
+}
+
+// SendBulk sends a bulk of templated content to a list of recipients
+func (sender *senderSMS) SendBulk(kind Template, list []map[string]string) error {
+	for _, userData := range list {
+		err := sender.Send(kind, userData)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sender *senderSMS) write(toNumber string, body string) error {
+	form := url.Values{}
+	form.Set("To", toNumber)
+	form.Set("From", sender.fromNumber)
+	form.Set("Body", body)
+
+	req, err := http.NewRequest(http.MethodPost, sender.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(sender.accountSID, sender.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := sender.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("sms provider responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// WithSMSTemplates sets the templates for the sender
+func WithSMSTemplates(bodyTemplates map[Template]string) senderSMSOptFn {
+	// pre compile body templates
+	bodyTmpls := make(map[Template]*template.Template)
+	for kind, raw := range bodyTemplates {
+		tmpl, err := template.New(fmt.Sprintf("sms_body_template_%d", kind)).Parse(raw)
+		if err == nil {
+			bodyTmpls[kind] = tmpl
+		}
+	}
+
+	return func(sender *senderSMS) *senderSMS {
+		sender.bodyTemplates = bodyTmpls
+		return sender
+	}
+}
+
+// WithSMSEndpoint changes the messages api endpoint, for example to use
+// another twilio compatible provider
+func WithSMSEndpoint(endpoint string) senderSMSOptFn {
+	return func(sender *senderSMS) *senderSMS {
+		sender.endpoint = endpoint
+		return sender
+	}
+}
+
+// WithSMSHTTPClient changes the http client used to reach the provider
+func WithSMSHTTPClient(client *http.Client) senderSMSOptFn {
+	return func(sender *senderSMS) *senderSMS {
+		sender.client = client
+		return sender
+	}
+}
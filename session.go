@@ -0,0 +1,224 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// createSession registers the device of the request for the new session
+func (auth Auth) createSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	if auth.sessionStorage == nil {
+		return nil
+	}
+
+	now := time.Now()
+	return auth.sessionStorage.CreateSession(ctx, entity.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		UserAgent:  GetContextUserAgent(ctx),
+		IP:         GetContextClientIP(ctx),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	})
+}
+
+// touchSession updates the last seen of the session upon a refresh
+func (auth Auth) touchSession(ctx context.Context, sessionID uuid.UUID) error {
+	if auth.sessionStorage == nil {
+		return nil
+	}
+
+	return auth.sessionStorage.TouchSession(ctx, sessionID, GetContextClientIP(ctx), time.Now())
+}
+
+// getContextSessionID resolves the session of the request through the
+// tokens set by WithAuthUserID, uuid.Nil when there is none
+func (auth Auth) getContextSessionID(ctx context.Context) (uuid.UUID, error) {
+	accessToken, refreshToken := getContextTokens(ctx)
+	for _, value := range []string{accessToken, refreshToken} {
+		if len(value) == 0 {
+			continue
+		}
+
+		token, err := auth.tokenStorage.GetToken(ctx, value)
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			continue
+		}
+		if err != nil {
+			return uuid.Nil, err
+		}
+
+		return token.FamilyID, nil
+	}
+
+	return uuid.Nil, nil
+}
+
+// ListSessions returns the devices the user is signed in, the session of the
+// request (set by WithAuthUserID) is flagged as current
+func (auth Auth) ListSessions(ctx context.Context, userID uuid.UUID) ([]entity.Session, error) {
+	if auth.tokenStorage == nil || auth.sessionStorage == nil {
+		return nil, ErrStorageRequired
+	}
+
+	currentID, err := auth.getContextSessionID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := auth.sessionStorage.GetUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].IsCurrent = currentID != uuid.Nil && sessions[i].ID == currentID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession signs the user out of a single device
+func (auth Auth) RevokeSession(
+	ctx context.Context,
+	userID uuid.UUID,
+	sessionID uuid.UUID,
+) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRevokeSession, userID, err) }()
+
+	if auth.tokenStorage == nil {
+		return ErrStorageRequired
+	}
+
+	if sessionID == uuid.Nil {
+		return ErrSessionNotFound
+	}
+
+	// make sure the session is of the user before removing the family
+	found := false
+	tokens, err := auth.tokenStorage.GetUserTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if token.FamilyID == sessionID {
+			found = true
+			break
+		}
+	}
+
+	if !found && auth.sessionStorage != nil {
+		sessions, err := auth.sessionStorage.GetUserSessions(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			if session.ID == sessionID {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		return ErrSessionNotFound
+	}
+
+	err = auth.tokenStorage.RemoveTokenFamily(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if auth.sessionStorage == nil {
+		return nil
+	}
+
+	return auth.sessionStorage.RemoveSession(ctx, userID, sessionID)
+}
+
+// RevokeOtherSessions signs the user out of every device other than the one
+// of the request (set by WithAuthUserID)
+func (auth Auth) RevokeOtherSessions(ctx context.Context, userID uuid.UUID) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRevokeOtherSessions, userID, err) }()
+
+	if auth.tokenStorage == nil {
+		return ErrStorageRequired
+	}
+
+	return auth.revokeOtherSessions(ctx, userID)
+}
+
+func (auth Auth) revokeOtherSessions(ctx context.Context, userID uuid.UUID) error {
+	currentID, err := auth.getContextSessionID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := auth.tokenStorage.GetUserTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if token.Kind != entity.TokenKindAccess && token.Kind != entity.TokenKindRefresh {
+			continue
+		}
+
+		if currentID != uuid.Nil && token.FamilyID == currentID {
+			continue
+		}
+
+		err = auth.tokenStorage.RemoveUserToken(ctx, userID, token.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth.sessionStorage == nil {
+		return nil
+	}
+
+	sessions, err := auth.sessionStorage.GetUserSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if currentID != uuid.Nil && session.ID == currentID {
+			continue
+		}
+
+		err = auth.sessionStorage.RemoveSession(ctx, userID, session.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokeUserSessions removes the access and refresh tokens of the user
+func (auth Auth) revokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	err := auth.tokenStorage.RemoveUserTokensByKind(ctx, userID, entity.TokenKindAccess)
+	if err != nil {
+		return err
+	}
+
+	err = auth.tokenStorage.RemoveUserTokensByKind(ctx, userID, entity.TokenKindRefresh)
+	if err != nil {
+		return err
+	}
+
+	if auth.sessionStorage == nil {
+		return nil
+	}
+
+	return auth.sessionStorage.RemoveUserSessions(ctx, userID)
+}
@@ -0,0 +1,127 @@
+package goauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+var sessionsTests = []struct {
+	description   string
+	inRevokeOther bool
+	inOtherUser   bool
+	expectError   error
+}{
+	{"revoke session", false, false, nil},
+	{"revoke other sessions", true, false, nil},
+	{"session of another user", false, true, ErrSessionNotFound},
+}
+
+func TestSessions(t *testing.T) {
+	for _, testCase := range sessionsTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			userID := uuid.New()
+			otherUserID := uuid.New()
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			sessionStore := inmem.NewSessions([]entity.Session{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: userID, Email: "foo@bar.com", Password: encryptPassword("1234"), IsVerified: true},
+				{ID: otherUserID, Email: "nofoo@bar.com", Password: encryptPassword("4321"), IsVerified: true},
+			})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:  "1234",
+					TokenRefresh: "2345",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithSessionStorage(sessionStore),
+			)
+
+			laptopCtx := context.WithValue(context.Background(), ClientUserAgentKey, "laptop")
+			phoneCtx := context.WithValue(context.Background(), ClientUserAgentKey, "phone")
+			laptop, _ := auth.SignIn(laptopCtx, "foo@bar.com", "1234")
+			phone, _ := auth.SignIn(phoneCtx, "foo@bar.com", "1234")
+			_, _ = auth.SignIn(context.Background(), "nofoo@bar.com", "4321")
+
+			// the requests come from the laptop
+			ctx := context.WithValue(context.Background(), accessTokenKey, laptop.AccessToken)
+			ctx = context.WithValue(ctx, refreshTokenKey, laptop.RefreshToken)
+
+			sessions, err := auth.ListSessions(ctx, userID)
+			if err != nil {
+				t.Fatalf("expected: non error on list and got %v", err)
+			}
+			if len(sessions) != 2 {
+				t.Fatalf("expected: 2 sessions and got %d", len(sessions))
+			}
+
+			var phoneSessionID uuid.UUID
+			for _, session := range sessions {
+				if session.IsCurrent != (session.UserAgent == "laptop") {
+					t.Fatalf("expected: only the laptop to be current and got %v", session)
+				}
+
+				if session.UserAgent == "phone" {
+					phoneSessionID = session.ID
+				}
+			}
+
+			if testCase.inRevokeOther {
+				err = auth.RevokeOtherSessions(ctx, userID)
+			} else if testCase.inOtherUser {
+				err = auth.RevokeSession(ctx, otherUserID, phoneSessionID)
+			} else {
+				err = auth.RevokeSession(ctx, userID, phoneSessionID)
+			}
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("expected: %v and got %v", testCase.expectError, err)
+			}
+
+			if testCase.expectError != nil {
+				return
+			}
+
+			sessions, _ = auth.ListSessions(ctx, userID)
+			if len(sessions) != 1 || sessions[0].UserAgent != "laptop" {
+				t.Fatalf("expected: only the laptop session to be kept and got %v", sessions)
+			}
+
+			ok, _ := tokenStore.AreTokensRegistered(context.Background(), []string{phone.RefreshToken})
+			if ok {
+				t.Fatal("expected: phone tokens to be removed")
+			}
+
+			ok, _ = tokenStore.AreTokensRegistered(context.Background(), []string{laptop.RefreshToken})
+			if !ok {
+				t.Fatal("expected: laptop tokens to be kept")
+			}
+
+			// the access token of the revoked session is refused before it expires
+			handler := auth.WithAuthUserID(true, ErrorHandler)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}),
+			)
+			for accessToken, expectStatus := range map[string]int{
+				phone.AccessToken:  http.StatusUnauthorized,
+				laptop.AccessToken: http.StatusOK,
+			} {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Authorization", "Bearer "+accessToken)
+				handler.ServeHTTP(w, r)
+
+				if w.Code != expectStatus {
+					t.Fatalf("expected: %d and got %d", expectStatus, w.Code)
+				}
+			}
+		})
+	}
+}
@@ -3,5 +3,18 @@ package storage
 import "errors"
 
 var (
-	ErrUserNotFound = errors.New("user not found")
+	ErrAPIKeyNotFound             = errors.New("api key not found")
+	ErrAuditRecordNotFound        = errors.New("audit record not found")
+	ErrTokenNotFound              = errors.New("token not found")
+	ErrUserNotFound               = errors.New("user not found")
+	ErrIdentityNotFound           = errors.New("identity not found")
+	ErrPasskeyNotFound            = errors.New("passkey not found")
+	ErrPhoneCodeNotFound          = errors.New("phone code not found")
+	ErrInvitationNotFound         = errors.New("invitation not found")
+	ErrOAuthClientNotFound        = errors.New("oauth client not found")
+	ErrOAuthConsentNotFound       = errors.New("oauth consent not found")
+	ErrOrganizationNotFound       = errors.New("organization not found")
+	ErrOrganizationMemberNotFound = errors.New("organization member not found")
+	ErrRoleNotFound               = errors.New("role not found")
+	ErrSignInAttemptNotFound      = errors.New("sign in attempt not found")
 )
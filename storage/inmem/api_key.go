@@ -0,0 +1,93 @@
+package inmem
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+type apiKeys struct {
+	keys []entity.APIKey
+}
+
+func NewAPIKeys(initialKeys []entity.APIKey) *apiKeys {
+	return &apiKeys{
+		keys: initialKeys,
+	}
+}
+
+func (s *apiKeys) GetAll(ctx context.Context) ([]entity.APIKey, error) {
+	return s.keys, nil
+}
+
+func (s *apiKeys) CreateAPIKey(ctx context.Context, key entity.APIKey) error {
+	s.keys = append(s.keys, key)
+
+	return nil
+}
+
+func (s *apiKeys) TouchAPIKey(ctx context.Context, keyID uuid.UUID, lastUsedAt time.Time) error {
+	newKeys := []entity.APIKey{}
+	for _, key := range s.keys {
+		if key.ID == keyID {
+			key.LastUsedAt = lastUsedAt
+		}
+
+		newKeys = append(newKeys, key)
+	}
+	s.keys = newKeys
+
+	return nil
+}
+
+func (s *apiKeys) RemoveAPIKey(ctx context.Context, userID uuid.UUID, keyID uuid.UUID) error {
+	newKeys := []entity.APIKey{}
+	for _, key := range s.keys {
+		if key.UserID == userID && key.ID == keyID {
+			continue
+		}
+
+		newKeys = append(newKeys, key)
+	}
+	s.keys = newKeys
+
+	return nil
+}
+
+func (s *apiKeys) RemoveUserAPIKeys(ctx context.Context, userID uuid.UUID) error {
+	newKeys := []entity.APIKey{}
+	for _, key := range s.keys {
+		if key.UserID == userID {
+			continue
+		}
+
+		newKeys = append(newKeys, key)
+	}
+	s.keys = newKeys
+
+	return nil
+}
+
+func (s *apiKeys) GetAPIKeyByPrefix(ctx context.Context, prefix string) (entity.APIKey, error) {
+	for _, key := range s.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+
+	return entity.APIKey{}, storage.ErrAPIKeyNotFound
+}
+
+func (s *apiKeys) GetUserAPIKeys(ctx context.Context, userID uuid.UUID) ([]entity.APIKey, error) {
+	keys := []entity.APIKey{}
+	for _, key := range s.keys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,99 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+type auditRecords struct {
+	mu      sync.Mutex
+	records []entity.AuditRecord
+}
+
+func NewAuditRecords(initialRecords []entity.AuditRecord) *auditRecords {
+	return &auditRecords{
+		records: initialRecords,
+	}
+}
+
+func (s *auditRecords) GetAll(ctx context.Context) ([]entity.AuditRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.records, nil
+}
+
+func (s *auditRecords) AppendAuditRecord(
+	ctx context.Context,
+	record entity.AuditRecord,
+	hash func(entity.AuditRecord) string,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record.PrevHash = ""
+	if len(s.records) > 0 {
+		record.PrevHash = s.records[len(s.records)-1].Hash
+	}
+	record.Hash = hash(record)
+	record.ID = int64(len(s.records) + 1)
+	s.records = append(s.records, record)
+
+	return nil
+}
+
+func (s *auditRecords) GetLastAuditRecord(ctx context.Context) (entity.AuditRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.records) == 0 {
+		return entity.AuditRecord{}, storage.ErrAuditRecordNotFound
+	}
+
+	return s.records[len(s.records)-1], nil
+}
+
+func (s *auditRecords) ListAuditRecords(
+	ctx context.Context,
+	filter entity.AuditFilter,
+) ([]entity.AuditRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := []entity.AuditRecord{}
+	skipped := 0
+	for _, r := range s.records {
+		if len(filter.Action) > 0 && r.Action != filter.Action {
+			continue
+		}
+
+		if filter.UserID != uuid.Nil && r.UserID != filter.UserID {
+			continue
+		}
+
+		if !filter.From.IsZero() && r.CreatedAt.Before(filter.From) {
+			continue
+		}
+
+		if !filter.To.IsZero() && !r.CreatedAt.Before(filter.To) {
+			continue
+		}
+
+		if skipped < filter.Offset {
+			skipped += 1
+			continue
+		}
+
+		if filter.Limit > 0 && len(records) == filter.Limit {
+			break
+		}
+
+		records = append(records, r)
+	}
+
+	return records, nil
+}
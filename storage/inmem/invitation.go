@@ -0,0 +1,93 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+type invitations struct {
+	invitations []entity.Invitation
+}
+
+func NewInvitations(initialInvitations []entity.Invitation) *invitations {
+	return &invitations{
+		invitations: initialInvitations,
+	}
+}
+
+func (s *invitations) GetAll(ctx context.Context) ([]entity.Invitation, error) {
+	return s.invitations, nil
+}
+
+func (s *invitations) CreateInvitation(ctx context.Context, invitation entity.Invitation) error {
+	s.invitations = append(s.invitations, invitation)
+
+	return nil
+}
+
+func (s *invitations) UpdateInvitationStatus(
+	ctx context.Context,
+	invitationID uuid.UUID,
+	status string,
+) error {
+	newInvitations := []entity.Invitation{}
+	for _, invitation := range s.invitations {
+		if invitation.ID == invitationID {
+			invitation.Status = status
+		}
+
+		newInvitations = append(newInvitations, invitation)
+	}
+	s.invitations = newInvitations
+
+	return nil
+}
+
+func (s *invitations) GetInvitation(
+	ctx context.Context,
+	invitationID uuid.UUID,
+) (entity.Invitation, error) {
+	for _, invitation := range s.invitations {
+		if invitation.ID == invitationID {
+			return invitation, nil
+		}
+	}
+
+	return entity.Invitation{}, storage.ErrInvitationNotFound
+}
+
+func (s *invitations) GetInvitations(
+	ctx context.Context,
+	orgID uuid.UUID,
+) ([]entity.Invitation, error) {
+	invitations := []entity.Invitation{}
+	for _, invitation := range s.invitations {
+		if invitation.OrganizationID == orgID {
+			invitations = append(invitations, invitation)
+		}
+	}
+
+	return invitations, nil
+}
+
+func (s *invitations) RemovePendingUserInvitations(
+	ctx context.Context,
+	inviterID uuid.UUID,
+	email string,
+) error {
+	newInvitations := []entity.Invitation{}
+	for _, invitation := range s.invitations {
+		isUser := invitation.InviterID == inviterID || invitation.Email == email
+		if isUser && invitation.Status == entity.InvitationStatusPending {
+			continue
+		}
+
+		newInvitations = append(newInvitations, invitation)
+	}
+	s.invitations = newInvitations
+
+	return nil
+}
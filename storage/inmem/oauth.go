@@ -0,0 +1,138 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+type oauth struct {
+	clients  []entity.OAuthClient
+	consents []entity.OAuthConsent
+}
+
+func NewOAuth(initialClients []entity.OAuthClient, initialConsents []entity.OAuthConsent) *oauth {
+	return &oauth{
+		clients:  initialClients,
+		consents: initialConsents,
+	}
+}
+
+func (s *oauth) GetAll(ctx context.Context) ([]entity.OAuthClient, error) {
+	return s.clients, nil
+}
+
+func (s *oauth) CreateOAuthClient(ctx context.Context, client entity.OAuthClient) error {
+	s.clients = append(s.clients, client)
+
+	return nil
+}
+
+func (s *oauth) RemoveOAuthClient(ctx context.Context, clientID uuid.UUID) error {
+	newClients := []entity.OAuthClient{}
+	for _, client := range s.clients {
+		if client.ID == clientID {
+			continue
+		}
+
+		newClients = append(newClients, client)
+	}
+	s.clients = newClients
+
+	// the consents go away with the client
+	newConsents := []entity.OAuthConsent{}
+	for _, consent := range s.consents {
+		if consent.ClientID == clientID {
+			continue
+		}
+
+		newConsents = append(newConsents, consent)
+	}
+	s.consents = newConsents
+
+	return nil
+}
+
+func (s *oauth) GetOAuthClient(ctx context.Context, clientID uuid.UUID) (entity.OAuthClient, error) {
+	for _, client := range s.clients {
+		if client.ID == clientID {
+			return client, nil
+		}
+	}
+
+	return entity.OAuthClient{}, storage.ErrOAuthClientNotFound
+}
+
+func (s *oauth) SaveOAuthConsent(ctx context.Context, consent entity.OAuthConsent) error {
+	newConsents := []entity.OAuthConsent{}
+	for _, c := range s.consents {
+		if c.UserID == consent.UserID && c.ClientID == consent.ClientID {
+			// the consent is kept since the first time
+			consent.CreatedAt = c.CreatedAt
+			continue
+		}
+
+		newConsents = append(newConsents, c)
+	}
+	s.consents = append(newConsents, consent)
+
+	return nil
+}
+
+func (s *oauth) RemoveOAuthConsent(ctx context.Context, userID uuid.UUID, clientID uuid.UUID) error {
+	newConsents := []entity.OAuthConsent{}
+	for _, consent := range s.consents {
+		if consent.UserID == userID && consent.ClientID == clientID {
+			continue
+		}
+
+		newConsents = append(newConsents, consent)
+	}
+	s.consents = newConsents
+
+	return nil
+}
+
+func (s *oauth) RemoveUserOAuthConsents(ctx context.Context, userID uuid.UUID) error {
+	newConsents := []entity.OAuthConsent{}
+	for _, consent := range s.consents {
+		if consent.UserID == userID {
+			continue
+		}
+
+		newConsents = append(newConsents, consent)
+	}
+	s.consents = newConsents
+
+	return nil
+}
+
+func (s *oauth) GetOAuthConsent(
+	ctx context.Context,
+	userID uuid.UUID,
+	clientID uuid.UUID,
+) (entity.OAuthConsent, error) {
+	for _, consent := range s.consents {
+		if consent.UserID == userID && consent.ClientID == clientID {
+			return consent, nil
+		}
+	}
+
+	return entity.OAuthConsent{}, storage.ErrOAuthConsentNotFound
+}
+
+func (s *oauth) GetUserOAuthConsents(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]entity.OAuthConsent, error) {
+	consents := []entity.OAuthConsent{}
+	for _, consent := range s.consents {
+		if consent.UserID == userID {
+			consents = append(consents, consent)
+		}
+	}
+
+	return consents, nil
+}
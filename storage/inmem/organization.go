@@ -0,0 +1,140 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+type organizations struct {
+	organizations []entity.Organization
+	members       []entity.OrganizationMember
+}
+
+func NewOrganizations(
+	initialOrganizations []entity.Organization,
+	initialMembers []entity.OrganizationMember,
+) *organizations {
+	return &organizations{
+		organizations: initialOrganizations,
+		members:       initialMembers,
+	}
+}
+
+func (s *organizations) GetAll(ctx context.Context) ([]entity.Organization, error) {
+	return s.organizations, nil
+}
+
+func (s *organizations) CreateOrganization(ctx context.Context, org entity.Organization) error {
+	s.organizations = append(s.organizations, org)
+
+	return nil
+}
+
+func (s *organizations) GetOrganization(
+	ctx context.Context,
+	orgID uuid.UUID,
+) (entity.Organization, error) {
+	for _, org := range s.organizations {
+		if org.ID == orgID {
+			return org, nil
+		}
+	}
+
+	return entity.Organization{}, storage.ErrOrganizationNotFound
+}
+
+func (s *organizations) SaveOrganizationMember(
+	ctx context.Context,
+	member entity.OrganizationMember,
+) error {
+	newMembers := []entity.OrganizationMember{}
+	for _, m := range s.members {
+		if m.OrganizationID == member.OrganizationID && m.UserID == member.UserID {
+			// the membership is kept since the first time
+			member.CreatedAt = m.CreatedAt
+			continue
+		}
+
+		newMembers = append(newMembers, m)
+	}
+	s.members = append(newMembers, member)
+
+	return nil
+}
+
+func (s *organizations) RemoveOrganizationMember(
+	ctx context.Context,
+	orgID uuid.UUID,
+	userID uuid.UUID,
+) error {
+	newMembers := []entity.OrganizationMember{}
+	for _, m := range s.members {
+		if m.OrganizationID == orgID && m.UserID == userID {
+			continue
+		}
+
+		newMembers = append(newMembers, m)
+	}
+	s.members = newMembers
+
+	return nil
+}
+
+func (s *organizations) RemoveUserOrganizationMembers(ctx context.Context, userID uuid.UUID) error {
+	newMembers := []entity.OrganizationMember{}
+	for _, m := range s.members {
+		if m.UserID == userID {
+			continue
+		}
+
+		newMembers = append(newMembers, m)
+	}
+	s.members = newMembers
+
+	return nil
+}
+
+func (s *organizations) GetOrganizationMember(
+	ctx context.Context,
+	orgID uuid.UUID,
+	userID uuid.UUID,
+) (entity.OrganizationMember, error) {
+	for _, m := range s.members {
+		if m.OrganizationID == orgID && m.UserID == userID {
+			return m, nil
+		}
+	}
+
+	return entity.OrganizationMember{}, storage.ErrOrganizationMemberNotFound
+}
+
+func (s *organizations) GetOrganizationMembers(
+	ctx context.Context,
+	orgID uuid.UUID,
+) ([]entity.OrganizationMember, error) {
+	members := []entity.OrganizationMember{}
+	for _, m := range s.members {
+		if m.OrganizationID == orgID {
+			members = append(members, m)
+		}
+	}
+
+	return members, nil
+}
+
+func (s *organizations) GetUserOrganizationMembers(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]entity.OrganizationMember, error) {
+	members := []entity.OrganizationMember{}
+	for _, m := range s.members {
+		if m.UserID == userID {
+			members = append(members, m)
+		}
+	}
+
+	return members, nil
+}
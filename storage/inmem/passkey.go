@@ -0,0 +1,83 @@
+package inmem
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+type passkeys struct {
+	passkeys []entity.Passkey
+}
+
+func NewPasskeys(initialPasskeys []entity.Passkey) *passkeys {
+	return &passkeys{
+		passkeys: initialPasskeys,
+	}
+}
+
+func (s *passkeys) GetAll(ctx context.Context) ([]entity.Passkey, error) {
+	return s.passkeys, nil
+}
+
+func (s *passkeys) CreatePasskey(ctx context.Context, passkey entity.Passkey) error {
+	s.passkeys = append(s.passkeys, passkey)
+
+	return nil
+}
+
+func (s *passkeys) UpdatePasskeySignCount(
+	ctx context.Context,
+	credentialID []byte,
+	signCount uint32,
+) error {
+	newPasskeys := []entity.Passkey{}
+	for _, p := range s.passkeys {
+		if bytes.Equal(p.ID, credentialID) {
+			p.SignCount = signCount
+			p.LastUsedAt = time.Now()
+		}
+
+		newPasskeys = append(newPasskeys, p)
+	}
+	s.passkeys = newPasskeys
+
+	return nil
+}
+
+func (s *passkeys) GetPasskey(ctx context.Context, credentialID []byte) (entity.Passkey, error) {
+	for _, p := range s.passkeys {
+		if bytes.Equal(p.ID, credentialID) {
+			return p, nil
+		}
+	}
+
+	return entity.Passkey{}, storage.ErrPasskeyNotFound
+}
+
+func (s *passkeys) GetUserPasskeys(ctx context.Context, userID uuid.UUID) ([]entity.Passkey, error) {
+	passkeys := []entity.Passkey{}
+	for _, p := range s.passkeys {
+		if p.UserID == userID {
+			passkeys = append(passkeys, p)
+		}
+	}
+
+	return passkeys, nil
+}
+
+func (s *passkeys) RemoveUserPasskeys(ctx context.Context, userID uuid.UUID) error {
+	newPasskeys := []entity.Passkey{}
+	for _, p := range s.passkeys {
+		if p.UserID != userID {
+			newPasskeys = append(newPasskeys, p)
+		}
+	}
+	s.passkeys = newPasskeys
+
+	return nil
+}
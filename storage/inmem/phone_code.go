@@ -0,0 +1,71 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+type phoneCodes struct {
+	codes []entity.PhoneCode
+}
+
+func NewPhoneCodes(initialCodes []entity.PhoneCode) *phoneCodes {
+	return &phoneCodes{
+		codes: initialCodes,
+	}
+}
+
+func (s *phoneCodes) GetAll(ctx context.Context) ([]entity.PhoneCode, error) {
+	return s.codes, nil
+}
+
+func (s *phoneCodes) CreatePhoneCode(ctx context.Context, code entity.PhoneCode) error {
+	// a user only has one code at a time
+	err := s.RemovePhoneCode(ctx, code.UserID)
+	if err != nil {
+		return err
+	}
+
+	s.codes = append(s.codes, code)
+
+	return nil
+}
+
+func (s *phoneCodes) IncrementPhoneCodeAttempts(ctx context.Context, userID uuid.UUID) error {
+	newCodes := []entity.PhoneCode{}
+	for _, c := range s.codes {
+		if c.UserID == userID {
+			c.Attempts += 1
+		}
+
+		newCodes = append(newCodes, c)
+	}
+	s.codes = newCodes
+
+	return nil
+}
+
+func (s *phoneCodes) RemovePhoneCode(ctx context.Context, userID uuid.UUID) error {
+	newCodes := []entity.PhoneCode{}
+	for _, c := range s.codes {
+		if c.UserID != userID {
+			newCodes = append(newCodes, c)
+		}
+	}
+	s.codes = newCodes
+
+	return nil
+}
+
+func (s *phoneCodes) GetPhoneCode(ctx context.Context, userID uuid.UUID) (entity.PhoneCode, error) {
+	for _, c := range s.codes {
+		if c.UserID == userID {
+			return c, nil
+		}
+	}
+
+	return entity.PhoneCode{}, storage.ErrPhoneCodeNotFound
+}
@@ -0,0 +1,83 @@
+package inmem
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type rateLimitBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// rateLimiter is a token bucket per key, a key can burst up to burst
+// requests and regains one every interval
+type rateLimiter struct {
+	mu       sync.Mutex
+	burst    int
+	interval time.Duration
+	buckets  map[string]rateLimitBucket
+	sweptAt  time.Time
+}
+
+func NewRateLimiter(burst int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		burst:    burst,
+		interval: interval,
+		buckets:  map[string]rateLimitBucket{},
+		sweptAt:  time.Now(),
+	}
+}
+
+// refill returns the tokens of the bucket per the time passed since the last
+// request
+func (s *rateLimiter) refill(bucket rateLimitBucket, now time.Time) float64 {
+	elapsed := now.Sub(bucket.updatedAt)
+	return math.Min(
+		float64(s.burst),
+		bucket.tokens+float64(elapsed)/float64(s.interval),
+	)
+}
+
+// sweep evicts the buckets that refilled, these are the same as a key never
+// seen. It runs at most once every interval to keep Allow cheap
+func (s *rateLimiter) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < s.interval {
+		return
+	}
+
+	for key, bucket := range s.buckets {
+		if s.refill(bucket, now) >= float64(s.burst) {
+			delete(s.buckets, key)
+		}
+	}
+	s.sweptAt = now
+}
+
+func (s *rateLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = rateLimitBucket{tokens: float64(s.burst), updatedAt: now}
+	}
+
+	bucket.tokens = s.refill(bucket, now)
+	bucket.updatedAt = now
+
+	if bucket.tokens < 1 {
+		s.buckets[key] = bucket
+		return false, time.Duration((1 - bucket.tokens) * float64(s.interval)), nil
+	}
+
+	bucket.tokens -= 1
+	s.buckets[key] = bucket
+
+	return true, 0, nil
+}
@@ -0,0 +1,75 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+)
+
+type recoveryCodes struct {
+	codes []entity.RecoveryCode
+}
+
+func NewRecoveryCodes(initialCodes []entity.RecoveryCode) *recoveryCodes {
+	return &recoveryCodes{
+		codes: initialCodes,
+	}
+}
+
+func (s *recoveryCodes) GetAll(ctx context.Context) ([]entity.RecoveryCode, error) {
+	return s.codes, nil
+}
+
+func (s *recoveryCodes) CreateRecoveryCodes(
+	ctx context.Context,
+	codes []entity.RecoveryCode,
+) error {
+	s.codes = append(s.codes, codes...)
+
+	return nil
+}
+
+func (s *recoveryCodes) RemoveUserRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	newCodes := []entity.RecoveryCode{}
+	for _, c := range s.codes {
+		if c.UserID != userID {
+			newCodes = append(newCodes, c)
+		}
+	}
+	s.codes = newCodes
+
+	return nil
+}
+
+func (s *recoveryCodes) RemoveUserRecoveryCode(
+	ctx context.Context,
+	userID uuid.UUID,
+	code string,
+) error {
+	newCodes := []entity.RecoveryCode{}
+	for _, c := range s.codes {
+		if c.UserID == userID && c.Code == code {
+			continue
+		}
+
+		newCodes = append(newCodes, c)
+	}
+	s.codes = newCodes
+
+	return nil
+}
+
+func (s *recoveryCodes) GetUserRecoveryCodes(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]entity.RecoveryCode, error) {
+	codes := []entity.RecoveryCode{}
+	for _, c := range s.codes {
+		if c.UserID == userID {
+			codes = append(codes, c)
+		}
+	}
+
+	return codes, nil
+}
@@ -0,0 +1,130 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+type roles struct {
+	roles     []entity.Role
+	userRoles []entity.UserRole
+}
+
+func NewRoles(initialRoles []entity.Role, initialUserRoles []entity.UserRole) *roles {
+	return &roles{
+		roles:     initialRoles,
+		userRoles: initialUserRoles,
+	}
+}
+
+func (s *roles) GetAll(ctx context.Context) ([]entity.Role, error) {
+	return s.roles, nil
+}
+
+func (s *roles) SaveRole(ctx context.Context, role entity.Role) error {
+	newRoles := []entity.Role{}
+	for _, r := range s.roles {
+		if r.Name == role.Name {
+			continue
+		}
+
+		newRoles = append(newRoles, r)
+	}
+	s.roles = append(newRoles, role)
+
+	return nil
+}
+
+func (s *roles) RemoveRole(ctx context.Context, name string) error {
+	newRoles := []entity.Role{}
+	for _, r := range s.roles {
+		if r.Name == name {
+			continue
+		}
+
+		newRoles = append(newRoles, r)
+	}
+	s.roles = newRoles
+
+	newUserRoles := []entity.UserRole{}
+	for _, r := range s.userRoles {
+		if r.Role == name {
+			continue
+		}
+
+		newUserRoles = append(newUserRoles, r)
+	}
+	s.userRoles = newUserRoles
+
+	return nil
+}
+
+func (s *roles) GetRole(ctx context.Context, name string) (entity.Role, error) {
+	for _, r := range s.roles {
+		if r.Name == name {
+			return r, nil
+		}
+	}
+
+	return entity.Role{}, storage.ErrRoleNotFound
+}
+
+func (s *roles) AssignUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	for _, r := range s.userRoles {
+		if r.UserID == userID && r.Role == role {
+			return nil
+		}
+	}
+
+	s.userRoles = append(s.userRoles, entity.UserRole{UserID: userID, Role: role})
+
+	return nil
+}
+
+func (s *roles) UnassignUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	newUserRoles := []entity.UserRole{}
+	for _, r := range s.userRoles {
+		if r.UserID == userID && r.Role == role {
+			continue
+		}
+
+		newUserRoles = append(newUserRoles, r)
+	}
+	s.userRoles = newUserRoles
+
+	return nil
+}
+
+func (s *roles) RemoveUserRoles(ctx context.Context, userID uuid.UUID) error {
+	newUserRoles := []entity.UserRole{}
+	for _, r := range s.userRoles {
+		if r.UserID == userID {
+			continue
+		}
+
+		newUserRoles = append(newUserRoles, r)
+	}
+	s.userRoles = newUserRoles
+
+	return nil
+}
+
+func (s *roles) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	userRoles := []entity.Role{}
+	for _, userRole := range s.userRoles {
+		if userRole.UserID != userID {
+			continue
+		}
+
+		for _, r := range s.roles {
+			if r.Name == userRole.Role {
+				userRoles = append(userRoles, r)
+			}
+		}
+	}
+
+	return userRoles, nil
+}
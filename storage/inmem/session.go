@@ -0,0 +1,88 @@
+package inmem
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+)
+
+type sessions struct {
+	sessions []entity.Session
+}
+
+func NewSessions(initialSessions []entity.Session) *sessions {
+	return &sessions{
+		sessions: initialSessions,
+	}
+}
+
+func (s *sessions) GetAll(ctx context.Context) ([]entity.Session, error) {
+	return s.sessions, nil
+}
+
+func (s *sessions) CreateSession(ctx context.Context, session entity.Session) error {
+	s.sessions = append(s.sessions, session)
+
+	return nil
+}
+
+func (s *sessions) TouchSession(
+	ctx context.Context,
+	sessionID uuid.UUID,
+	ip string,
+	lastSeenAt time.Time,
+) error {
+	newSessions := []entity.Session{}
+	for _, session := range s.sessions {
+		if session.ID == sessionID {
+			if len(ip) > 0 {
+				session.IP = ip
+			}
+			session.LastSeenAt = lastSeenAt
+		}
+
+		newSessions = append(newSessions, session)
+	}
+	s.sessions = newSessions
+
+	return nil
+}
+
+func (s *sessions) RemoveSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	newSessions := []entity.Session{}
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.ID == sessionID {
+			continue
+		}
+
+		newSessions = append(newSessions, session)
+	}
+	s.sessions = newSessions
+
+	return nil
+}
+
+func (s *sessions) RemoveUserSessions(ctx context.Context, userID uuid.UUID) error {
+	newSessions := []entity.Session{}
+	for _, session := range s.sessions {
+		if session.UserID != userID {
+			newSessions = append(newSessions, session)
+		}
+	}
+	s.sessions = newSessions
+
+	return nil
+}
+
+func (s *sessions) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]entity.Session, error) {
+	sessions := []entity.Session{}
+	for _, session := range s.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions, nil
+}
@@ -0,0 +1,60 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+type signInAttempts struct {
+	attempts []entity.SignInAttempt
+}
+
+func NewSignInAttempts(initialAttempts []entity.SignInAttempt) *signInAttempts {
+	return &signInAttempts{
+		attempts: initialAttempts,
+	}
+}
+
+func (s *signInAttempts) GetAll(ctx context.Context) ([]entity.SignInAttempt, error) {
+	return s.attempts, nil
+}
+
+func (s *signInAttempts) SaveSignInAttempt(ctx context.Context, attempt entity.SignInAttempt) error {
+	// a user only has one record at a time
+	err := s.RemoveSignInAttempt(ctx, attempt.UserID)
+	if err != nil {
+		return err
+	}
+
+	s.attempts = append(s.attempts, attempt)
+
+	return nil
+}
+
+func (s *signInAttempts) RemoveSignInAttempt(ctx context.Context, userID uuid.UUID) error {
+	newAttempts := []entity.SignInAttempt{}
+	for _, a := range s.attempts {
+		if a.UserID != userID {
+			newAttempts = append(newAttempts, a)
+		}
+	}
+	s.attempts = newAttempts
+
+	return nil
+}
+
+func (s *signInAttempts) GetSignInAttempt(
+	ctx context.Context,
+	userID uuid.UUID,
+) (entity.SignInAttempt, error) {
+	for _, a := range s.attempts {
+		if a.UserID == userID {
+			return a, nil
+		}
+	}
+
+	return entity.SignInAttempt{}, storage.ErrSignInAttemptNotFound
+}
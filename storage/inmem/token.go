@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
 )
 
 type tokens struct {
@@ -57,11 +58,29 @@ func (s *tokens) RemoveUserToken(
 	return nil
 }
 
+func (s *tokens) RemoveUserTokensByKind(
+	ctx context.Context,
+	userID uuid.UUID,
+	kind entity.TokenKind,
+) error {
+	newTokens := []entity.Token{}
+	for _, t := range s.tokens {
+		if t.UserID == userID && t.Kind == kind {
+			continue
+		}
+
+		newTokens = append(newTokens, t)
+	}
+	s.tokens = newTokens
+
+	return nil
+}
+
 func (s *tokens) AreTokensRegistered(ctx context.Context, tokens []string) (bool, error) {
 	found := 0
 	for _, storageToken := range s.tokens {
 		for _, token := range tokens {
-			if storageToken.Value == token {
+			if storageToken.Value == token && !storageToken.IsRevoked {
 				found += 1
 				break
 			}
@@ -70,3 +89,92 @@ func (s *tokens) AreTokensRegistered(ctx context.Context, tokens []string) (bool
 
 	return len(tokens) == found, nil
 }
+
+func (s *tokens) GetUserTokens(ctx context.Context, userID uuid.UUID) ([]entity.Token, error) {
+	tokens := []entity.Token{}
+	for _, t := range s.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, t)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (s *tokens) RemoveTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	if familyID == uuid.Nil {
+		return nil
+	}
+
+	newTokens := []entity.Token{}
+	for _, t := range s.tokens {
+		if t.FamilyID != familyID {
+			newTokens = append(newTokens, t)
+		}
+	}
+	s.tokens = newTokens
+
+	return nil
+}
+
+func (s *tokens) RemoveClientTokens(ctx context.Context, clientID uuid.UUID) error {
+	if clientID == uuid.Nil {
+		return nil
+	}
+
+	newTokens := []entity.Token{}
+	for _, t := range s.tokens {
+		if t.ClientID != clientID {
+			newTokens = append(newTokens, t)
+		}
+	}
+	s.tokens = newTokens
+
+	return nil
+}
+
+func (s *tokens) RemoveUserClientTokens(
+	ctx context.Context,
+	userID uuid.UUID,
+	clientID uuid.UUID,
+) error {
+	if clientID == uuid.Nil {
+		return nil
+	}
+
+	newTokens := []entity.Token{}
+	for _, t := range s.tokens {
+		if t.UserID == userID && t.ClientID == clientID {
+			continue
+		}
+
+		newTokens = append(newTokens, t)
+	}
+	s.tokens = newTokens
+
+	return nil
+}
+
+func (s *tokens) RevokeToken(ctx context.Context, token string) error {
+	newTokens := []entity.Token{}
+	for _, t := range s.tokens {
+		if t.Value == token {
+			t.IsRevoked = true
+		}
+
+		newTokens = append(newTokens, t)
+	}
+	s.tokens = newTokens
+
+	return nil
+}
+
+func (s *tokens) GetToken(ctx context.Context, token string) (entity.Token, error) {
+	for _, t := range s.tokens {
+		if t.Value == token {
+			return t, nil
+		}
+	}
+
+	return entity.Token{}, storage.ErrTokenNotFound
+}
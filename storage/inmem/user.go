@@ -24,9 +24,12 @@ func (s *users) GetAll(ctx context.Context) ([]entity.AuthUser, error) {
 
 func (s *users) CreateUser(ctx context.Context, user entity.AuthUser) error {
 	s.users = append(s.users, entity.AuthUser{
-		ID:       user.ID,
-		Email:    user.Email,
-		Password: user.Password,
+		ID:          user.ID,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+		Password:    user.Password,
+		Meta:        user.Meta,
+		TenantID:    user.TenantID,
 	})
 
 	return nil
@@ -50,6 +53,24 @@ func (s *users) UpdateUserPassword(
 	return nil
 }
 
+func (s *users) UpdateUserEmail(
+	ctx context.Context,
+	userID uuid.UUID,
+	email string,
+) error {
+	newUsers := []entity.AuthUser{}
+	for _, u := range s.users {
+		if u.ID == userID {
+			u.Email = email
+		}
+
+		newUsers = append(newUsers, u)
+	}
+	s.users = newUsers
+
+	return nil
+}
+
 func (s *users) VerifyUser(ctx context.Context, userID uuid.UUID) error {
 	newUsers := []entity.AuthUser{}
 	for _, u := range s.users {
@@ -64,6 +85,44 @@ func (s *users) VerifyUser(ctx context.Context, userID uuid.UUID) error {
 	return nil
 }
 
+func (s *users) UpdateUserTOTP(
+	ctx context.Context,
+	userID uuid.UUID,
+	secret string,
+	isEnabled bool,
+) error {
+	newUsers := []entity.AuthUser{}
+	for _, u := range s.users {
+		if u.ID == userID {
+			u.TOTPSecret = secret
+			u.IsTOTPEnabled = isEnabled
+		}
+
+		newUsers = append(newUsers, u)
+	}
+	s.users = newUsers
+
+	return nil
+}
+
+func (s *users) UpdateUserTOTPLastStep(
+	ctx context.Context,
+	userID uuid.UUID,
+	step int64,
+) error {
+	newUsers := []entity.AuthUser{}
+	for _, u := range s.users {
+		if u.ID == userID {
+			u.TOTPLastStep = step
+		}
+
+		newUsers = append(newUsers, u)
+	}
+	s.users = newUsers
+
+	return nil
+}
+
 func (s *users) GetUserByID(ctx context.Context, userID uuid.UUID) (entity.AuthUser, error) {
 	for _, u := range s.users {
 		if u.ID == userID {
@@ -83,3 +142,62 @@ func (s *users) GetUserByEmail(ctx context.Context, email string) (entity.AuthUs
 
 	return entity.AuthUser{}, storage.ErrUserNotFound
 }
+
+func (s *users) GetTenantUserByEmail(
+	ctx context.Context,
+	tenantID uuid.UUID,
+	email string,
+) (entity.AuthUser, error) {
+	for _, u := range s.users {
+		if u.TenantID == tenantID && u.Email == email {
+			return u, nil
+		}
+	}
+
+	return entity.AuthUser{}, storage.ErrUserNotFound
+}
+
+func (s *users) GetUserByPhone(ctx context.Context, phone string) (entity.AuthUser, error) {
+	for _, u := range s.users {
+		if len(u.PhoneNumber) > 0 && u.PhoneNumber == phone {
+			return u, nil
+		}
+	}
+
+	return entity.AuthUser{}, storage.ErrUserNotFound
+}
+
+func (s *users) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	newUsers := []entity.AuthUser{}
+	for _, u := range s.users {
+		if u.ID != userID {
+			newUsers = append(newUsers, u)
+		}
+	}
+	s.users = newUsers
+
+	return nil
+}
+
+func (s *users) AnonymizeUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	email string,
+) error {
+	newUsers := []entity.AuthUser{}
+	for _, u := range s.users {
+		if u.ID == userID {
+			u = entity.AuthUser{
+				ID:         u.ID,
+				Email:      email,
+				IsVerified: u.IsVerified,
+				TenantID:   u.TenantID,
+			}
+		}
+
+		newUsers = append(newUsers, u)
+	}
+	s.users = newUsers
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+type userIdentities struct {
+	identities []entity.UserIdentity
+}
+
+func NewUserIdentities(initialIdentities []entity.UserIdentity) *userIdentities {
+	return &userIdentities{
+		identities: initialIdentities,
+	}
+}
+
+func (s *userIdentities) GetAll(ctx context.Context) ([]entity.UserIdentity, error) {
+	return s.identities, nil
+}
+
+func (s *userIdentities) CreateIdentity(ctx context.Context, identity entity.UserIdentity) error {
+	s.identities = append(s.identities, identity)
+
+	return nil
+}
+
+func (s *userIdentities) GetIdentity(
+	ctx context.Context,
+	provider string,
+	subject string,
+) (entity.UserIdentity, error) {
+	for _, identity := range s.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+
+	return entity.UserIdentity{}, storage.ErrIdentityNotFound
+}
+
+func (s *userIdentities) GetUserIdentities(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]entity.UserIdentity, error) {
+	identities := []entity.UserIdentity{}
+	for _, identity := range s.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+
+	return identities, nil
+}
+
+func (s *userIdentities) RemoveUserIdentities(ctx context.Context, userID uuid.UUID) error {
+	newIdentities := []entity.UserIdentity{}
+	for _, identity := range s.identities {
+		if identity.UserID == userID {
+			continue
+		}
+
+		newIdentities = append(newIdentities, identity)
+	}
+	s.identities = newIdentities
+
+	return nil
+}
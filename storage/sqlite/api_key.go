@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type apiKeys struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewAPIKeys(db dbWithTx) *apiKeys {
+	return &apiKeys{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+func dbAPIKeyToAPIKey(dbKey dbgen.AppAuthApiKey) (entity.APIKey, error) {
+	keyID, err := uuid.Parse(dbKey.ID)
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	userID, err := uuid.Parse(dbKey.UserID)
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	scopes := []string{}
+	err = json.Unmarshal([]byte(dbKey.Scopes), &scopes)
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	expiresAt := time.Time{}
+	if dbKey.ExpiresAt.Valid {
+		expiresAt, err = time.Parse(timestampFormat, dbKey.ExpiresAt.String)
+		if err != nil {
+			return entity.APIKey{}, err
+		}
+	}
+
+	lastUsedAt := time.Time{}
+	if dbKey.LastUsedAt.Valid {
+		lastUsedAt, err = time.Parse(timestampFormat, dbKey.LastUsedAt.String)
+		if err != nil {
+			return entity.APIKey{}, err
+		}
+	}
+
+	createdAt, err := time.Parse(timestampFormat, dbKey.CreatedAt)
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	return entity.APIKey{
+		ID:         keyID,
+		UserID:     userID,
+		Name:       dbKey.Name,
+		Prefix:     dbKey.Prefix,
+		Hash:       dbKey.Hash,
+		Scopes:     scopes,
+		ExpiresAt:  expiresAt,
+		LastUsedAt: lastUsedAt,
+		CreatedAt:  createdAt,
+	}, nil
+}
+
+func (s *apiKeys) CreateAPIKey(ctx context.Context, key entity.APIKey) error {
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	rawScopes, err := json.Marshal(scopes)
+	if err != nil {
+		return err
+	}
+
+	return s.dbgen().CreateAPIKey(ctx, dbgen.CreateAPIKeyParams{
+		ID:     key.ID.String(),
+		UserID: key.UserID.String(),
+		Name:   key.Name,
+		Prefix: key.Prefix,
+		Hash:   key.Hash,
+		Scopes: string(rawScopes),
+		ExpiresAt: sql.NullString{
+			String: key.ExpiresAt.UTC().Format(timestampFormat),
+			Valid:  !key.ExpiresAt.IsZero(),
+		},
+		CreatedAt: key.CreatedAt.UTC().Format(timestampFormat),
+	})
+}
+
+func (s *apiKeys) TouchAPIKey(ctx context.Context, keyID uuid.UUID, lastUsedAt time.Time) error {
+	return s.dbgen().TouchAPIKey(ctx, dbgen.TouchAPIKeyParams{
+		LastUsedAt: sql.NullString{
+			String: lastUsedAt.UTC().Format(timestampFormat),
+			Valid:  true,
+		},
+		ID: keyID.String(),
+	})
+}
+
+func (s *apiKeys) RemoveAPIKey(ctx context.Context, userID uuid.UUID, keyID uuid.UUID) error {
+	return s.dbgen().RemoveAPIKey(ctx, dbgen.RemoveAPIKeyParams{
+		UserID: userID.String(),
+		ID:     keyID.String(),
+	})
+}
+
+func (s *apiKeys) RemoveUserAPIKeys(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().RemoveUserAPIKeys(ctx, userID.String())
+}
+
+func (s *apiKeys) GetAPIKeyByPrefix(ctx context.Context, prefix string) (entity.APIKey, error) {
+	dbKey, err := s.dbgen().GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.APIKey{}, storage.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	return dbAPIKeyToAPIKey(dbKey)
+}
+
+func (s *apiKeys) GetUserAPIKeys(ctx context.Context, userID uuid.UUID) ([]entity.APIKey, error) {
+	dbKeys, err := s.dbgen().GetUserAPIKeys(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]entity.APIKey, len(dbKeys))
+	for i, dbKey := range dbKeys {
+		keys[i], err = dbAPIKeyToAPIKey(dbKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
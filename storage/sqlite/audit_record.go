@@ -0,0 +1,148 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type auditRecords struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewAuditRecords(db dbWithTx) *auditRecords {
+	return &auditRecords{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+func dbAuditRecordToAuditRecord(dbRecord dbgen.AppAuthAuditRecord) (entity.AuditRecord, error) {
+	actorID, err := parseOptionalID(dbRecord.ActorID)
+	if err != nil {
+		return entity.AuditRecord{}, err
+	}
+
+	userID, err := parseOptionalID(dbRecord.UserID)
+	if err != nil {
+		return entity.AuditRecord{}, err
+	}
+
+	createdAt, err := time.Parse(timestampFormat, dbRecord.CreatedAt)
+	if err != nil {
+		return entity.AuditRecord{}, err
+	}
+
+	return entity.AuditRecord{
+		ID:        dbRecord.ID,
+		Action:    dbRecord.Action,
+		ActorID:   actorID,
+		UserID:    userID,
+		IP:        dbRecord.Ip,
+		UserAgent: dbRecord.UserAgent,
+		Outcome:   dbRecord.Outcome,
+		Error:     dbRecord.Error,
+		PrevHash:  dbRecord.PrevHash,
+		Hash:      dbRecord.Hash,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+func (s *auditRecords) AppendAuditRecord(
+	ctx context.Context,
+	record entity.AuditRecord,
+	hash func(entity.AuditRecord) string,
+) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.dbgen().WithTx(tx)
+
+	// the last record is read within the transaction so that the appends of
+	// other instances can't chain to the same one
+	last, err := qtx.GetLastAuditRecord(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	record.PrevHash = last.Hash
+	record.Hash = hash(record)
+
+	err = qtx.AppendAuditRecord(ctx, dbgen.AppendAuditRecordParams{
+		Action:    record.Action,
+		ActorID:   optionalIDToString(record.ActorID),
+		UserID:    optionalIDToString(record.UserID),
+		Ip:        record.IP,
+		UserAgent: record.UserAgent,
+		Outcome:   record.Outcome,
+		Error:     record.Error,
+		PrevHash:  record.PrevHash,
+		Hash:      record.Hash,
+		CreatedAt: record.CreatedAt.UTC().Format(timestampFormat),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *auditRecords) GetLastAuditRecord(ctx context.Context) (entity.AuditRecord, error) {
+	dbRecord, err := s.dbgen().GetLastAuditRecord(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.AuditRecord{}, storage.ErrAuditRecordNotFound
+	}
+	if err != nil {
+		return entity.AuditRecord{}, err
+	}
+
+	return dbAuditRecordToAuditRecord(dbRecord)
+}
+
+func (s *auditRecords) ListAuditRecords(
+	ctx context.Context,
+	filter entity.AuditFilter,
+) ([]entity.AuditRecord, error) {
+	params := dbgen.ListAuditRecordsParams{
+		UserID: optionalIDToString(filter.UserID),
+		Action: filter.Action,
+		// a negative limit has no upper bound on sqlite
+		Limit:  -1,
+		Offset: int64(filter.Offset),
+	}
+	if filter.Limit > 0 {
+		params.Limit = int64(filter.Limit)
+	}
+	if !filter.From.IsZero() {
+		params.FromAt = filter.From.UTC().Format(timestampFormat)
+	}
+	if !filter.To.IsZero() {
+		params.ToAt = filter.To.UTC().Format(timestampFormat)
+	}
+
+	dbRecords, err := s.dbgen().ListAuditRecords(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]entity.AuditRecord, len(dbRecords))
+	for i, dbRecord := range dbRecords {
+		records[i], err = dbAuditRecordToAuditRecord(dbRecord)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
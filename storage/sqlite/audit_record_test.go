@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/iamajoe/goauth/entity"
+)
+
+func TestAppendAuditRecord(t *testing.T) {
+	tests := []struct {
+		description  string
+		inAppends    int
+		expectHashes []string
+	}{
+		{"first record", 1, []string{"", "0"}},
+		{"chained records", 2, []string{"", "0", "0", "1"}},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewAuditRecords(newTestDB(t))
+
+			// the hash is the count of the records before it
+			count := 0
+			hash := func(record entity.AuditRecord) string {
+				h := strconv.Itoa(count)
+				count += 1
+				return h
+			}
+
+			for i := 0; i < testCase.inAppends; i++ {
+				err := store.AppendAuditRecord(ctx, entity.AuditRecord{
+					Action:    "sign_in",
+					CreatedAt: time.Now(),
+				}, hash)
+				if err != nil {
+					t.Fatalf("expected: non error on append and got %v", err)
+				}
+			}
+
+			records, err := store.ListAuditRecords(ctx, entity.AuditFilter{})
+			if err != nil {
+				t.Fatalf("expected: non error on list and got %v", err)
+			}
+
+			hashes := []string{}
+			for _, record := range records {
+				hashes = append(hashes, record.PrevHash, record.Hash)
+			}
+
+			if len(hashes) != len(testCase.expectHashes) {
+				t.Fatalf("expected: %v and got %v", testCase.expectHashes, hashes)
+			}
+			for i := range hashes {
+				if hashes[i] != testCase.expectHashes[i] {
+					t.Fatalf("expected: %v and got %v", testCase.expectHashes, hashes)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package sqlite
 import (
 	"database/sql"
 
+	"github.com/google/uuid"
 	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
 )
 
@@ -14,3 +15,22 @@ type dbWithTx interface {
 	dbgen.DBTX
 	Begin() (*sql.Tx, error)
 }
+
+// optionalIDToString keeps the ids that aren't set, for example the tokens
+// without a family, as an empty string
+func optionalIDToString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+
+	return id.String()
+}
+
+// parseOptionalID is the reverse of optionalIDToString
+func parseOptionalID(raw string) (uuid.UUID, error) {
+	if len(raw) == 0 {
+		return uuid.Nil, nil
+	}
+
+	return uuid.Parse(raw)
+}
@@ -8,6 +8,133 @@ import (
 	"database/sql"
 )
 
+type AppAuthApiKey struct {
+	ID         string
+	UserID     string
+	Name       string
+	Prefix     string
+	Hash       string
+	Scopes     string
+	ExpiresAt  sql.NullString
+	LastUsedAt sql.NullString
+	CreatedAt  string
+}
+
+type AppAuthAuditRecord struct {
+	ID        int64
+	Action    string
+	ActorID   string
+	UserID    string
+	Ip        string
+	UserAgent string
+	Outcome   string
+	Error     string
+	PrevHash  string
+	Hash      string
+	CreatedAt string
+}
+
+type AppAuthInvitation struct {
+	ID             string
+	InviterID      string
+	Email          string
+	OrganizationID string
+	Roles          string
+	Meta           string
+	Status         string
+	ExpiresAt      string
+	CreatedAt      string
+	UpdatedAt      sql.NullString
+}
+
+type AppAuthOauthClient struct {
+	ID             string
+	Name           string
+	SecretHash     string
+	RedirectUris   string
+	Scopes         string
+	IsConfidential bool
+	CreatedAt      string
+}
+
+type AppAuthOauthConsent struct {
+	UserID    string
+	ClientID  string
+	Scopes    string
+	CreatedAt string
+}
+
+type AppAuthOrganization struct {
+	ID        string
+	Name      string
+	CreatedAt string
+}
+
+type AppAuthOrganizationMember struct {
+	OrganizationID string
+	UserID         string
+	Roles          string
+	CreatedAt      string
+}
+
+type AppAuthPasskey struct {
+	ID         []byte
+	UserID     string
+	PublicKey  []byte
+	SignCount  int64
+	CreatedAt  sql.NullString
+	LastUsedAt sql.NullString
+}
+
+type AppAuthPhoneCode struct {
+	UserID    string
+	Code      string
+	Attempts  int64
+	ExpiresAt string
+	CreatedAt sql.NullString
+}
+
+type AppAuthRateLimit struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt int64
+}
+
+type AppAuthRecoveryCode struct {
+	ID        int64
+	UserID    string
+	Code      string
+	CreatedAt sql.NullString
+}
+
+type AppAuthRole struct {
+	Name      string
+	CreatedAt sql.NullString
+}
+
+type AppAuthRolePermission struct {
+	Role       string
+	Permission string
+}
+
+type AppAuthSession struct {
+	ID         string
+	UserID     string
+	UserAgent  string
+	Ip         string
+	CreatedAt  sql.NullString
+	LastSeenAt string
+}
+
+type AppAuthSignInAttempt struct {
+	UserID        string
+	Attempts      int64
+	Lockouts      int64
+	WindowStartAt string
+	LockedUntil   sql.NullString
+	UpdatedAt     sql.NullString
+}
+
 type AppAuthToken struct {
 	ID        int64
 	UserID    string
@@ -15,16 +142,37 @@ type AppAuthToken struct {
 	Value     string
 	ExpiresAt string
 	CreatedAt sql.NullString
+	FamilyID  string
+	IsRevoked bool
+	ClientID  string
 }
 
 type AppAuthUser struct {
-	ID           string
-	Email        string
-	PhoneNumber  sql.NullString
-	Password     string
-	IsVerifiedAt sql.NullString
-	IsVerified   sql.NullBool
-	Meta         interface{}
-	CreatedAt    sql.NullString
-	UpdatedAt    sql.NullString
+	ID            string
+	TenantID      string
+	Email         string
+	PhoneNumber   sql.NullString
+	Password      string
+	IsVerifiedAt  sql.NullString
+	IsVerified    sql.NullBool
+	Meta          interface{}
+	CreatedAt     sql.NullString
+	UpdatedAt     sql.NullString
+	TotpSecret    sql.NullString
+	IsTotpEnabled sql.NullBool
+	TotpLastStep  int64
+}
+
+type AppAuthUserIdentity struct {
+	UserID    string
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt string
+}
+
+type AppAuthUserRole struct {
+	UserID    string
+	Role      string
+	CreatedAt sql.NullString
 }
@@ -10,8 +10,8 @@ import (
 )
 
 const createToken = `-- name: CreateToken :exec
-INSERT INTO app_auth_tokens (user_id, kind, value, expires_at)
-VALUES (?, ?, ?, ?)
+INSERT INTO app_auth_tokens (user_id, kind, value, expires_at, family_id, client_id)
+VALUES (?, ?, ?, ?, ?, ?)
 `
 
 type CreateTokenParams struct {
@@ -19,6 +19,8 @@ type CreateTokenParams struct {
 	Kind      int64
 	Value     string
 	ExpiresAt string
+	FamilyID  string
+	ClientID  string
 }
 
 func (q *Queries) CreateToken(ctx context.Context, arg CreateTokenParams) error {
@@ -27,12 +29,74 @@ func (q *Queries) CreateToken(ctx context.Context, arg CreateTokenParams) error
 		arg.Kind,
 		arg.Value,
 		arg.ExpiresAt,
+		arg.FamilyID,
+		arg.ClientID,
 	)
 	return err
 }
 
+const getToken = `-- name: GetToken :one
+SELECT id, user_id, kind, value, expires_at, created_at, family_id, is_revoked, client_id
+FROM app_auth_tokens WHERE value = ? LIMIT 1
+`
+
+func (q *Queries) GetToken(ctx context.Context, value string) (AppAuthToken, error) {
+	row := q.db.QueryRowContext(ctx, getToken, value)
+	var i AppAuthToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Kind,
+		&i.Value,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.FamilyID,
+		&i.IsRevoked,
+		&i.ClientID,
+	)
+	return i, err
+}
+
+const getUserTokens = `-- name: GetUserTokens :many
+SELECT id, user_id, kind, value, expires_at, created_at, family_id, is_revoked, client_id
+FROM app_auth_tokens WHERE user_id = ?
+`
+
+func (q *Queries) GetUserTokens(ctx context.Context, userID string) ([]AppAuthToken, error) {
+	rows, err := q.db.QueryContext(ctx, getUserTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AppAuthToken
+	for rows.Next() {
+		var i AppAuthToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Kind,
+			&i.Value,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.FamilyID,
+			&i.IsRevoked,
+			&i.ClientID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const isTokenRegistered = `-- name: IsTokenRegistered :one
-SELECT EXISTS(SELECT 1 FROM app_auth_tokens WHERE value = ? LIMIT 1)
+SELECT EXISTS(SELECT 1 FROM app_auth_tokens WHERE value = ? AND is_revoked = FALSE LIMIT 1)
 `
 
 func (q *Queries) IsTokenRegistered(ctx context.Context, value string) (int64, error) {
@@ -42,6 +106,38 @@ func (q *Queries) IsTokenRegistered(ctx context.Context, value string) (int64, e
 	return column_1, err
 }
 
+const removeClientTokens = `-- name: RemoveClientTokens :exec
+DELETE FROM app_auth_tokens WHERE client_id = ? AND client_id != ''
+`
+
+func (q *Queries) RemoveClientTokens(ctx context.Context, clientID string) error {
+	_, err := q.db.ExecContext(ctx, removeClientTokens, clientID)
+	return err
+}
+
+const removeTokenFamily = `-- name: RemoveTokenFamily :exec
+DELETE FROM app_auth_tokens WHERE family_id = ? AND family_id != ''
+`
+
+func (q *Queries) RemoveTokenFamily(ctx context.Context, familyID string) error {
+	_, err := q.db.ExecContext(ctx, removeTokenFamily, familyID)
+	return err
+}
+
+const removeUserClientTokens = `-- name: RemoveUserClientTokens :exec
+DELETE FROM app_auth_tokens WHERE user_id = ? AND client_id = ? AND client_id != ''
+`
+
+type RemoveUserClientTokensParams struct {
+	UserID   string
+	ClientID string
+}
+
+func (q *Queries) RemoveUserClientTokens(ctx context.Context, arg RemoveUserClientTokensParams) error {
+	_, err := q.db.ExecContext(ctx, removeUserClientTokens, arg.UserID, arg.ClientID)
+	return err
+}
+
 const removeUserToken = `-- name: RemoveUserToken :exec
 DELETE FROM app_auth_tokens WHERE user_id = ? AND value = ?
 `
@@ -64,3 +160,26 @@ func (q *Queries) RemoveUserTokens(ctx context.Context, userID string) error {
 	_, err := q.db.ExecContext(ctx, removeUserTokens, userID)
 	return err
 }
+
+const removeUserTokensByKind = `-- name: RemoveUserTokensByKind :exec
+DELETE FROM app_auth_tokens WHERE user_id = ? AND kind = ?
+`
+
+type RemoveUserTokensByKindParams struct {
+	UserID string
+	Kind   int64
+}
+
+func (q *Queries) RemoveUserTokensByKind(ctx context.Context, arg RemoveUserTokensByKindParams) error {
+	_, err := q.db.ExecContext(ctx, removeUserTokensByKind, arg.UserID, arg.Kind)
+	return err
+}
+
+const revokeToken = `-- name: RevokeToken :exec
+UPDATE app_auth_tokens SET is_revoked = TRUE WHERE value = ?
+`
+
+func (q *Queries) RevokeToken(ctx context.Context, value string) error {
+	_, err := q.db.ExecContext(ctx, revokeToken, value)
+	return err
+}
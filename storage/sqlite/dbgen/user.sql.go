@@ -10,10 +10,32 @@ import (
 	"database/sql"
 )
 
+const anonymizeUser = `-- name: AnonymizeUser :exec
+UPDATE app_auth_users SET
+    email = ?,
+    phone_number = '',
+    meta = '{}',
+    password = '',
+    totp_secret = '',
+    is_totp_enabled = FALSE,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type AnonymizeUserParams struct {
+	Email string
+	ID    string
+}
+
+func (q *Queries) AnonymizeUser(ctx context.Context, arg AnonymizeUserParams) error {
+	_, err := q.db.ExecContext(ctx, anonymizeUser, arg.Email, arg.ID)
+	return err
+}
+
 const createUser = `-- name: CreateUser :exec
-INSERT INTO app_auth_users (id, email, phone_number, meta, password, is_verified)
-VALUES (?, ?, ?, ?, ?, ?)
-ON CONFLICT(email) DO UPDATE SET
+INSERT INTO app_auth_users (id, tenant_id, email, phone_number, meta, password, is_verified)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(tenant_id, email) DO UPDATE SET
     phone_number = excluded.phone_number,
     meta = excluded.meta,
     password = excluded.password,
@@ -22,6 +44,7 @@ ON CONFLICT(email) DO UPDATE SET
 
 type CreateUserParams struct {
 	ID          string
+	TenantID    string
 	Email       string
 	PhoneNumber sql.NullString
 	Meta        interface{}
@@ -32,6 +55,7 @@ type CreateUserParams struct {
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
 	_, err := q.db.ExecContext(ctx, createUser,
 		arg.ID,
+		arg.TenantID,
 		arg.Email,
 		arg.PhoneNumber,
 		arg.Meta,
@@ -41,8 +65,50 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
 	return err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM app_auth_users WHERE id = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const getTenantUserByEmail = `-- name: GetTenantUserByEmail :one
+SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at,
+  totp_secret, is_totp_enabled, tenant_id, totp_last_step
+FROM app_auth_users WHERE tenant_id = ? AND email = ?
+`
+
+type GetTenantUserByEmailParams struct {
+	TenantID string
+	Email    string
+}
+
+func (q *Queries) GetTenantUserByEmail(ctx context.Context, arg GetTenantUserByEmailParams) (AppAuthUser, error) {
+	row := q.db.QueryRowContext(ctx, getTenantUserByEmail, arg.TenantID, arg.Email)
+	var i AppAuthUser
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PhoneNumber,
+		&i.Password,
+		&i.IsVerifiedAt,
+		&i.IsVerified,
+		&i.Meta,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TenantID,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at
+SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at,
+  totp_secret, is_totp_enabled, tenant_id, totp_last_step
 FROM app_auth_users WHERE email = ?
 `
 
@@ -59,12 +125,17 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (AppAuthUser
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TenantID,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at
+SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at,
+  totp_secret, is_totp_enabled, tenant_id, totp_last_step
 FROM app_auth_users WHERE id = ?
 `
 
@@ -81,12 +152,57 @@ func (q *Queries) GetUserByID(ctx context.Context, id string) (AppAuthUser, erro
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TenantID,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
+const getUserByPhone = `-- name: GetUserByPhone :one
+SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at,
+  totp_secret, is_totp_enabled, tenant_id, totp_last_step
+FROM app_auth_users WHERE phone_number = ? AND phone_number != '' LIMIT 1
+`
+
+func (q *Queries) GetUserByPhone(ctx context.Context, phoneNumber sql.NullString) (AppAuthUser, error) {
+	row := q.db.QueryRowContext(ctx, getUserByPhone, phoneNumber)
+	var i AppAuthUser
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PhoneNumber,
+		&i.Password,
+		&i.IsVerifiedAt,
+		&i.IsVerified,
+		&i.Meta,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TenantID,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :exec
+UPDATE app_auth_users SET email = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+`
+
+type UpdateUserEmailParams struct {
+	Email string
+	ID    string
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserEmail, arg.Email, arg.ID)
+	return err
+}
+
 const updateUserIsVerified = `-- name: UpdateUserIsVerified :exec
-UPDATE app_auth_users SET is_verified = ? AND is_verified_at = CURRENT_TIMESTAMP WHERE id = ?
+UPDATE app_auth_users SET is_verified = ?, is_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ?
 `
 
 type UpdateUserIsVerifiedParams struct {
@@ -112,3 +228,32 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.Password, arg.ID)
 	return err
 }
+
+const updateUserTOTP = `-- name: UpdateUserTOTP :exec
+UPDATE app_auth_users SET totp_secret = ?, is_totp_enabled = ? WHERE id = ?
+`
+
+type UpdateUserTOTPParams struct {
+	TotpSecret    sql.NullString
+	IsTotpEnabled sql.NullBool
+	ID            string
+}
+
+func (q *Queries) UpdateUserTOTP(ctx context.Context, arg UpdateUserTOTPParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserTOTP, arg.TotpSecret, arg.IsTotpEnabled, arg.ID)
+	return err
+}
+
+const updateUserTOTPLastStep = `-- name: UpdateUserTOTPLastStep :exec
+UPDATE app_auth_users SET totp_last_step = ? WHERE id = ?
+`
+
+type UpdateUserTOTPLastStepParams struct {
+	TotpLastStep int64
+	ID           string
+}
+
+func (q *Queries) UpdateUserTOTPLastStep(ctx context.Context, arg UpdateUserTOTPLastStepParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserTOTPLastStep, arg.TotpLastStep, arg.ID)
+	return err
+}
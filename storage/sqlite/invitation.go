@@ -0,0 +1,181 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type invitations struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewInvitations(db dbWithTx) *invitations {
+	return &invitations{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+func dbInvitationToInvitation(dbInvitation dbgen.AppAuthInvitation) (entity.Invitation, error) {
+	invitationID, err := uuid.Parse(dbInvitation.ID)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	inviterID, err := uuid.Parse(dbInvitation.InviterID)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	orgID := uuid.Nil
+	if len(dbInvitation.OrganizationID) > 0 {
+		orgID, err = uuid.Parse(dbInvitation.OrganizationID)
+		if err != nil {
+			return entity.Invitation{}, err
+		}
+	}
+
+	roles := []string{}
+	err = json.Unmarshal([]byte(dbInvitation.Roles), &roles)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	meta := map[string]string{}
+	err = json.Unmarshal([]byte(dbInvitation.Meta), &meta)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	expiresAt, err := time.Parse(timestampFormat, dbInvitation.ExpiresAt)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	createdAt, err := time.Parse(timestampFormat, dbInvitation.CreatedAt)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	return entity.Invitation{
+		ID:             invitationID,
+		InviterID:      inviterID,
+		Email:          dbInvitation.Email,
+		OrganizationID: orgID,
+		Roles:          roles,
+		Meta:           meta,
+		Status:         dbInvitation.Status,
+		ExpiresAt:      expiresAt,
+		CreatedAt:      createdAt,
+	}, nil
+}
+
+func (s *invitations) CreateInvitation(ctx context.Context, invitation entity.Invitation) error {
+	roles := invitation.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	rawRoles, err := json.Marshal(roles)
+	if err != nil {
+		return err
+	}
+
+	meta := invitation.Meta
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	rawMeta, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	orgID := ""
+	if invitation.OrganizationID != uuid.Nil {
+		orgID = invitation.OrganizationID.String()
+	}
+
+	return s.dbgen().CreateInvitation(ctx, dbgen.CreateInvitationParams{
+		ID:             invitation.ID.String(),
+		InviterID:      invitation.InviterID.String(),
+		Email:          invitation.Email,
+		OrganizationID: orgID,
+		Roles:          string(rawRoles),
+		Meta:           string(rawMeta),
+		Status:         invitation.Status,
+		ExpiresAt:      invitation.ExpiresAt.UTC().Format(timestampFormat),
+		CreatedAt:      invitation.CreatedAt.UTC().Format(timestampFormat),
+	})
+}
+
+func (s *invitations) UpdateInvitationStatus(
+	ctx context.Context,
+	invitationID uuid.UUID,
+	status string,
+) error {
+	return s.dbgen().UpdateInvitationStatus(ctx, dbgen.UpdateInvitationStatusParams{
+		Status: status,
+		ID:     invitationID.String(),
+	})
+}
+
+func (s *invitations) GetInvitation(
+	ctx context.Context,
+	invitationID uuid.UUID,
+) (entity.Invitation, error) {
+	dbInvitation, err := s.dbgen().GetInvitation(ctx, invitationID.String())
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Invitation{}, storage.ErrInvitationNotFound
+	}
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	return dbInvitationToInvitation(dbInvitation)
+}
+
+func (s *invitations) GetInvitations(
+	ctx context.Context,
+	orgID uuid.UUID,
+) ([]entity.Invitation, error) {
+	rawOrgID := ""
+	if orgID != uuid.Nil {
+		rawOrgID = orgID.String()
+	}
+
+	dbInvitations, err := s.dbgen().GetInvitations(ctx, rawOrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := make([]entity.Invitation, len(dbInvitations))
+	for i, dbInvitation := range dbInvitations {
+		invitations[i], err = dbInvitationToInvitation(dbInvitation)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return invitations, nil
+}
+
+func (s *invitations) RemovePendingUserInvitations(
+	ctx context.Context,
+	inviterID uuid.UUID,
+	email string,
+) error {
+	return s.dbgen().RemovePendingUserInvitations(ctx, dbgen.RemovePendingUserInvitationsParams{
+		InviterID: inviterID.String(),
+		Email:     email,
+	})
+}
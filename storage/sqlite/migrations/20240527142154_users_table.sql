@@ -11,7 +11,6 @@ CREATE TABLE IF NOT EXISTS app_auth_users(
   meta                            JSON DEFAULT '{}',
 
   -- TODO: SSO?!
-  -- TODO: 2FA?!
   
   created_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
   updated_at                      TEXT DEFAULT CURRENT_TIMESTAMP
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE app_auth_users ADD COLUMN totp_secret TEXT DEFAULT '';
+ALTER TABLE app_auth_users ADD COLUMN is_totp_enabled BOOLEAN DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE app_auth_users DROP COLUMN is_totp_enabled;
+ALTER TABLE app_auth_users DROP COLUMN totp_secret;
+
+-- +goose StatementEnd
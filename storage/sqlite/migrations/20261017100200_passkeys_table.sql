@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS app_auth_passkeys(
+  id                              BLOB PRIMARY KEY,
+  user_id                         TEXT NOT NULL,
+  public_key                      BLOB NOT NULL,
+  sign_count                      INTEGER NOT NULL DEFAULT 0,
+  created_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+  last_used_at                    TEXT,
+
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS app_auth_passkeys;
+
+-- +goose StatementEnd
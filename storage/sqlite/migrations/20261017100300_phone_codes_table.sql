@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS app_auth_phone_codes(
+  user_id                         TEXT PRIMARY KEY,
+  code                            TEXT NOT NULL,
+  attempts                        INTEGER NOT NULL DEFAULT 0,
+  expires_at                      TEXT NOT NULL,
+  created_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS app_auth_users_phone_number_idx ON app_auth_users(phone_number);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS app_auth_users_phone_number_idx;
+DROP TABLE IF EXISTS app_auth_phone_codes;
+
+-- +goose StatementEnd
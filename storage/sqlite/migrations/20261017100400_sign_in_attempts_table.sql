@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS app_auth_sign_in_attempts(
+  user_id                         TEXT PRIMARY KEY,
+  attempts                        INTEGER NOT NULL DEFAULT 0,
+  lockouts                        INTEGER NOT NULL DEFAULT 0,
+  window_start_at                 TEXT NOT NULL,
+  locked_until                    TEXT,
+  updated_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS app_auth_sign_in_attempts;
+
+-- +goose StatementEnd
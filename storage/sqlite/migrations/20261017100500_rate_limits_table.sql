@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS app_auth_rate_limits(
+  key                             TEXT PRIMARY KEY,
+  tokens                          REAL NOT NULL,
+  updated_at                      TEXT NOT NULL
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS app_auth_rate_limits;
+
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+-- sqlite can't drop the user_id unique constraint, the table is rebuilt so
+-- that the users can have several tokens and the tokens a family
+CREATE TABLE IF NOT EXISTS app_auth_tokens_new(
+  id                              INTEGER PRIMARY KEY,
+  user_id                         TEXT NOT NULL,
+  kind                            INTEGER NOT NULL,
+  value                           TEXT NOT NULL,
+  expires_at                      TEXT NOT NULL,
+  created_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+  family_id                       TEXT NOT NULL DEFAULT '',
+  is_revoked                      BOOLEAN NOT NULL DEFAULT FALSE,
+
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+INSERT INTO app_auth_tokens_new (id, user_id, kind, value, expires_at, created_at)
+SELECT id, user_id, kind, value, expires_at, created_at FROM app_auth_tokens;
+
+DROP TABLE app_auth_tokens;
+ALTER TABLE app_auth_tokens_new RENAME TO app_auth_tokens;
+
+CREATE INDEX IF NOT EXISTS app_auth_tokens_user_id_idx ON app_auth_tokens(user_id);
+CREATE INDEX IF NOT EXISTS app_auth_tokens_value_idx ON app_auth_tokens(value);
+CREATE INDEX IF NOT EXISTS app_auth_tokens_family_id_idx ON app_auth_tokens(family_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS app_auth_tokens_old(
+  id                              INTEGER PRIMARY KEY,
+  user_id                         TEXT NOT NULL UNIQUE,
+  kind                            INTEGER NOT NULL,
+  value                           TEXT NOT NULL,
+  expires_at                      TEXT NOT NULL,
+  created_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+INSERT OR IGNORE INTO app_auth_tokens_old (id, user_id, kind, value, expires_at, created_at)
+SELECT id, user_id, kind, value, expires_at, created_at FROM app_auth_tokens;
+
+DROP TABLE app_auth_tokens;
+ALTER TABLE app_auth_tokens_old RENAME TO app_auth_tokens;
+
+-- +goose StatementEnd
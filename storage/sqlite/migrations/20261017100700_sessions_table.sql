@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS app_auth_sessions(
+  id                              TEXT PRIMARY KEY,
+  user_id                         TEXT NOT NULL,
+  user_agent                      TEXT NOT NULL DEFAULT '',
+  ip                              TEXT NOT NULL DEFAULT '',
+  created_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+  last_seen_at                    TEXT NOT NULL,
+
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS app_auth_sessions_user_id_idx ON app_auth_sessions(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS app_auth_sessions_user_id_idx;
+DROP TABLE IF EXISTS app_auth_sessions;
+
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the records aren't tied to the users so they outlive their deletion
+CREATE TABLE IF NOT EXISTS app_auth_audit_records(
+  id                              INTEGER PRIMARY KEY AUTOINCREMENT,
+  action                          TEXT NOT NULL,
+  actor_id                        TEXT NOT NULL DEFAULT '',
+  user_id                         TEXT NOT NULL DEFAULT '',
+  ip                              TEXT NOT NULL DEFAULT '',
+  user_agent                      TEXT NOT NULL DEFAULT '',
+  outcome                         TEXT NOT NULL,
+  error                           TEXT NOT NULL DEFAULT '',
+  prev_hash                       TEXT NOT NULL DEFAULT '',
+  hash                            TEXT NOT NULL,
+  created_at                      TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS app_auth_audit_records_user_id_idx ON app_auth_audit_records(user_id);
+CREATE INDEX IF NOT EXISTS app_auth_audit_records_action_idx ON app_auth_audit_records(action);
+CREATE INDEX IF NOT EXISTS app_auth_audit_records_created_at_idx ON app_auth_audit_records(created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS app_auth_audit_records_created_at_idx;
+DROP INDEX IF EXISTS app_auth_audit_records_action_idx;
+DROP INDEX IF EXISTS app_auth_audit_records_user_id_idx;
+DROP TABLE IF EXISTS app_auth_audit_records;
+
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS app_auth_roles(
+  name                            TEXT PRIMARY KEY,
+  created_at                      TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS app_auth_role_permissions(
+  role                            TEXT NOT NULL,
+  permission                      TEXT NOT NULL,
+
+  PRIMARY KEY (role, permission),
+  FOREIGN KEY (role)
+    REFERENCES app_auth_roles(name)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS app_auth_user_roles(
+  user_id                         TEXT NOT NULL,
+  role                            TEXT NOT NULL,
+  created_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (user_id, role),
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE,
+  FOREIGN KEY (role)
+    REFERENCES app_auth_roles(name)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS app_auth_user_roles_role_idx ON app_auth_user_roles(role);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS app_auth_user_roles_role_idx;
+DROP TABLE IF EXISTS app_auth_user_roles;
+DROP TABLE IF EXISTS app_auth_role_permissions;
+DROP TABLE IF EXISTS app_auth_roles;
+
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS app_auth_organizations(
+  id                              TEXT PRIMARY KEY,
+  name                            TEXT NOT NULL,
+  created_at                      TEXT NOT NULL
+);
+
+-- roles is a json array of the role names within the organization
+CREATE TABLE IF NOT EXISTS app_auth_organization_members(
+  organization_id                 TEXT NOT NULL,
+  user_id                         TEXT NOT NULL,
+  roles                           TEXT NOT NULL DEFAULT '[]',
+  created_at                      TEXT NOT NULL,
+
+  PRIMARY KEY (organization_id, user_id),
+  FOREIGN KEY (organization_id)
+    REFERENCES app_auth_organizations(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE,
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS app_auth_organization_members_user_id_idx ON app_auth_organization_members(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS app_auth_organization_members_user_id_idx;
+DROP TABLE IF EXISTS app_auth_organization_members;
+DROP TABLE IF EXISTS app_auth_organizations;
+
+-- +goose StatementEnd
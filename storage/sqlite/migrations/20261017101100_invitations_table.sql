@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- organization_id is empty on the invitations to the app, roles is a json
+-- array and meta a json object
+CREATE TABLE IF NOT EXISTS app_auth_invitations(
+  id                              TEXT PRIMARY KEY,
+  inviter_id                      TEXT NOT NULL,
+  email                           TEXT NOT NULL,
+  organization_id                 TEXT NOT NULL DEFAULT '',
+  roles                           TEXT NOT NULL DEFAULT '[]',
+  meta                            TEXT NOT NULL DEFAULT '{}',
+  status                          TEXT NOT NULL,
+  expires_at                      TEXT NOT NULL,
+  created_at                      TEXT NOT NULL,
+  updated_at                      TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS app_auth_invitations_organization_id_idx ON app_auth_invitations(organization_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS app_auth_invitations_organization_id_idx;
+DROP TABLE IF EXISTS app_auth_invitations;
+
+-- +goose StatementEnd
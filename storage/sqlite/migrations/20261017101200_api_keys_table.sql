@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- scopes is a json array, the key itself is only kept as a hash
+CREATE TABLE IF NOT EXISTS app_auth_api_keys(
+  id                              TEXT PRIMARY KEY,
+  user_id                         TEXT NOT NULL,
+  name                            TEXT NOT NULL,
+  prefix                          TEXT NOT NULL UNIQUE,
+  hash                            TEXT NOT NULL,
+  scopes                          TEXT NOT NULL DEFAULT '[]',
+  expires_at                      TEXT,
+  last_used_at                    TEXT,
+  created_at                      TEXT NOT NULL,
+
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS app_auth_api_keys_user_id_idx ON app_auth_api_keys(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS app_auth_api_keys_user_id_idx;
+DROP TABLE IF EXISTS app_auth_api_keys;
+
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- subject is the id of the user within the external provider
+CREATE TABLE IF NOT EXISTS app_auth_user_identities(
+  user_id                         TEXT NOT NULL,
+  provider                        TEXT NOT NULL,
+  subject                         TEXT NOT NULL,
+  email                           TEXT NOT NULL,
+  created_at                      TEXT NOT NULL,
+
+  PRIMARY KEY (provider, subject),
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS app_auth_user_identities_user_id_idx ON app_auth_user_identities(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS app_auth_user_identities_user_id_idx;
+DROP TABLE IF EXISTS app_auth_user_identities;
+
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+-- redirect_uris and scopes are json arrays, the secret is only kept as a hash
+CREATE TABLE IF NOT EXISTS app_auth_oauth_clients(
+  id                              TEXT PRIMARY KEY,
+  name                            TEXT NOT NULL,
+  secret_hash                     TEXT NOT NULL DEFAULT '',
+  redirect_uris                   TEXT NOT NULL DEFAULT '[]',
+  scopes                          TEXT NOT NULL DEFAULT '[]',
+  is_confidential                 BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at                      TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS app_auth_oauth_consents(
+  user_id                         TEXT NOT NULL,
+  client_id                       TEXT NOT NULL,
+  scopes                          TEXT NOT NULL DEFAULT '[]',
+  created_at                      TEXT NOT NULL,
+
+  PRIMARY KEY (user_id, client_id),
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE,
+  FOREIGN KEY (client_id)
+    REFERENCES app_auth_oauth_clients(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS app_auth_oauth_consents_client_id_idx ON app_auth_oauth_consents(client_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS app_auth_oauth_consents_client_id_idx;
+DROP TABLE IF EXISTS app_auth_oauth_consents;
+DROP TABLE IF EXISTS app_auth_oauth_clients;
+
+-- +goose StatementEnd
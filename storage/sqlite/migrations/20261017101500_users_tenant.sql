@@ -0,0 +1,91 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- the unique email constraint can't be dropped, the table is rebuilt with the
+-- email unique within the tenant, the users without tenant have it empty
+PRAGMA foreign_keys = OFF;
+
+-- +goose StatementBegin
+CREATE TABLE app_auth_users_tenant(
+  id                              TEXT PRIMARY KEY,
+  tenant_id                       TEXT NOT NULL DEFAULT '',
+  email                           TEXT NOT NULL,
+  phone_number                    TEXT DEFAULT '',
+
+  password                        TEXT NOT NULL,
+  is_verified_at                  TEXT,
+  is_verified                     BOOLEAN DEFAULT FALSE,
+  meta                            JSON DEFAULT '{}',
+  totp_secret                     TEXT DEFAULT '',
+  is_totp_enabled                 BOOLEAN DEFAULT FALSE,
+
+  created_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+  updated_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+
+  UNIQUE (tenant_id, email)
+);
+
+INSERT INTO app_auth_users_tenant (
+  id, email, phone_number, password, is_verified_at, is_verified, meta,
+  totp_secret, is_totp_enabled, created_at, updated_at
+)
+SELECT id, email, phone_number, password, is_verified_at, is_verified, meta,
+  totp_secret, is_totp_enabled, created_at, updated_at
+FROM app_auth_users;
+
+DROP TABLE app_auth_users;
+ALTER TABLE app_auth_users_tenant RENAME TO app_auth_users;
+CREATE INDEX IF NOT EXISTS app_auth_users_phone_number_idx ON app_auth_users(phone_number);
+
+-- +goose StatementEnd
+
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+-- the emails of the users of the tenants may collide once unique again, the
+-- migration refuses to run, through the check, while there is any of them
+-- instead of dropping them
+-- +goose StatementBegin
+CREATE TEMP TABLE app_auth_users_tenant_guard(
+  tenant_users                    INTEGER NOT NULL CHECK (tenant_users = 0)
+);
+
+INSERT INTO app_auth_users_tenant_guard (tenant_users)
+SELECT COUNT(*) FROM app_auth_users WHERE tenant_id != '';
+
+DROP TABLE app_auth_users_tenant_guard;
+-- +goose StatementEnd
+
+PRAGMA foreign_keys = OFF;
+
+-- +goose StatementBegin
+CREATE TABLE app_auth_users_global(
+  id                              TEXT PRIMARY KEY,
+  email                           TEXT NOT NULL UNIQUE,
+  phone_number                    TEXT DEFAULT '',
+
+  password                        TEXT NOT NULL,
+  is_verified_at                  TEXT,
+  is_verified                     BOOLEAN DEFAULT FALSE,
+  meta                            JSON DEFAULT '{}',
+  totp_secret                     TEXT DEFAULT '',
+  is_totp_enabled                 BOOLEAN DEFAULT FALSE,
+
+  created_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+  updated_at                      TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO app_auth_users_global (
+  id, email, phone_number, password, is_verified_at, is_verified, meta,
+  totp_secret, is_totp_enabled, created_at, updated_at
+)
+SELECT id, email, phone_number, password, is_verified_at, is_verified, meta,
+  totp_secret, is_totp_enabled, created_at, updated_at
+FROM app_auth_users;
+
+DROP TABLE app_auth_users;
+ALTER TABLE app_auth_users_global RENAME TO app_auth_users;
+CREATE INDEX IF NOT EXISTS app_auth_users_phone_number_idx ON app_auth_users(phone_number);
+
+-- +goose StatementEnd
+
+PRAGMA foreign_keys = ON;
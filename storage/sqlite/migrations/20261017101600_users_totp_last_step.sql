@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE app_auth_users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE app_auth_users DROP COLUMN totp_last_step;
+
+-- +goose StatementEnd
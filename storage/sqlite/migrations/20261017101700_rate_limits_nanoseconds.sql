@@ -0,0 +1,25 @@
+-- +goose Up
+-- the buckets are rebuilt with the time in unix nanoseconds, the buckets in
+-- flight are dropped, these only refill the keys
+-- +goose StatementBegin
+DROP TABLE IF EXISTS app_auth_rate_limits;
+
+CREATE TABLE app_auth_rate_limits(
+  key                             TEXT PRIMARY KEY,
+  tokens                          REAL NOT NULL,
+  updated_at                      INTEGER NOT NULL
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS app_auth_rate_limits;
+
+CREATE TABLE app_auth_rate_limits(
+  key                             TEXT PRIMARY KEY,
+  tokens                          REAL NOT NULL,
+  updated_at                      TEXT NOT NULL
+);
+
+-- +goose StatementEnd
@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the tokens of the client credentials are issued to the oauth client and
+-- not to an user, the table is rebuilt without the user foreign key. The
+-- tokens of the users are removed with them by the auth
+CREATE TABLE IF NOT EXISTS app_auth_tokens_new(
+  id                              INTEGER PRIMARY KEY,
+  user_id                         TEXT NOT NULL,
+  kind                            INTEGER NOT NULL,
+  value                           TEXT NOT NULL,
+  expires_at                      TEXT NOT NULL,
+  created_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+  family_id                       TEXT NOT NULL DEFAULT '',
+  is_revoked                      BOOLEAN NOT NULL DEFAULT FALSE,
+  client_id                       TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO app_auth_tokens_new (id, user_id, kind, value, expires_at, created_at, family_id, is_revoked)
+SELECT id, user_id, kind, value, expires_at, created_at, family_id, is_revoked FROM app_auth_tokens;
+
+DROP TABLE app_auth_tokens;
+ALTER TABLE app_auth_tokens_new RENAME TO app_auth_tokens;
+
+CREATE INDEX IF NOT EXISTS app_auth_tokens_user_id_idx ON app_auth_tokens(user_id);
+CREATE INDEX IF NOT EXISTS app_auth_tokens_value_idx ON app_auth_tokens(value);
+CREATE INDEX IF NOT EXISTS app_auth_tokens_family_id_idx ON app_auth_tokens(family_id);
+CREATE INDEX IF NOT EXISTS app_auth_tokens_client_id_idx ON app_auth_tokens(client_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- the tokens of the client credentials have no user to reference, they are
+-- the only ones left behind
+CREATE TABLE IF NOT EXISTS app_auth_tokens_old(
+  id                              INTEGER PRIMARY KEY,
+  user_id                         TEXT NOT NULL,
+  kind                            INTEGER NOT NULL,
+  value                           TEXT NOT NULL,
+  expires_at                      TEXT NOT NULL,
+  created_at                      TEXT DEFAULT CURRENT_TIMESTAMP,
+  family_id                       TEXT NOT NULL DEFAULT '',
+  is_revoked                      BOOLEAN NOT NULL DEFAULT FALSE,
+
+  FOREIGN KEY (user_id)
+    REFERENCES app_auth_users(id)
+      ON UPDATE NO ACTION
+      ON DELETE CASCADE
+);
+
+INSERT INTO app_auth_tokens_old (id, user_id, kind, value, expires_at, created_at, family_id, is_revoked)
+SELECT id, user_id, kind, value, expires_at, created_at, family_id, is_revoked FROM app_auth_tokens
+WHERE user_id IN (SELECT id FROM app_auth_users);
+
+DROP TABLE app_auth_tokens;
+ALTER TABLE app_auth_tokens_old RENAME TO app_auth_tokens;
+
+CREATE INDEX IF NOT EXISTS app_auth_tokens_user_id_idx ON app_auth_tokens(user_id);
+CREATE INDEX IF NOT EXISTS app_auth_tokens_value_idx ON app_auth_tokens(value);
+CREATE INDEX IF NOT EXISTS app_auth_tokens_family_id_idx ON app_auth_tokens(family_id);
+
+-- +goose StatementEnd
@@ -0,0 +1,220 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type oauth struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewOAuth(db dbWithTx) *oauth {
+	return &oauth{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+func marshalList(list []string) (string, error) {
+	if list == nil {
+		list = []string{}
+	}
+
+	raw, err := json.Marshal(list)
+	return string(raw), err
+}
+
+func dbOAuthClientToOAuthClient(dbClient dbgen.AppAuthOauthClient) (entity.OAuthClient, error) {
+	clientID, err := uuid.Parse(dbClient.ID)
+	if err != nil {
+		return entity.OAuthClient{}, err
+	}
+
+	redirectURIs := []string{}
+	err = json.Unmarshal([]byte(dbClient.RedirectUris), &redirectURIs)
+	if err != nil {
+		return entity.OAuthClient{}, err
+	}
+
+	scopes := []string{}
+	err = json.Unmarshal([]byte(dbClient.Scopes), &scopes)
+	if err != nil {
+		return entity.OAuthClient{}, err
+	}
+
+	createdAt, err := time.Parse(timestampFormat, dbClient.CreatedAt)
+	if err != nil {
+		return entity.OAuthClient{}, err
+	}
+
+	return entity.OAuthClient{
+		ID:             clientID,
+		Name:           dbClient.Name,
+		SecretHash:     dbClient.SecretHash,
+		RedirectURIs:   redirectURIs,
+		Scopes:         scopes,
+		IsConfidential: dbClient.IsConfidential,
+		CreatedAt:      createdAt,
+	}, nil
+}
+
+func dbOAuthConsentToOAuthConsent(dbConsent dbgen.AppAuthOauthConsent) (entity.OAuthConsent, error) {
+	userID, err := uuid.Parse(dbConsent.UserID)
+	if err != nil {
+		return entity.OAuthConsent{}, err
+	}
+
+	clientID, err := uuid.Parse(dbConsent.ClientID)
+	if err != nil {
+		return entity.OAuthConsent{}, err
+	}
+
+	scopes := []string{}
+	err = json.Unmarshal([]byte(dbConsent.Scopes), &scopes)
+	if err != nil {
+		return entity.OAuthConsent{}, err
+	}
+
+	createdAt, err := time.Parse(timestampFormat, dbConsent.CreatedAt)
+	if err != nil {
+		return entity.OAuthConsent{}, err
+	}
+
+	return entity.OAuthConsent{
+		UserID:    userID,
+		ClientID:  clientID,
+		Scopes:    scopes,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+func (s *oauth) CreateOAuthClient(ctx context.Context, client entity.OAuthClient) error {
+	redirectURIs, err := marshalList(client.RedirectURIs)
+	if err != nil {
+		return err
+	}
+
+	scopes, err := marshalList(client.Scopes)
+	if err != nil {
+		return err
+	}
+
+	return s.dbgen().CreateOAuthClient(ctx, dbgen.CreateOAuthClientParams{
+		ID:             client.ID.String(),
+		Name:           client.Name,
+		SecretHash:     client.SecretHash,
+		RedirectUris:   redirectURIs,
+		Scopes:         scopes,
+		IsConfidential: client.IsConfidential,
+		CreatedAt:      client.CreatedAt.UTC().Format(timestampFormat),
+	})
+}
+
+func (s *oauth) RemoveOAuthClient(ctx context.Context, clientID uuid.UUID) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.dbgen().WithTx(tx)
+	err = qtx.RemoveOAuthClientConsents(ctx, clientID.String())
+	if err != nil {
+		return err
+	}
+
+	err = qtx.RemoveOAuthClient(ctx, clientID.String())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *oauth) GetOAuthClient(ctx context.Context, clientID uuid.UUID) (entity.OAuthClient, error) {
+	dbClient, err := s.dbgen().GetOAuthClient(ctx, clientID.String())
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.OAuthClient{}, storage.ErrOAuthClientNotFound
+	}
+	if err != nil {
+		return entity.OAuthClient{}, err
+	}
+
+	return dbOAuthClientToOAuthClient(dbClient)
+}
+
+func (s *oauth) SaveOAuthConsent(ctx context.Context, consent entity.OAuthConsent) error {
+	scopes, err := marshalList(consent.Scopes)
+	if err != nil {
+		return err
+	}
+
+	return s.dbgen().SaveOAuthConsent(ctx, dbgen.SaveOAuthConsentParams{
+		UserID:    consent.UserID.String(),
+		ClientID:  consent.ClientID.String(),
+		Scopes:    scopes,
+		CreatedAt: consent.CreatedAt.UTC().Format(timestampFormat),
+	})
+}
+
+func (s *oauth) RemoveOAuthConsent(ctx context.Context, userID uuid.UUID, clientID uuid.UUID) error {
+	return s.dbgen().RemoveOAuthConsent(ctx, dbgen.RemoveOAuthConsentParams{
+		UserID:   userID.String(),
+		ClientID: clientID.String(),
+	})
+}
+
+func (s *oauth) RemoveUserOAuthConsents(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().RemoveUserOAuthConsents(ctx, userID.String())
+}
+
+func (s *oauth) GetOAuthConsent(
+	ctx context.Context,
+	userID uuid.UUID,
+	clientID uuid.UUID,
+) (entity.OAuthConsent, error) {
+	dbConsent, err := s.dbgen().GetOAuthConsent(ctx, dbgen.GetOAuthConsentParams{
+		UserID:   userID.String(),
+		ClientID: clientID.String(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.OAuthConsent{}, storage.ErrOAuthConsentNotFound
+	}
+	if err != nil {
+		return entity.OAuthConsent{}, err
+	}
+
+	return dbOAuthConsentToOAuthConsent(dbConsent)
+}
+
+func (s *oauth) GetUserOAuthConsents(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]entity.OAuthConsent, error) {
+	dbConsents, err := s.dbgen().GetUserOAuthConsents(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	consents := make([]entity.OAuthConsent, len(dbConsents))
+	for i, dbConsent := range dbConsents {
+		consents[i], err = dbOAuthConsentToOAuthConsent(dbConsent)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return consents, nil
+}
@@ -0,0 +1,186 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type organizations struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewOrganizations(db dbWithTx) *organizations {
+	return &organizations{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+func dbMemberToMember(dbMember dbgen.AppAuthOrganizationMember) (entity.OrganizationMember, error) {
+	orgID, err := uuid.Parse(dbMember.OrganizationID)
+	if err != nil {
+		return entity.OrganizationMember{}, err
+	}
+
+	userID, err := uuid.Parse(dbMember.UserID)
+	if err != nil {
+		return entity.OrganizationMember{}, err
+	}
+
+	roles := []string{}
+	err = json.Unmarshal([]byte(dbMember.Roles), &roles)
+	if err != nil {
+		return entity.OrganizationMember{}, err
+	}
+
+	createdAt, err := time.Parse(timestampFormat, dbMember.CreatedAt)
+	if err != nil {
+		return entity.OrganizationMember{}, err
+	}
+
+	return entity.OrganizationMember{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Roles:          roles,
+		CreatedAt:      createdAt,
+	}, nil
+}
+
+func dbMembersToMembers(
+	dbMembers []dbgen.AppAuthOrganizationMember,
+) ([]entity.OrganizationMember, error) {
+	members := make([]entity.OrganizationMember, len(dbMembers))
+	for i, dbMember := range dbMembers {
+		member, err := dbMemberToMember(dbMember)
+		if err != nil {
+			return nil, err
+		}
+
+		members[i] = member
+	}
+
+	return members, nil
+}
+
+func (s *organizations) CreateOrganization(ctx context.Context, org entity.Organization) error {
+	return s.dbgen().CreateOrganization(ctx, dbgen.CreateOrganizationParams{
+		ID:        org.ID.String(),
+		Name:      org.Name,
+		CreatedAt: org.CreatedAt.UTC().Format(timestampFormat),
+	})
+}
+
+func (s *organizations) GetOrganization(
+	ctx context.Context,
+	orgID uuid.UUID,
+) (entity.Organization, error) {
+	dbOrg, err := s.dbgen().GetOrganization(ctx, orgID.String())
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Organization{}, storage.ErrOrganizationNotFound
+	}
+	if err != nil {
+		return entity.Organization{}, err
+	}
+
+	createdAt, err := time.Parse(timestampFormat, dbOrg.CreatedAt)
+	if err != nil {
+		return entity.Organization{}, err
+	}
+
+	return entity.Organization{
+		ID:        orgID,
+		Name:      dbOrg.Name,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+func (s *organizations) SaveOrganizationMember(
+	ctx context.Context,
+	member entity.OrganizationMember,
+) error {
+	roles := member.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+
+	rawRoles, err := json.Marshal(roles)
+	if err != nil {
+		return err
+	}
+
+	return s.dbgen().SaveOrganizationMember(ctx, dbgen.SaveOrganizationMemberParams{
+		OrganizationID: member.OrganizationID.String(),
+		UserID:         member.UserID.String(),
+		Roles:          string(rawRoles),
+		CreatedAt:      member.CreatedAt.UTC().Format(timestampFormat),
+	})
+}
+
+func (s *organizations) RemoveOrganizationMember(
+	ctx context.Context,
+	orgID uuid.UUID,
+	userID uuid.UUID,
+) error {
+	return s.dbgen().RemoveOrganizationMember(ctx, dbgen.RemoveOrganizationMemberParams{
+		OrganizationID: orgID.String(),
+		UserID:         userID.String(),
+	})
+}
+
+func (s *organizations) RemoveUserOrganizationMembers(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().RemoveUserOrganizationMembers(ctx, userID.String())
+}
+
+func (s *organizations) GetOrganizationMember(
+	ctx context.Context,
+	orgID uuid.UUID,
+	userID uuid.UUID,
+) (entity.OrganizationMember, error) {
+	dbMember, err := s.dbgen().GetOrganizationMember(ctx, dbgen.GetOrganizationMemberParams{
+		OrganizationID: orgID.String(),
+		UserID:         userID.String(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.OrganizationMember{}, storage.ErrOrganizationMemberNotFound
+	}
+	if err != nil {
+		return entity.OrganizationMember{}, err
+	}
+
+	return dbMemberToMember(dbMember)
+}
+
+func (s *organizations) GetOrganizationMembers(
+	ctx context.Context,
+	orgID uuid.UUID,
+) ([]entity.OrganizationMember, error) {
+	dbMembers, err := s.dbgen().GetOrganizationMembers(ctx, orgID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return dbMembersToMembers(dbMembers)
+}
+
+func (s *organizations) GetUserOrganizationMembers(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]entity.OrganizationMember, error) {
+	dbMembers, err := s.dbgen().GetUserOrganizationMembers(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return dbMembersToMembers(dbMembers)
+}
@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type passkeys struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewPasskeys(db dbWithTx) *passkeys {
+	return &passkeys{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+func (s *passkeys) CreatePasskey(ctx context.Context, passkey entity.Passkey) error {
+	return s.dbgen().CreatePasskey(ctx, dbgen.CreatePasskeyParams{
+		ID:        passkey.ID,
+		UserID:    passkey.UserID.String(),
+		PublicKey: passkey.PublicKey,
+		SignCount: int64(passkey.SignCount),
+	})
+}
+
+func (s *passkeys) UpdatePasskeySignCount(
+	ctx context.Context,
+	credentialID []byte,
+	signCount uint32,
+) error {
+	return s.dbgen().UpdatePasskeySignCount(ctx, dbgen.UpdatePasskeySignCountParams{
+		ID:        credentialID,
+		SignCount: int64(signCount),
+	})
+}
+
+func dbPasskeyToPasskey(dbPasskey dbgen.AppAuthPasskey) (entity.Passkey, error) {
+	userID, err := uuid.Parse(dbPasskey.UserID)
+	if err != nil {
+		return entity.Passkey{}, err
+	}
+
+	createdAt, err := time.Parse(timestampFormat, dbPasskey.CreatedAt.String)
+	if err != nil {
+		return entity.Passkey{}, err
+	}
+
+	lastUsedAt := time.Time{}
+	if dbPasskey.LastUsedAt.Valid {
+		lastUsedAt, err = time.Parse(timestampFormat, dbPasskey.LastUsedAt.String)
+		if err != nil {
+			return entity.Passkey{}, err
+		}
+	}
+
+	return entity.Passkey{
+		ID:         dbPasskey.ID,
+		UserID:     userID,
+		PublicKey:  dbPasskey.PublicKey,
+		SignCount:  uint32(dbPasskey.SignCount),
+		CreatedAt:  createdAt,
+		LastUsedAt: lastUsedAt,
+	}, nil
+}
+
+func (s *passkeys) GetPasskey(ctx context.Context, credentialID []byte) (entity.Passkey, error) {
+	dbPasskey, err := s.dbgen().GetPasskey(ctx, credentialID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Passkey{}, storage.ErrPasskeyNotFound
+	}
+	if err != nil {
+		return entity.Passkey{}, err
+	}
+
+	return dbPasskeyToPasskey(dbPasskey)
+}
+
+func (s *passkeys) GetUserPasskeys(ctx context.Context, userID uuid.UUID) ([]entity.Passkey, error) {
+	dbPasskeys, err := s.dbgen().GetUserPasskeys(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys := make([]entity.Passkey, len(dbPasskeys))
+	for i, dbPasskey := range dbPasskeys {
+		passkeys[i], err = dbPasskeyToPasskey(dbPasskey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return passkeys, nil
+}
+
+func (s *passkeys) RemoveUserPasskeys(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().RemoveUserPasskeys(ctx, userID.String())
+}
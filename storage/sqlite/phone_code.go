@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type phoneCodes struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewPhoneCodes(db dbWithTx) *phoneCodes {
+	return &phoneCodes{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+func (s *phoneCodes) CreatePhoneCode(ctx context.Context, code entity.PhoneCode) error {
+	return s.dbgen().CreatePhoneCode(ctx, dbgen.CreatePhoneCodeParams{
+		UserID:    code.UserID.String(),
+		Code:      code.Code,
+		Attempts:  int64(code.Attempts),
+		ExpiresAt: code.ExpiresAt.UTC().Format(timestampFormat),
+	})
+}
+
+func (s *phoneCodes) IncrementPhoneCodeAttempts(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().IncrementPhoneCodeAttempts(ctx, userID.String())
+}
+
+func (s *phoneCodes) RemovePhoneCode(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().RemovePhoneCode(ctx, userID.String())
+}
+
+func (s *phoneCodes) GetPhoneCode(ctx context.Context, userID uuid.UUID) (entity.PhoneCode, error) {
+	dbCode, err := s.dbgen().GetPhoneCode(ctx, userID.String())
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.PhoneCode{}, storage.ErrPhoneCodeNotFound
+	}
+	if err != nil {
+		return entity.PhoneCode{}, err
+	}
+
+	expiresAt, err := time.Parse(timestampFormat, dbCode.ExpiresAt)
+	if err != nil {
+		return entity.PhoneCode{}, err
+	}
+
+	createdAt, err := time.Parse(timestampFormat, dbCode.CreatedAt.String)
+	if err != nil {
+		return entity.PhoneCode{}, err
+	}
+
+	return entity.PhoneCode{
+		UserID:    userID,
+		Code:      dbCode.Code,
+		Attempts:  int(dbCode.Attempts),
+		ExpiresAt: expiresAt,
+		CreatedAt: createdAt,
+	}, nil
+}
@@ -0,0 +1,21 @@
+-- name: CreateAPIKey :exec
+INSERT INTO app_auth_api_keys (id, user_id, name, prefix, hash, scopes, expires_at, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: TouchAPIKey :exec
+UPDATE app_auth_api_keys SET last_used_at = ? WHERE id = ?;
+
+-- name: RemoveAPIKey :exec
+DELETE FROM app_auth_api_keys WHERE user_id = ? AND id = ?;
+
+-- name: RemoveUserAPIKeys :exec
+DELETE FROM app_auth_api_keys WHERE user_id = ?;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT id, user_id, name, prefix, hash, scopes, expires_at, last_used_at, created_at
+FROM app_auth_api_keys WHERE prefix = ?;
+
+-- name: GetUserAPIKeys :many
+SELECT id, user_id, name, prefix, hash, scopes, expires_at, last_used_at, created_at
+FROM app_auth_api_keys WHERE user_id = ?
+ORDER BY created_at;
@@ -0,0 +1,20 @@
+-- name: AppendAuditRecord :exec
+INSERT INTO app_auth_audit_records (
+  action, actor_id, user_id, ip, user_agent, outcome, error, prev_hash, hash, created_at
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetLastAuditRecord :one
+SELECT id, action, actor_id, user_id, ip, user_agent, outcome, error, prev_hash, hash, created_at
+FROM app_auth_audit_records
+ORDER BY id DESC LIMIT 1;
+
+-- name: ListAuditRecords :many
+SELECT id, action, actor_id, user_id, ip, user_agent, outcome, error, prev_hash, hash, created_at
+FROM app_auth_audit_records
+WHERE (sqlc.arg(user_id) = '' OR user_id = sqlc.arg(user_id))
+  AND (sqlc.arg(action) = '' OR action = sqlc.arg(action))
+  AND (sqlc.arg(from_at) = '' OR created_at >= sqlc.arg(from_at))
+  AND (sqlc.arg(to_at) = '' OR created_at < sqlc.arg(to_at))
+ORDER BY id ASC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
@@ -0,0 +1,21 @@
+-- name: CreateInvitation :exec
+INSERT INTO app_auth_invitations (
+  id, inviter_id, email, organization_id, roles, meta, status, expires_at, created_at
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: UpdateInvitationStatus :exec
+UPDATE app_auth_invitations SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: GetInvitation :one
+SELECT id, inviter_id, email, organization_id, roles, meta, status, expires_at, created_at, updated_at
+FROM app_auth_invitations WHERE id = ?;
+
+-- name: GetInvitations :many
+SELECT id, inviter_id, email, organization_id, roles, meta, status, expires_at, created_at, updated_at
+FROM app_auth_invitations WHERE organization_id = ?
+ORDER BY created_at;
+
+-- name: RemovePendingUserInvitations :exec
+DELETE FROM app_auth_invitations
+WHERE status = 'pending' AND (inviter_id = ? OR email = ?);
@@ -0,0 +1,33 @@
+-- name: CreateOAuthClient :exec
+INSERT INTO app_auth_oauth_clients (id, name, secret_hash, redirect_uris, scopes, is_confidential, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: RemoveOAuthClient :exec
+DELETE FROM app_auth_oauth_clients WHERE id = ?;
+
+-- name: RemoveOAuthClientConsents :exec
+DELETE FROM app_auth_oauth_consents WHERE client_id = ?;
+
+-- name: GetOAuthClient :one
+SELECT id, name, secret_hash, redirect_uris, scopes, is_confidential, created_at
+FROM app_auth_oauth_clients WHERE id = ?;
+
+-- name: SaveOAuthConsent :exec
+INSERT INTO app_auth_oauth_consents (user_id, client_id, scopes, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(user_id, client_id) DO UPDATE SET scopes = excluded.scopes;
+
+-- name: RemoveOAuthConsent :exec
+DELETE FROM app_auth_oauth_consents WHERE user_id = ? AND client_id = ?;
+
+-- name: RemoveUserOAuthConsents :exec
+DELETE FROM app_auth_oauth_consents WHERE user_id = ?;
+
+-- name: GetOAuthConsent :one
+SELECT user_id, client_id, scopes, created_at
+FROM app_auth_oauth_consents WHERE user_id = ? AND client_id = ?;
+
+-- name: GetUserOAuthConsents :many
+SELECT user_id, client_id, scopes, created_at
+FROM app_auth_oauth_consents WHERE user_id = ?
+ORDER BY created_at;
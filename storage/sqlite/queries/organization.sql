@@ -0,0 +1,30 @@
+-- name: CreateOrganization :exec
+INSERT INTO app_auth_organizations (id, name, created_at) VALUES (?, ?, ?);
+
+-- name: GetOrganization :one
+SELECT id, name, created_at FROM app_auth_organizations WHERE id = ?;
+
+-- name: SaveOrganizationMember :exec
+INSERT INTO app_auth_organization_members (organization_id, user_id, roles, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(organization_id, user_id) DO UPDATE SET roles = excluded.roles;
+
+-- name: RemoveOrganizationMember :exec
+DELETE FROM app_auth_organization_members WHERE organization_id = ? AND user_id = ?;
+
+-- name: RemoveUserOrganizationMembers :exec
+DELETE FROM app_auth_organization_members WHERE user_id = ?;
+
+-- name: GetOrganizationMember :one
+SELECT organization_id, user_id, roles, created_at
+FROM app_auth_organization_members WHERE organization_id = ? AND user_id = ?;
+
+-- name: GetOrganizationMembers :many
+SELECT organization_id, user_id, roles, created_at
+FROM app_auth_organization_members WHERE organization_id = ?
+ORDER BY created_at;
+
+-- name: GetUserOrganizationMembers :many
+SELECT organization_id, user_id, roles, created_at
+FROM app_auth_organization_members WHERE user_id = ?
+ORDER BY created_at;
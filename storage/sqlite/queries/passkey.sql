@@ -0,0 +1,17 @@
+-- name: CreatePasskey :exec
+INSERT INTO app_auth_passkeys (id, user_id, public_key, sign_count)
+VALUES (?, ?, ?, ?);
+
+-- name: UpdatePasskeySignCount :exec
+UPDATE app_auth_passkeys SET sign_count = ?, last_used_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: GetPasskey :one
+SELECT id, user_id, public_key, sign_count, created_at, last_used_at
+FROM app_auth_passkeys WHERE id = ?;
+
+-- name: GetUserPasskeys :many
+SELECT id, user_id, public_key, sign_count, created_at, last_used_at
+FROM app_auth_passkeys WHERE user_id = ?;
+
+-- name: RemoveUserPasskeys :exec
+DELETE FROM app_auth_passkeys WHERE user_id = ?;
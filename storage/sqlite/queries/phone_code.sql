@@ -0,0 +1,18 @@
+-- name: CreatePhoneCode :exec
+INSERT INTO app_auth_phone_codes (user_id, code, attempts, expires_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(user_id) DO UPDATE SET
+    code = excluded.code,
+    attempts = excluded.attempts,
+    expires_at = excluded.expires_at,
+    created_at = CURRENT_TIMESTAMP;
+
+-- name: IncrementPhoneCodeAttempts :exec
+UPDATE app_auth_phone_codes SET attempts = attempts + 1 WHERE user_id = ?;
+
+-- name: RemovePhoneCode :exec
+DELETE FROM app_auth_phone_codes WHERE user_id = ?;
+
+-- name: GetPhoneCode :one
+SELECT user_id, code, attempts, expires_at, created_at
+FROM app_auth_phone_codes WHERE user_id = ?;
@@ -0,0 +1,14 @@
+-- name: SaveRateLimit :exec
+INSERT INTO app_auth_rate_limits (key, tokens, updated_at)
+VALUES (?, ?, ?)
+ON CONFLICT(key) DO UPDATE SET
+    tokens = excluded.tokens,
+    updated_at = excluded.updated_at;
+
+-- name: GetRateLimit :one
+SELECT key, tokens, updated_at
+FROM app_auth_rate_limits WHERE key = ?;
+
+-- name: SweepRateLimits :exec
+DELETE FROM app_auth_rate_limits
+WHERE tokens + CAST(sqlc.arg(now) - updated_at AS REAL) / sqlc.arg(interval) >= sqlc.arg(burst);
@@ -0,0 +1,13 @@
+-- name: CreateRecoveryCode :exec
+INSERT INTO app_auth_recovery_codes (user_id, code)
+VALUES (?, ?);
+
+-- name: RemoveUserRecoveryCodes :exec
+DELETE FROM app_auth_recovery_codes WHERE user_id = ?;
+
+-- name: RemoveUserRecoveryCode :exec
+DELETE FROM app_auth_recovery_codes WHERE user_id = ? AND code = ?;
+
+-- name: GetUserRecoveryCodes :many
+SELECT id, user_id, code, created_at
+FROM app_auth_recovery_codes WHERE user_id = ?;
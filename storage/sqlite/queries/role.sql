@@ -0,0 +1,40 @@
+-- name: CreateRole :exec
+INSERT INTO app_auth_roles (name) VALUES (?)
+ON CONFLICT(name) DO NOTHING;
+
+-- name: CreateRolePermission :exec
+INSERT INTO app_auth_role_permissions (role, permission) VALUES (?, ?)
+ON CONFLICT(role, permission) DO NOTHING;
+
+-- name: RemoveRolePermissions :exec
+DELETE FROM app_auth_role_permissions WHERE role = ?;
+
+-- name: RemoveRoleUsers :exec
+DELETE FROM app_auth_user_roles WHERE role = ?;
+
+-- name: RemoveRole :exec
+DELETE FROM app_auth_roles WHERE name = ?;
+
+-- name: GetRole :one
+SELECT name FROM app_auth_roles WHERE name = ?;
+
+-- name: GetRolePermissions :many
+SELECT permission FROM app_auth_role_permissions WHERE role = ?
+ORDER BY permission;
+
+-- name: AssignUserRole :exec
+INSERT INTO app_auth_user_roles (user_id, role) VALUES (?, ?)
+ON CONFLICT(user_id, role) DO NOTHING;
+
+-- name: RemoveUserRoles :exec
+DELETE FROM app_auth_user_roles WHERE user_id = ?;
+
+-- name: UnassignUserRole :exec
+DELETE FROM app_auth_user_roles WHERE user_id = ? AND role = ?;
+
+-- name: GetUserRolePermissions :many
+SELECT user_roles.role, role_permissions.permission
+FROM app_auth_user_roles user_roles
+LEFT JOIN app_auth_role_permissions role_permissions ON role_permissions.role = user_roles.role
+WHERE user_roles.user_id = ?
+ORDER BY user_roles.role, role_permissions.permission;
@@ -0,0 +1,17 @@
+-- name: CreateSession :exec
+INSERT INTO app_auth_sessions (id, user_id, user_agent, ip, last_seen_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: TouchSession :exec
+UPDATE app_auth_sessions SET ip = COALESCE(NULLIF(?, ''), ip), last_seen_at = ? WHERE id = ?;
+
+-- name: RemoveSession :exec
+DELETE FROM app_auth_sessions WHERE user_id = ? AND id = ?;
+
+-- name: RemoveUserSessions :exec
+DELETE FROM app_auth_sessions WHERE user_id = ?;
+
+-- name: GetUserSessions :many
+SELECT id, user_id, user_agent, ip, created_at, last_seen_at
+FROM app_auth_sessions WHERE user_id = ?
+ORDER BY last_seen_at DESC;
@@ -0,0 +1,16 @@
+-- name: SaveSignInAttempt :exec
+INSERT INTO app_auth_sign_in_attempts (user_id, attempts, lockouts, window_start_at, locked_until)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(user_id) DO UPDATE SET
+    attempts = excluded.attempts,
+    lockouts = excluded.lockouts,
+    window_start_at = excluded.window_start_at,
+    locked_until = excluded.locked_until,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: RemoveSignInAttempt :exec
+DELETE FROM app_auth_sign_in_attempts WHERE user_id = ?;
+
+-- name: GetSignInAttempt :one
+SELECT user_id, attempts, lockouts, window_start_at, locked_until, updated_at
+FROM app_auth_sign_in_attempts WHERE user_id = ?;
@@ -1,6 +1,6 @@
 -- name: CreateToken :exec
-INSERT INTO app_auth_tokens (user_id, kind, value, expires_at)
-VALUES (?, ?, ?, ?);
+INSERT INTO app_auth_tokens (user_id, kind, value, expires_at, family_id, client_id)
+VALUES (?, ?, ?, ?, ?, ?);
 
 -- name: RemoveUserTokens :exec
 DELETE FROM app_auth_tokens WHERE user_id = ?;
@@ -8,5 +8,28 @@ DELETE FROM app_auth_tokens WHERE user_id = ?;
 -- name: RemoveUserToken :exec
 DELETE FROM app_auth_tokens WHERE user_id = ? AND value = ?;
 
+-- name: RemoveUserTokensByKind :exec
+DELETE FROM app_auth_tokens WHERE user_id = ? AND kind = ?;
+
+-- name: RemoveTokenFamily :exec
+DELETE FROM app_auth_tokens WHERE family_id = ? AND family_id != '';
+
+-- name: RemoveClientTokens :exec
+DELETE FROM app_auth_tokens WHERE client_id = ? AND client_id != '';
+
+-- name: RemoveUserClientTokens :exec
+DELETE FROM app_auth_tokens WHERE user_id = ? AND client_id = ? AND client_id != '';
+
+-- name: RevokeToken :exec
+UPDATE app_auth_tokens SET is_revoked = TRUE WHERE value = ?;
+
 -- name: IsTokenRegistered :one
-SELECT EXISTS(SELECT 1 FROM app_auth_tokens WHERE value = ? LIMIT 1);
+SELECT EXISTS(SELECT 1 FROM app_auth_tokens WHERE value = ? AND is_revoked = FALSE LIMIT 1);
+
+-- name: GetToken :one
+SELECT id, user_id, kind, value, expires_at, created_at, family_id, is_revoked, client_id
+FROM app_auth_tokens WHERE value = ? LIMIT 1;
+
+-- name: GetUserTokens :many
+SELECT id, user_id, kind, value, expires_at, created_at, family_id, is_revoked, client_id
+FROM app_auth_tokens WHERE user_id = ?;
@@ -1,23 +1,57 @@
 -- name: CreateUser :exec
-INSERT INTO app_auth_users (id, email, phone_number, meta, password, is_verified)
-VALUES (?, ?, ?, ?, ?, ?)
-ON CONFLICT(email) DO UPDATE SET
+INSERT INTO app_auth_users (id, tenant_id, email, phone_number, meta, password, is_verified)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(tenant_id, email) DO UPDATE SET
     phone_number = excluded.phone_number,
     meta = excluded.meta,
     password = excluded.password,
     is_verified = excluded.is_verified;
 
+-- name: DeleteUser :exec
+DELETE FROM app_auth_users WHERE id = ?;
+
+-- name: AnonymizeUser :exec
+UPDATE app_auth_users SET
+    email = ?,
+    phone_number = '',
+    meta = '{}',
+    password = '',
+    totp_secret = '',
+    is_totp_enabled = FALSE,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
 -- name: UpdateUserPassword :exec
 UPDATE app_auth_users SET password = ? WHERE id = ?;
 
+-- name: UpdateUserEmail :exec
+UPDATE app_auth_users SET email = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
+
 -- name: UpdateUserIsVerified :exec
-UPDATE app_auth_users SET is_verified = ? AND is_verified_at = CURRENT_TIMESTAMP WHERE id = ?;
+UPDATE app_auth_users SET is_verified = ?, is_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: UpdateUserTOTP :exec
+UPDATE app_auth_users SET totp_secret = ?, is_totp_enabled = ? WHERE id = ?;
+
+-- name: UpdateUserTOTPLastStep :exec
+UPDATE app_auth_users SET totp_last_step = ? WHERE id = ?;
 
 -- name: GetUserByID :one
-SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at
+SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at,
+  totp_secret, is_totp_enabled, tenant_id, totp_last_step
 FROM app_auth_users WHERE id = ?;
 
 -- name: GetUserByEmail :one
-SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at
+SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at,
+  totp_secret, is_totp_enabled, tenant_id, totp_last_step
 FROM app_auth_users WHERE email = ?;
 
+-- name: GetTenantUserByEmail :one
+SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at,
+  totp_secret, is_totp_enabled, tenant_id, totp_last_step
+FROM app_auth_users WHERE tenant_id = ? AND email = ?;
+
+-- name: GetUserByPhone :one
+SELECT id, email, phone_number, password, is_verified_at, is_verified, meta, created_at, updated_at,
+  totp_secret, is_totp_enabled, tenant_id, totp_last_step
+FROM app_auth_users WHERE phone_number = ? AND phone_number != '' LIMIT 1;
@@ -0,0 +1,15 @@
+-- name: CreateIdentity :exec
+INSERT INTO app_auth_user_identities (user_id, provider, subject, email, created_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetIdentity :one
+SELECT user_id, provider, subject, email, created_at
+FROM app_auth_user_identities WHERE provider = ? AND subject = ?;
+
+-- name: GetUserIdentities :many
+SELECT user_id, provider, subject, email, created_at
+FROM app_auth_user_identities WHERE user_id = ?
+ORDER BY created_at;
+
+-- name: RemoveUserIdentities :exec
+DELETE FROM app_auth_user_identities WHERE user_id = ?;
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+// rateLimiter is a token bucket per key, a key can burst up to burst
+// requests and regains one every interval
+type rateLimiter struct {
+	db       dbWithTx
+	dbgen    func() *dbgen.Queries
+	burst    int
+	interval time.Duration
+	mu       sync.Mutex
+	sweptAt  time.Time
+}
+
+func NewRateLimiter(db dbWithTx, burst int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+		burst:    burst,
+		interval: interval,
+		sweptAt:  time.Now(),
+	}
+}
+
+// isSweepDue is true at most once every interval, to keep Allow cheap
+func (s *rateLimiter) isSweepDue(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.sweptAt) < s.interval {
+		return false
+	}
+
+	s.sweptAt = now
+	return true
+}
+
+func (s *rateLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	tokens := float64(s.burst)
+	qtx := s.dbgen().WithTx(tx)
+
+	// the rows that refilled are the same as a key never seen, removing them
+	// keeps the table from growing with every key
+	if s.isSweepDue(now) {
+		err = qtx.SweepRateLimits(ctx, dbgen.SweepRateLimitsParams{
+			Now:      now.UnixNano(),
+			Interval: int64(s.interval),
+			Burst:    float64(s.burst),
+		})
+		if err != nil {
+			return false, 0, err
+		}
+	}
+
+	dbLimit, err := qtx.GetRateLimit(ctx, key)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, 0, err
+	}
+
+	if err == nil {
+		// refill per the time passed since the last request, the time is kept
+		// in nanoseconds as the intervals can be under a second
+		elapsed := now.Sub(time.Unix(0, dbLimit.UpdatedAt))
+		tokens = math.Min(
+			float64(s.burst),
+			dbLimit.Tokens+float64(elapsed)/float64(s.interval),
+		)
+	}
+
+	isAllowed := tokens >= 1
+	if isAllowed {
+		tokens -= 1
+	}
+
+	err = qtx.SaveRateLimit(ctx, dbgen.SaveRateLimitParams{
+		Key:       key,
+		Tokens:    tokens,
+		UpdatedAt: now.UnixNano(),
+	})
+	if err != nil {
+		return false, 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, 0, err
+	}
+
+	if !isAllowed {
+		return false, time.Duration((1 - tokens) * float64(s.interval)), nil
+	}
+
+	return true, 0, nil
+}
@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	tests := []struct {
+		description string
+		inWait      time.Duration
+		expectAllow bool
+	}{
+		{"within the interval", 0, false},
+		{"after the interval", 150 * time.Millisecond, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			// the interval is under a second for the refill to need the sub
+			// second precision of the stored time
+			limiter := NewRateLimiter(newTestDB(t), 1, 100*time.Millisecond)
+
+			allowed, _, err := limiter.Allow(ctx, "foo")
+			if err != nil || !allowed {
+				t.Fatalf("expected: first request to be allowed and got %v, %v", allowed, err)
+			}
+
+			time.Sleep(testCase.inWait)
+
+			allowed, retryAfter, err := limiter.Allow(ctx, "foo")
+			if err != nil {
+				t.Fatalf("expected: non error and got %v", err)
+			}
+
+			if allowed != testCase.expectAllow {
+				t.Fatalf("expected: allowed=%v and got %v", testCase.expectAllow, allowed)
+			}
+
+			if !allowed && (retryAfter <= 0 || retryAfter > 100*time.Millisecond) {
+				t.Fatalf("expected: retry after within the interval and got %v", retryAfter)
+			}
+		})
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	tests := []struct {
+		description string
+		inWait      time.Duration
+		expectRows  int
+	}{
+		{"within the interval", 0, 2},
+		{"after the bucket refilled", 150 * time.Millisecond, 1},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			db := newTestDB(t)
+			limiter := NewRateLimiter(db, 1, 100*time.Millisecond)
+
+			_, _, err := limiter.Allow(ctx, "foo")
+			if err != nil {
+				t.Fatalf("expected: non error and got %v", err)
+			}
+
+			time.Sleep(testCase.inWait)
+
+			// the requests of other keys sweep the refilled ones
+			_, _, err = limiter.Allow(ctx, "bar")
+			if err != nil {
+				t.Fatalf("expected: non error and got %v", err)
+			}
+
+			rows := 0
+			err = db.QueryRow("SELECT COUNT(*) FROM app_auth_rate_limits").Scan(&rows)
+			if err != nil {
+				t.Fatalf("expected: non error counting and got %v", err)
+			}
+
+			if rows != testCase.expectRows {
+				t.Fatalf("expected: %d rows and got %d", testCase.expectRows, rows)
+			}
+		})
+	}
+}
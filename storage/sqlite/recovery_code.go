@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type recoveryCodes struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewRecoveryCodes(db dbWithTx) *recoveryCodes {
+	return &recoveryCodes{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+func (s *recoveryCodes) CreateRecoveryCodes(
+	ctx context.Context,
+	codes []entity.RecoveryCode,
+) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.dbgen().WithTx(tx)
+	for _, code := range codes {
+		err := qtx.CreateRecoveryCode(ctx, dbgen.CreateRecoveryCodeParams{
+			UserID: code.UserID.String(),
+			Code:   code.Code,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *recoveryCodes) RemoveUserRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().RemoveUserRecoveryCodes(ctx, userID.String())
+}
+
+func (s *recoveryCodes) RemoveUserRecoveryCode(
+	ctx context.Context,
+	userID uuid.UUID,
+	code string,
+) error {
+	return s.dbgen().RemoveUserRecoveryCode(ctx, dbgen.RemoveUserRecoveryCodeParams{
+		UserID: userID.String(),
+		Code:   code,
+	})
+}
+
+func (s *recoveryCodes) GetUserRecoveryCodes(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]entity.RecoveryCode, error) {
+	dbCodes, err := s.dbgen().GetUserRecoveryCodes(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]entity.RecoveryCode, len(dbCodes))
+	for i, dbCode := range dbCodes {
+		createdAt, err := time.Parse(timestampFormat, dbCode.CreatedAt.String)
+		if err != nil {
+			return nil, err
+		}
+
+		codes[i] = entity.RecoveryCode{
+			UserID:    userID,
+			Code:      dbCode.Code,
+			CreatedAt: createdAt,
+		}
+	}
+
+	return codes, nil
+}
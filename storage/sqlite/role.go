@@ -0,0 +1,143 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type roles struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewRoles(db dbWithTx) *roles {
+	return &roles{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+// SaveRole creates the role or replaces its permissions
+func (s *roles) SaveRole(ctx context.Context, role entity.Role) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.dbgen().WithTx(tx)
+
+	err = qtx.CreateRole(ctx, role.Name)
+	if err != nil {
+		return err
+	}
+
+	err = qtx.RemoveRolePermissions(ctx, role.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, permission := range role.Permissions {
+		err = qtx.CreateRolePermission(ctx, dbgen.CreateRolePermissionParams{
+			Role:       role.Name,
+			Permission: permission,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *roles) RemoveRole(ctx context.Context, name string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.dbgen().WithTx(tx)
+
+	err = qtx.RemoveRoleUsers(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	err = qtx.RemoveRolePermissions(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	err = qtx.RemoveRole(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *roles) GetRole(ctx context.Context, name string) (entity.Role, error) {
+	name, err := s.dbgen().GetRole(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Role{}, storage.ErrRoleNotFound
+	}
+	if err != nil {
+		return entity.Role{}, err
+	}
+
+	permissions, err := s.dbgen().GetRolePermissions(ctx, name)
+	if err != nil {
+		return entity.Role{}, err
+	}
+
+	return entity.Role{Name: name, Permissions: permissions}, nil
+}
+
+func (s *roles) AssignUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	return s.dbgen().AssignUserRole(ctx, dbgen.AssignUserRoleParams{
+		UserID: userID.String(),
+		Role:   role,
+	})
+}
+
+func (s *roles) UnassignUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	return s.dbgen().UnassignUserRole(ctx, dbgen.UnassignUserRoleParams{
+		UserID: userID.String(),
+		Role:   role,
+	})
+}
+
+func (s *roles) RemoveUserRoles(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().RemoveUserRoles(ctx, userID.String())
+}
+
+func (s *roles) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	rows, err := s.dbgen().GetUserRolePermissions(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// the rows are ordered by role, one per permission
+	roles := []entity.Role{}
+	for _, row := range rows {
+		if len(roles) == 0 || roles[len(roles)-1].Name != row.Role {
+			roles = append(roles, entity.Role{Name: row.Role, Permissions: []string{}})
+		}
+
+		if row.Permission.Valid {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, row.Permission.String)
+		}
+	}
+
+	return roles, nil
+}
@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type sessions struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewSessions(db dbWithTx) *sessions {
+	return &sessions{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+func (s *sessions) CreateSession(ctx context.Context, session entity.Session) error {
+	return s.dbgen().CreateSession(ctx, dbgen.CreateSessionParams{
+		ID:         session.ID.String(),
+		UserID:     session.UserID.String(),
+		UserAgent:  session.UserAgent,
+		Ip:         session.IP,
+		LastSeenAt: session.LastSeenAt.UTC().Format(timestampFormat),
+	})
+}
+
+func (s *sessions) TouchSession(
+	ctx context.Context,
+	sessionID uuid.UUID,
+	ip string,
+	lastSeenAt time.Time,
+) error {
+	return s.dbgen().TouchSession(ctx, dbgen.TouchSessionParams{
+		ID:         sessionID.String(),
+		Ip:         ip,
+		LastSeenAt: lastSeenAt.UTC().Format(timestampFormat),
+	})
+}
+
+func (s *sessions) RemoveSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	return s.dbgen().RemoveSession(ctx, dbgen.RemoveSessionParams{
+		UserID: userID.String(),
+		ID:     sessionID.String(),
+	})
+}
+
+func (s *sessions) RemoveUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().RemoveUserSessions(ctx, userID.String())
+}
+
+func (s *sessions) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]entity.Session, error) {
+	dbSessions, err := s.dbgen().GetUserSessions(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]entity.Session, len(dbSessions))
+	for i, dbSession := range dbSessions {
+		sessionID, err := uuid.Parse(dbSession.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		createdAt, err := time.Parse(timestampFormat, dbSession.CreatedAt.String)
+		if err != nil {
+			return nil, err
+		}
+
+		lastSeenAt, err := time.Parse(timestampFormat, dbSession.LastSeenAt)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions[i] = entity.Session{
+			ID:         sessionID,
+			UserID:     userID,
+			UserAgent:  dbSession.UserAgent,
+			IP:         dbSession.Ip,
+			CreatedAt:  createdAt,
+			LastSeenAt: lastSeenAt,
+		}
+	}
+
+	return sessions, nil
+}
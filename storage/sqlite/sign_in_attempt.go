@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type signInAttempts struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewSignInAttempts(db dbWithTx) *signInAttempts {
+	return &signInAttempts{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+func (s *signInAttempts) SaveSignInAttempt(ctx context.Context, attempt entity.SignInAttempt) error {
+	return s.dbgen().SaveSignInAttempt(ctx, dbgen.SaveSignInAttemptParams{
+		UserID:        attempt.UserID.String(),
+		Attempts:      int64(attempt.Attempts),
+		Lockouts:      int64(attempt.Lockouts),
+		WindowStartAt: attempt.WindowStartAt.UTC().Format(timestampFormat),
+		LockedUntil: sql.NullString{
+			String: attempt.LockedUntil.UTC().Format(timestampFormat),
+			Valid:  !attempt.LockedUntil.IsZero(),
+		},
+	})
+}
+
+func (s *signInAttempts) RemoveSignInAttempt(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().RemoveSignInAttempt(ctx, userID.String())
+}
+
+func (s *signInAttempts) GetSignInAttempt(
+	ctx context.Context,
+	userID uuid.UUID,
+) (entity.SignInAttempt, error) {
+	dbAttempt, err := s.dbgen().GetSignInAttempt(ctx, userID.String())
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.SignInAttempt{}, storage.ErrSignInAttemptNotFound
+	}
+	if err != nil {
+		return entity.SignInAttempt{}, err
+	}
+
+	windowStartAt, err := time.Parse(timestampFormat, dbAttempt.WindowStartAt)
+	if err != nil {
+		return entity.SignInAttempt{}, err
+	}
+
+	lockedUntil := time.Time{}
+	if dbAttempt.LockedUntil.Valid {
+		lockedUntil, err = time.Parse(timestampFormat, dbAttempt.LockedUntil.String)
+		if err != nil {
+			return entity.SignInAttempt{}, err
+		}
+	}
+
+	return entity.SignInAttempt{
+		UserID:        userID,
+		Attempts:      int(dbAttempt.Attempts),
+		Lockouts:      int(dbAttempt.Lockouts),
+		WindowStartAt: windowStartAt,
+		LockedUntil:   lockedUntil,
+	}, nil
+}
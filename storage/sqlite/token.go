@@ -2,9 +2,13 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
 	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
 )
 
@@ -36,7 +40,9 @@ func (s *tokens) CreateTokens(ctx context.Context, tokens []entity.Token) error
 			UserID:    token.UserID.String(),
 			Kind:      int64(token.Kind),
 			Value:     token.Value,
-			ExpiresAt: token.ExpiresAt.Format(timestampFormat),
+			ExpiresAt: token.ExpiresAt.UTC().Format(timestampFormat),
+			FamilyID:  optionalIDToString(token.FamilyID),
+			ClientID:  optionalIDToString(token.ClientID),
 		})
 
 		if err != nil {
@@ -44,7 +50,7 @@ func (s *tokens) CreateTokens(ctx context.Context, tokens []entity.Token) error
 		}
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 func (s *tokens) RemoveUserTokens(ctx context.Context, userID uuid.UUID) error {
@@ -62,6 +68,17 @@ func (s *tokens) RemoveUserToken(
 	})
 }
 
+func (s *tokens) RemoveUserTokensByKind(
+	ctx context.Context,
+	userID uuid.UUID,
+	kind entity.TokenKind,
+) error {
+	return s.dbgen().RemoveUserTokensByKind(ctx, dbgen.RemoveUserTokensByKindParams{
+		UserID: userID.String(),
+		Kind:   int64(kind),
+	})
+}
+
 func (s *tokens) AreTokensRegistered(ctx context.Context, tokens []string) (bool, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -85,3 +102,93 @@ func (s *tokens) AreTokensRegistered(ctx context.Context, tokens []string) (bool
 
 	return true, nil
 }
+
+func (s *tokens) RemoveTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	return s.dbgen().RemoveTokenFamily(ctx, optionalIDToString(familyID))
+}
+
+func (s *tokens) RemoveClientTokens(ctx context.Context, clientID uuid.UUID) error {
+	return s.dbgen().RemoveClientTokens(ctx, optionalIDToString(clientID))
+}
+
+func (s *tokens) RemoveUserClientTokens(
+	ctx context.Context,
+	userID uuid.UUID,
+	clientID uuid.UUID,
+) error {
+	return s.dbgen().RemoveUserClientTokens(ctx, dbgen.RemoveUserClientTokensParams{
+		UserID:   userID.String(),
+		ClientID: optionalIDToString(clientID),
+	})
+}
+
+func (s *tokens) RevokeToken(ctx context.Context, token string) error {
+	return s.dbgen().RevokeToken(ctx, token)
+}
+
+func dbTokenToToken(dbToken dbgen.AppAuthToken) (entity.Token, error) {
+	userID, err := uuid.Parse(dbToken.UserID)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	familyID, err := parseOptionalID(dbToken.FamilyID)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	clientID, err := parseOptionalID(dbToken.ClientID)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	expiresAt, err := time.Parse(timestampFormat, dbToken.ExpiresAt)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	createdAt, err := time.Parse(timestampFormat, dbToken.CreatedAt.String)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	return entity.Token{
+		Kind:      entity.TokenKind(dbToken.Kind),
+		Value:     dbToken.Value,
+		UserID:    userID,
+		FamilyID:  familyID,
+		ClientID:  clientID,
+		IsRevoked: dbToken.IsRevoked,
+		ExpiresAt: expiresAt,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+func (s *tokens) GetToken(ctx context.Context, token string) (entity.Token, error) {
+	dbToken, err := s.dbgen().GetToken(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Token{}, storage.ErrTokenNotFound
+	}
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	return dbTokenToToken(dbToken)
+}
+
+func (s *tokens) GetUserTokens(ctx context.Context, userID uuid.UUID) ([]entity.Token, error) {
+	dbTokens, err := s.dbgen().GetUserTokens(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]entity.Token, len(dbTokens))
+	for i, dbToken := range dbTokens {
+		tokens[i], err = dbTokenToToken(dbToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tokens, nil
+}
@@ -3,6 +3,7 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,14 +26,24 @@ func NewUsers(db dbWithTx) *users {
 }
 
 func (s *users) CreateUser(ctx context.Context, user entity.AuthUser) error {
-	err := s.dbgen().CreateUser(ctx, dbgen.CreateUserParams{
-		ID:    user.ID.String(),
-		Email: user.Email,
+	meta := user.Meta
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	rawMeta, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	err = s.dbgen().CreateUser(ctx, dbgen.CreateUserParams{
+		ID:       user.ID.String(),
+		TenantID: tenantIDToString(user.TenantID),
+		Email:    user.Email,
 		PhoneNumber: sql.NullString{
 			String: user.PhoneNumber,
 			Valid:  len(user.PhoneNumber) > 0,
 		},
-		Meta:     user.Meta,
+		Meta:     string(rawMeta),
 		Password: user.Password,
 	})
 	return err
@@ -50,6 +61,18 @@ func (s *users) UpdateUserPassword(
 	return err
 }
 
+func (s *users) UpdateUserEmail(
+	ctx context.Context,
+	userID uuid.UUID,
+	email string,
+) error {
+	err := s.dbgen().UpdateUserEmail(ctx, dbgen.UpdateUserEmailParams{
+		ID:    userID.String(),
+		Email: email,
+	})
+	return err
+}
+
 func (s *users) VerifyUser(ctx context.Context, userID uuid.UUID) error {
 	err := s.dbgen().UpdateUserIsVerified(ctx, dbgen.UpdateUserIsVerifiedParams{
 		ID: userID.String(),
@@ -61,15 +84,63 @@ func (s *users) VerifyUser(ctx context.Context, userID uuid.UUID) error {
 	return err
 }
 
+func (s *users) UpdateUserTOTP(
+	ctx context.Context,
+	userID uuid.UUID,
+	secret string,
+	isEnabled bool,
+) error {
+	err := s.dbgen().UpdateUserTOTP(ctx, dbgen.UpdateUserTOTPParams{
+		ID: userID.String(),
+		TotpSecret: sql.NullString{
+			String: secret,
+			Valid:  true,
+		},
+		IsTotpEnabled: sql.NullBool{
+			Bool:  isEnabled,
+			Valid: true,
+		},
+	})
+	return err
+}
+
+func (s *users) UpdateUserTOTPLastStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	return s.dbgen().UpdateUserTOTPLastStep(ctx, dbgen.UpdateUserTOTPLastStepParams{
+		ID:           userID.String(),
+		TotpLastStep: step,
+	})
+}
+
+// tenantIDToString keeps the tenant empty for the users without one
+func tenantIDToString(tenantID uuid.UUID) string {
+	if tenantID == uuid.Nil {
+		return ""
+	}
+
+	return tenantID.String()
+}
+
 func dbUserToAuthUser(dbUser dbgen.AppAuthUser) (entity.AuthUser, error) {
 	userID, err := uuid.Parse(dbUser.ID)
 	if err != nil {
 		return entity.AuthUser{}, err
 	}
 
-	isVerifiedAt, err := time.Parse(timestampFormat, dbUser.IsVerifiedAt.String)
-	if err != nil {
-		return entity.AuthUser{}, err
+	tenantID := uuid.Nil
+	if len(dbUser.TenantID) > 0 {
+		tenantID, err = uuid.Parse(dbUser.TenantID)
+		if err != nil {
+			return entity.AuthUser{}, err
+		}
+	}
+
+	// the users that didn't verify yet have no verification time
+	isVerifiedAt := time.Time{}
+	if dbUser.IsVerifiedAt.Valid {
+		isVerifiedAt, err = time.Parse(timestampFormat, dbUser.IsVerifiedAt.String)
+		if err != nil {
+			return entity.AuthUser{}, err
+		}
 	}
 
 	createdAt, err := time.Parse(timestampFormat, dbUser.CreatedAt.String)
@@ -83,14 +154,18 @@ func dbUserToAuthUser(dbUser dbgen.AppAuthUser) (entity.AuthUser, error) {
 	}
 
 	return entity.AuthUser{
-		ID:           userID,
-		Email:        dbUser.Email,
-		PhoneNumber:  dbUser.PhoneNumber.String,
-		Password:     dbUser.Password,
-		IsVerified:   dbUser.IsVerified.Bool,
-		IsVerifiedAt: isVerifiedAt,
+		ID:            userID,
+		Email:         dbUser.Email,
+		PhoneNumber:   dbUser.PhoneNumber.String,
+		Password:      dbUser.Password,
+		IsVerified:    dbUser.IsVerified.Bool,
+		IsVerifiedAt:  isVerifiedAt,
+		TOTPSecret:    dbUser.TotpSecret.String,
+		IsTOTPEnabled: dbUser.IsTotpEnabled.Bool,
+		TOTPLastStep:  dbUser.TotpLastStep,
 		// TODO: how does the meta come in? string? map?
 		// Meta:         dbUser.Meta,
+		TenantID:  tenantID,
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 	}, nil
@@ -114,3 +189,46 @@ func (s *users) GetUserByEmail(ctx context.Context, email string) (entity.AuthUs
 
 	return dbUserToAuthUser(dbUser)
 }
+
+func (s *users) GetTenantUserByEmail(
+	ctx context.Context,
+	tenantID uuid.UUID,
+	email string,
+) (entity.AuthUser, error) {
+	dbUser, err := s.dbgen().GetTenantUserByEmail(ctx, dbgen.GetTenantUserByEmailParams{
+		TenantID: tenantIDToString(tenantID),
+		Email:    email,
+	})
+	if err != nil {
+		return entity.AuthUser{}, err
+	}
+
+	return dbUserToAuthUser(dbUser)
+}
+
+func (s *users) GetUserByPhone(ctx context.Context, phone string) (entity.AuthUser, error) {
+	dbUser, err := s.dbgen().GetUserByPhone(ctx, sql.NullString{
+		String: phone,
+		Valid:  len(phone) > 0,
+	})
+	if err != nil {
+		return entity.AuthUser{}, err
+	}
+
+	return dbUserToAuthUser(dbUser)
+}
+
+func (s *users) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().DeleteUser(ctx, userID.String())
+}
+
+func (s *users) AnonymizeUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	email string,
+) error {
+	return s.dbgen().AnonymizeUser(ctx, dbgen.AnonymizeUserParams{
+		ID:    userID.String(),
+		Email: email,
+	})
+}
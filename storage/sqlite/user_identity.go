@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+	"github.com/iamajoe/goauth/storage/sqlite/dbgen"
+)
+
+type userIdentities struct {
+	db    dbWithTx
+	dbgen func() *dbgen.Queries
+}
+
+func NewUserIdentities(db dbWithTx) *userIdentities {
+	return &userIdentities{
+		db: db,
+		dbgen: func() *dbgen.Queries {
+			return dbgen.New(db)
+		},
+	}
+}
+
+func dbUserIdentityToUserIdentity(dbIdentity dbgen.AppAuthUserIdentity) (entity.UserIdentity, error) {
+	userID, err := uuid.Parse(dbIdentity.UserID)
+	if err != nil {
+		return entity.UserIdentity{}, err
+	}
+
+	createdAt, err := time.Parse(timestampFormat, dbIdentity.CreatedAt)
+	if err != nil {
+		return entity.UserIdentity{}, err
+	}
+
+	return entity.UserIdentity{
+		UserID:    userID,
+		Provider:  dbIdentity.Provider,
+		Subject:   dbIdentity.Subject,
+		Email:     dbIdentity.Email,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+func (s *userIdentities) CreateIdentity(ctx context.Context, identity entity.UserIdentity) error {
+	return s.dbgen().CreateIdentity(ctx, dbgen.CreateIdentityParams{
+		UserID:    identity.UserID.String(),
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: identity.CreatedAt.UTC().Format(timestampFormat),
+	})
+}
+
+func (s *userIdentities) GetIdentity(
+	ctx context.Context,
+	provider string,
+	subject string,
+) (entity.UserIdentity, error) {
+	dbIdentity, err := s.dbgen().GetIdentity(ctx, dbgen.GetIdentityParams{
+		Provider: provider,
+		Subject:  subject,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.UserIdentity{}, storage.ErrIdentityNotFound
+	}
+	if err != nil {
+		return entity.UserIdentity{}, err
+	}
+
+	return dbUserIdentityToUserIdentity(dbIdentity)
+}
+
+func (s *userIdentities) GetUserIdentities(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]entity.UserIdentity, error) {
+	dbIdentities, err := s.dbgen().GetUserIdentities(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]entity.UserIdentity, len(dbIdentities))
+	for i, dbIdentity := range dbIdentities {
+		identities[i], err = dbUserIdentityToUserIdentity(dbIdentity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return identities, nil
+}
+
+func (s *userIdentities) RemoveUserIdentities(ctx context.Context, userID uuid.UUID) error {
+	return s.dbgen().RemoveUserIdentities(ctx, userID.String())
+}
@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestDB opens an in memory database with the up migrations applied
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("expected: non error opening the database and got %v", err)
+	}
+	// each connection of an in memory database is a database of its own
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	paths, _ := filepath.Glob(filepath.Join("migrations", "*.sql"))
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("expected: non error reading %s and got %v", path, err)
+		}
+
+		up, _, _ := strings.Cut(string(raw), "-- +goose Down")
+		if _, err := db.Exec(up); err != nil {
+			t.Fatalf("expected: non error migrating %s and got %v", path, err)
+		}
+	}
+
+	return db
+}
+
+func TestVerifyUser(t *testing.T) {
+	tests := []struct {
+		description  string
+		inVerify     bool
+		expectVerify bool
+	}{
+		{"verified", true, true},
+		{"not verified", false, false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			users := NewUsers(newTestDB(t))
+			userID := uuid.New()
+
+			err := users.CreateUser(ctx, entity.AuthUser{ID: userID, Email: "foo@bar.com", Password: "hash"})
+			if err != nil {
+				t.Fatalf("expected: non error on create and got %v", err)
+			}
+
+			if testCase.inVerify {
+				if err := users.VerifyUser(ctx, userID); err != nil {
+					t.Fatalf("expected: non error on verify and got %v", err)
+				}
+			}
+
+			user, err := users.GetUserByID(ctx, userID)
+			if err != nil {
+				t.Fatalf("expected: non error on get and got %v", err)
+			}
+
+			if user.IsVerified != testCase.expectVerify || user.IsVerifiedAt.IsZero() == testCase.expectVerify {
+				t.Fatalf("expected: verified=%v and got %v at %v", testCase.expectVerify, user.IsVerified, user.IsVerifiedAt)
+			}
+		})
+	}
+}
+
+func TestGetTenantUserByEmail(t *testing.T) {
+	tenantID := uuid.New()
+	otherTenantID := uuid.New()
+
+	tests := []struct {
+		description string
+		inTenantID  uuid.UUID
+		expectIndex int
+	}{
+		{"without tenant", uuid.Nil, 0},
+		{"tenant", tenantID, 1},
+		{"other tenant", otherTenantID, 2},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			users := NewUsers(newTestDB(t))
+
+			// the same email is registered once on each tenant
+			userIDs := []uuid.UUID{}
+			for _, tenantID := range []uuid.UUID{uuid.Nil, tenantID, otherTenantID} {
+				user := entity.AuthUser{ID: uuid.New(), TenantID: tenantID, Email: "foo@bar.com", Password: "hash"}
+				if err := users.CreateUser(ctx, user); err != nil {
+					t.Fatalf("expected: non error on create and got %v", err)
+				}
+				userIDs = append(userIDs, user.ID)
+			}
+
+			user, err := users.GetTenantUserByEmail(ctx, testCase.inTenantID, "foo@bar.com")
+			if err != nil {
+				t.Fatalf("expected: non error on get and got %v", err)
+			}
+
+			if user.ID != userIDs[testCase.expectIndex] || user.TenantID != testCase.inTenantID {
+				t.Fatalf("expected: the user of the tenant and got %v", user)
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package goauth
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
-	"strings"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -15,30 +19,260 @@ var (
 	ErrTokenWrongLength = errors.New("token has wrong length")
 	ErrTokenInvalid     = errors.New("token invalid")
 	ErrWrongUser        = errors.New("wrong user")
+	ErrSecretRequired   = errors.New("secret required")
 )
 
-func ValidateTokenUserID(rawToken string, secret string) (uuid.UUID, error) {
+// tokenClaims are the claims carried by the tokens, the user id is set as
+// the subject while the others are only set by the flows that need them
+type tokenClaims struct {
+	jwt.StandardClaims
+	// Type is the kind of the token so that a token isn't taken for another
+	// kind, even with the secrets shared
+	Type        string   `json:"typ,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// Org is the active organization, OrgRoles are the roles of the user
+	// within it
+	Org      string   `json:"org,omitempty"`
+	OrgRoles []string `json:"org_roles,omitempty"`
+	// ClientID and Scope are set on the tokens of the oauth clients, the
+	// redirect uri, code challenge and nonce on their authorization codes
+	ClientID      string `json:"client_id,omitempty"`
+	Scope         string `json:"scope,omitempty"`
+	RedirectURI   string `json:"redirect_uri,omitempty"`
+	CodeChallenge string `json:"code_challenge,omitempty"`
+	Nonce         string `json:"nonce,omitempty"`
+	// Custom are the claims of WithClaimsEnricher, set next to the others
+	Custom map[string]any `json:"-"`
+}
+
+// reservedClaims are the claims that Custom can't override
+var reservedClaims = []string{
+	"aud", "exp", "jti", "iat", "iss", "nbf", "sub", "typ",
+	"email", "roles", "permissions", "org", "org_roles",
+	"client_id", "scope", "redirect_uri", "code_challenge", "nonce",
+}
+
+// plainTokenClaims are the claims without their json methods
+type plainTokenClaims tokenClaims
+
+func (c tokenClaims) MarshalJSON() ([]byte, error) {
+	raw, err := json.Marshal(plainTokenClaims(c))
+	if err != nil || len(c.Custom) == 0 {
+		return raw, err
+	}
+
+	merged := map[string]any{}
+	for name, value := range c.Custom {
+		if !slices.Contains(reservedClaims, name) {
+			merged[name] = value
+		}
+	}
+
+	err = json.Unmarshal(raw, &merged)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(merged)
+}
+
+func (c *tokenClaims) UnmarshalJSON(raw []byte) error {
+	err := json.Unmarshal(raw, (*plainTokenClaims)(c))
+	if err != nil {
+		return err
+	}
+
+	all := map[string]any{}
+	err = json.Unmarshal(raw, &all)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range all {
+		if slices.Contains(reservedClaims, name) {
+			continue
+		}
+
+		if c.Custom == nil {
+			c.Custom = map[string]any{}
+		}
+		c.Custom[name] = value
+	}
+
+	return nil
+}
+
+// isLegacy is true for the tokens issued before the subject was set, the
+// user id was then set as the issuer
+func (c tokenClaims) isLegacy() bool {
+	return len(c.Subject) == 0
+}
+
+// subject returns the user of the token, or the client of the client
+// credentials tokens
+func (c tokenClaims) subject() string {
+	if c.isLegacy() {
+		return c.Issuer
+	}
+
+	return c.Subject
+}
+
+func (c tokenClaims) userID() (uuid.UUID, error) {
+	return uuid.Parse(c.subject())
+}
+
+// checkKind refuses the tokens of other kinds, the legacy ones have no kind
+// and are refused as well
+func (c tokenClaims) checkKind(kind entity.TokenKind) error {
+	if c.Type != kind.String() {
+		return ErrTokenInvalid
+	}
+
+	return nil
+}
+
+// checkKindOrLegacy is checkKind with the legacy tokens accepted until the
+// date, they have no kind to check
+func (c tokenClaims) checkKindOrLegacy(kind entity.TokenKind, legacyUntil time.Time) error {
+	if !c.isLegacy() {
+		return c.checkKind(kind)
+	}
+
+	if time.Now().Before(legacyUntil) {
+		return nil
+	}
+
+	return ErrTokenInvalid
+}
+
+// secretKeyID is the kid of the tokens signed with the secret, it hints the
+// secret to verify them with without giving it away
+func secretKeyID(secret string) string {
+	hash := sha256.Sum256([]byte("kid:" + secret))
+	return hex.EncodeToString(hash[:4])
+}
+
+func parseTokenClaims(rawToken string, secrets ...string) (tokenClaims, error) {
+	claims, _, err := parseTokenClaimsWithSecrets(rawToken, secrets)
+	return claims, err
+}
+
+// parseTokenClaimsWithSecrets verifies the token against the secret of its
+// kid, against each of them in order for the tokens without one. The index
+// of the secret the token was signed with is returned
+func parseTokenClaimsWithSecrets(rawToken string, secrets []string) (tokenClaims, int, error) {
+	if len(rawToken) == 0 {
+		return tokenClaims{}, -1, ErrTokenWrongLength
+	}
+
+	// an empty secret would verify the tokens anyone can sign
+	known := []int{}
+	for i, secret := range secrets {
+		if len(secret) > 0 {
+			known = append(known, i)
+		}
+	}
+	if len(known) == 0 {
+		return tokenClaims{}, -1, ErrSecretRequired
+	}
+
+	candidates := []int{}
+	unverified, _, err := new(jwt.Parser).ParseUnverified(rawToken, &tokenClaims{})
+	if err == nil {
+		kid, _ := unverified.Header["kid"].(string)
+		for _, i := range known {
+			if len(kid) > 0 && secretKeyID(secrets[i]) == kid {
+				candidates = append(candidates, i)
+				break
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = known
+	}
+
+	err = ErrTokenInvalid
+	for _, i := range candidates {
+		var claims tokenClaims
+		claims, err = parseTokenClaimsWithKey(rawToken, func(t *jwt.Token) (any, error) {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, ErrTokenInvalid
+			}
+
+			return []byte(secrets[i]), nil
+		})
+
+		// only a wrong signature is worth trying the next secret
+		var validationErr *jwt.ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Errors&jwt.ValidationErrorSignatureInvalid == 0 {
+			return claims, i, err
+		}
+	}
+
+	return tokenClaims{}, -1, err
+}
+
+// parseTokenClaimsWithKey parses the token with the key resolved by keyFunc,
+// for the tokens that aren't signed with a secret. The claims of the expired
+// tokens are returned along with ErrExpirationTime when the signature is
+// valid, so that the expired access tokens can be refreshed
+func parseTokenClaimsWithKey(rawToken string, keyFunc jwt.Keyfunc) (tokenClaims, error) {
 	if len(rawToken) == 0 {
-		return uuid.UUID{}, ErrTokenWrongLength
+		return tokenClaims{}, ErrTokenWrongLength
 	}
 
-	claims := &jwt.StandardClaims{}
-	token, err := jwt.ParseWithClaims(rawToken, claims, func(t *jwt.Token) (any, error) {
-		return []byte(secret), nil
-	})
+	claims := tokenClaims{}
+	token, err := jwt.ParseWithClaims(rawToken, &claims, keyFunc)
 	if err != nil {
-		if strings.Contains(err.Error(), "token is expired") {
-			return uuid.UUID{}, ErrExpirationTime
+		// the signature is still verified once the expiration fails
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Errors == jwt.ValidationErrorExpired {
+			return claims, ErrExpirationTime
 		}
 
-		return uuid.UUID{}, err
+		return tokenClaims{}, err
 	}
 
 	if !token.Valid {
-		return uuid.UUID{}, ErrTokenInvalid
+		return tokenClaims{}, ErrTokenInvalid
+	}
+
+	return claims, nil
+}
+
+// ValidateTokenUserID returns the user of the access token signed with any of
+// the secrets. Without an Auth there is no WithLegacyTokens date and the
+// legacy tokens are refused, Auth.ValidateToken accepts them up to the date
+func ValidateTokenUserID(rawToken string, secrets ...string) (uuid.UUID, error) {
+	claims, err := parseTokenClaims(rawToken, secrets...)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	err = claims.checkKindOrLegacy(entity.TokenKindAccess, time.Time{})
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return claims.userID()
+}
+
+// validateTokenKindUserID is ValidateTokenUserID for the tokens of the kind
+func validateTokenKindUserID(kind entity.TokenKind, rawToken string, secret string) (uuid.UUID, error) {
+	claims, err := parseTokenClaims(rawToken, secret)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	err = claims.checkKind(kind)
+	if err != nil {
+		return uuid.UUID{}, err
 	}
 
-	return uuid.Parse(claims.Issuer)
+	return claims.userID()
 }
 
 func NewToken(
@@ -47,12 +281,49 @@ func NewToken(
 	secret string,
 	expiringTime time.Duration,
 ) (entity.Token, error) {
-	expiringDate := time.Now().Add(expiringTime)
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.StandardClaims{
-		ExpiresAt: expiringDate.Unix(),
-		Issuer:    userID.String(),
-	})
-	value, err := jwtToken.SignedString([]byte(secret))
+	return newTokenWithClaims(kind, userID, secret, expiringTime, tokenClaims{})
+}
+
+func newTokenWithClaims(
+	kind entity.TokenKind,
+	userID uuid.UUID,
+	secret string,
+	expiringTime time.Duration,
+	claims tokenClaims,
+) (entity.Token, error) {
+	if len(secret) == 0 {
+		return entity.Token{}, ErrSecretRequired
+	}
+
+	sign := func(claims jwt.Claims) (string, error) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = secretKeyID(secret)
+
+		return token.SignedString([]byte(secret))
+	}
+
+	return newSignedToken(kind, userID, expiringTime, claims, sign)
+}
+
+// newSignedToken sets the claims of the token and signs it through sign
+func newSignedToken(
+	kind entity.TokenKind,
+	userID uuid.UUID,
+	expiringTime time.Duration,
+	claims tokenClaims,
+	sign func(claims jwt.Claims) (string, error),
+) (entity.Token, error) {
+	now := time.Now()
+	expiringDate := now.Add(expiringTime)
+	claims.Subject = userID.String()
+	claims.Type = kind.String()
+	claims.IssuedAt = now.Unix()
+	claims.NotBefore = now.Unix()
+	claims.ExpiresAt = expiringDate.Unix()
+	// unique id so that sessions issued in the same second differ
+	claims.Id = uuid.NewString()
+
+	value, err := sign(&claims)
 	if err != nil {
 		return entity.Token{}, err
 	}
@@ -62,6 +333,181 @@ func NewToken(
 		Value:     value,
 		UserID:    userID,
 		ExpiresAt: expiringDate,
+		CreatedAt: now,
+	}, nil
+}
+
+// issuer is the issuer of the tokens, the base url unless it is set
+func (auth Auth) issuer() string {
+	if len(auth.tokenIssuer) > 0 {
+		return auth.tokenIssuer
+	}
+
+	return auth.baseURL
+}
+
+// newKindToken issues the token of the kind with the secret of the kind, or
+// the key of the provider for the access tokens, the issuer and audience are
+// the ones of the service
+func (auth Auth) newKindToken(
+	kind entity.TokenKind,
+	userID uuid.UUID,
+	claims tokenClaims,
+) (entity.Token, error) {
+	claims.Issuer = auth.issuer()
+	claims.Audience = auth.tokenAudience
+
+	secret, expiringTime := getTokenKindSecretAndExpire(kind, auth.secrets, auth.tokenExpirationTimes)
+	if kind != entity.TokenKindAccess || auth.keyProvider == nil {
+		return newTokenWithClaims(kind, userID, secret, expiringTime, claims)
+	}
+
+	key, err := auth.keyProvider.SigningKey()
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	return newSignedToken(kind, userID, expiringTime, claims, key.sign)
+}
+
+// checkTokenClaims refuses the tokens of other kinds, issuers or audiences,
+// the legacy tokens are only accepted until the date of WithLegacyTokens
+func (auth Auth) checkTokenClaims(kind entity.TokenKind, claims tokenClaims) error {
+	err := claims.checkKindOrLegacy(kind, auth.legacyTokensUntil)
+	if err != nil || claims.isLegacy() {
+		return err
+	}
+
+	if claims.Issuer != auth.issuer() {
+		return ErrTokenInvalid
+	}
+
+	if len(auth.tokenAudience) > 0 && !claims.VerifyAudience(auth.tokenAudience, true) {
+		return ErrTokenInvalid
+	}
+
+	return nil
+}
+
+// parseKindTokenClaims verifies the token against the secrets of the kind,
+// EventDeprecatedSecretUsed is emitted when it was signed with one that is
+// no longer the first
+func (auth Auth) parseKindTokenClaims(
+	ctx context.Context,
+	kind entity.TokenKind,
+	rawToken string,
+) (tokenClaims, error) {
+	claims, index, err := parseTokenClaimsWithSecrets(rawToken, getTokenKindSecrets(kind, auth.secrets))
+	if err != nil && !errors.Is(err, ErrExpirationTime) {
+		return claims, err
+	}
+
+	checkErr := auth.checkTokenClaims(kind, claims)
+	if checkErr != nil {
+		return tokenClaims{}, checkErr
+	}
+
+	if err != nil {
+		return claims, err
+	}
+
+	if index > 0 {
+		userID, _ := claims.userID()
+		_ = auth.emit(ctx, Event{Kind: EventDeprecatedSecretUsed, UserID: userID, TokenKind: kind})
+	}
+
+	return claims, nil
+}
+
+// validateTokenOfKind is ValidateTokenUserID for the tokens of the kind, with
+// the secrets of the kind and the checks of checkTokenClaims
+func (auth Auth) validateTokenOfKind(
+	ctx context.Context,
+	kind entity.TokenKind,
+	rawToken string,
+) (uuid.UUID, error) {
+	claims, err := auth.parseKindTokenClaims(ctx, kind, rawToken)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return claims.userID()
+}
+
+// Claims are the claims of a token as returned by ValidateToken
+type Claims struct {
+	ID        string
+	Kind      entity.TokenKind
+	UserID    uuid.UUID
+	Issuer    string
+	Audience  string
+	IssuedAt  time.Time
+	NotBefore time.Time
+	ExpiresAt time.Time
+	Email     string
+	Roles     []string
+	// Permissions are the ones of the roles of the user, within the scopes
+	// for the tokens of the oauth clients
+	Permissions []string
+	Org         string
+	OrgRoles    []string
+	ClientID    string
+	Scope       string
+	// Custom are the claims added by WithClaimsEnricher
+	Custom map[string]any
+}
+
+// unixTime is the time of the claim, zero when the claim isn't set
+func unixTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0)
+}
+
+// ValidateToken verifies the token of the kind and returns its claims, the
+// access tokens are verified with the keys of WithKeyProvider as well
+func (auth Auth) ValidateToken(ctx context.Context, kind entity.TokenKind, rawToken string) (Claims, error) {
+	var claims tokenClaims
+	var err error
+	if kind == entity.TokenKindAccess {
+		claims, err = auth.parseAccessTokenClaims(ctx, rawToken)
+	} else {
+		claims, err = auth.parseKindTokenClaims(ctx, kind, rawToken)
+	}
+	if err != nil {
+		return Claims{}, err
+	}
+
+	// the client credentials tokens have the client as the subject
+	userID, _ := claims.userID()
+	if claims.subject() == claims.ClientID {
+		userID = uuid.Nil
+	}
+
+	issuer := claims.Issuer
+	if claims.isLegacy() {
+		issuer = ""
+	}
+
+	return Claims{
+		ID:          claims.Id,
+		Kind:        kind,
+		UserID:      userID,
+		Issuer:      issuer,
+		Audience:    claims.Audience,
+		IssuedAt:    unixTime(claims.IssuedAt),
+		NotBefore:   unixTime(claims.NotBefore),
+		ExpiresAt:   unixTime(claims.ExpiresAt),
+		Email:       claims.Email,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Org:         claims.Org,
+		OrgRoles:    claims.OrgRoles,
+		ClientID:    claims.ClientID,
+		Scope:       claims.Scope,
+		Custom:      claims.Custom,
 	}, nil
 }
 
@@ -80,14 +526,24 @@ func GetRefreshedToken(params GetRefreshedTokenParams) (entity.Token, error) {
 	refreshSecret := params.RefreshSecret
 	expiringTime := params.ExpiringTime
 
-	// check the auth token and retrieve the user id
-	authUserID, err := ValidateTokenUserID(accessToken, authSecret)
-	if err != nil && err.Error() != ErrExpirationTime.Error() {
+	// check the auth token and retrieve the user id, it may be expired
+	authClaims, err := parseTokenClaims(accessToken, authSecret)
+	if err != nil && !errors.Is(err, ErrExpirationTime) {
+		return entity.Token{}, err
+	}
+
+	err = authClaims.checkKind(entity.TokenKindAccess)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	authUserID, err := authClaims.userID()
+	if err != nil {
 		return entity.Token{}, err
 	}
 
 	// check the refresh token
-	refreshUserID, err := ValidateTokenUserID(refreshToken, refreshSecret)
+	refreshUserID, err := validateTokenKindUserID(entity.TokenKindRefresh, refreshToken, refreshSecret)
 	if err != nil {
 		return entity.Token{}, err
 	}
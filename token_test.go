@@ -1,11 +1,15 @@
 package goauth
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
 )
 
 var newAndValidateTokenTests = []struct {
@@ -29,6 +33,11 @@ var newAndValidateTokenTests = []struct {
 		"1234",
 		time.Minute * -1,
 		true,
+	}, {
+		"empty secret",
+		"",
+		time.Minute,
+		true,
 	},
 }
 
@@ -185,3 +194,253 @@ func TestGetRefreshedToken(t *testing.T) {
 		})
 	}
 }
+
+var secretRotationTests = []struct {
+	description  string
+	inSecret     string
+	inWithoutKid bool
+	expectError  bool
+	expectEvent  bool
+}{
+	{"current secret", "new", false, false, false},
+	{"deprecated secret", "old", false, false, true},
+	{"deprecated secret without kid", "old", true, false, true},
+	{"retired secret", "retired", false, true, false},
+	{"retired secret without kid", "retired", true, true, false},
+}
+
+func TestSecretRotation(t *testing.T) {
+	for _, testCase := range secretRotationTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			events := []Event{}
+			auth := New(
+				AuthSecrets{
+					TokenAccess: "new",
+					Deprecated:  map[entity.TokenKind][]string{entity.TokenKindAccess: {"older", "old"}},
+				},
+				WithEventHandler(func(ctx context.Context, event Event) error {
+					events = append(events, event)
+					return nil
+				}, EventDeliverySync, EventDeprecatedSecretUsed),
+			)
+
+			userID := uuid.New()
+			claims := tokenClaims{StandardClaims: jwt.StandardClaims{Issuer: auth.issuer()}}
+			token, err := newTokenWithClaims(entity.TokenKindAccess, userID, testCase.inSecret, time.Minute, claims)
+			if err != nil {
+				t.Fatalf("expected: non error on newToken and got %v", err)
+			}
+
+			// the tokens signed before the kid was set are tried on each secret
+			if testCase.inWithoutKid {
+				claims := tokenClaims{
+					StandardClaims: jwt.StandardClaims{
+						Subject:   userID.String(),
+						Issuer:    auth.issuer(),
+						ExpiresAt: time.Now().Add(time.Minute).Unix(),
+					},
+					Type: entity.TokenKindAccess.String(),
+				}
+				token.Value, _ = jwt.NewWithClaims(jwt.SigningMethodHS256, &claims).
+					SignedString([]byte(testCase.inSecret))
+			}
+
+			res, err := auth.validateAccessTokenUserID(ctx, token.Value)
+			if testCase.expectError {
+				if err == nil {
+					t.Fatal("expected: error")
+				}
+				return
+			}
+
+			if err != nil || res != userID {
+				t.Fatalf("expected: user=%v and got %v %v", userID, res, err)
+			}
+
+			if !testCase.expectEvent {
+				if len(events) > 0 {
+					t.Fatalf("expected: no events and got %v", events)
+				}
+				return
+			}
+
+			if len(events) != 1 ||
+				events[0].TokenKind != entity.TokenKindAccess ||
+				events[0].UserID != userID {
+				t.Fatalf("expected: the deprecated secret event and got %v", events)
+			}
+		})
+	}
+}
+
+var validateTokenUserIDTests = []struct {
+	description string
+	inKind      entity.TokenKind
+	inLegacy    bool
+	expectErr   error
+}{
+	{"access token", entity.TokenKindAccess, false, nil},
+	{"verify token", entity.TokenKindVerify, false, ErrTokenInvalid},
+	{"legacy token", entity.TokenKindAccess, true, ErrTokenInvalid},
+}
+
+func TestValidateTokenUserID(t *testing.T) {
+	for _, testCase := range validateTokenUserIDTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			userID := uuid.New()
+			token, err := NewToken(testCase.inKind, userID, "1234", time.Minute)
+			if err != nil {
+				t.Fatalf("expected: non error on newToken and got %v", err)
+			}
+
+			// the tokens were issued with the user as the issuer
+			if testCase.inLegacy {
+				legacy := tokenClaims{StandardClaims: jwt.StandardClaims{
+					Issuer:    userID.String(),
+					ExpiresAt: time.Now().Add(time.Minute).Unix(),
+				}}
+				token.Value, _ = jwt.NewWithClaims(jwt.SigningMethodHS256, &legacy).SignedString([]byte("1234"))
+			}
+
+			res, err := ValidateTokenUserID(token.Value, "1234")
+			if !errors.Is(err, testCase.expectErr) {
+				t.Fatalf("expected: %v and got %v", testCase.expectErr, err)
+			}
+
+			if testCase.expectErr == nil && res != userID {
+				t.Fatalf("expected: user=%v\ngot: %v", userID, res)
+			}
+		})
+	}
+}
+
+var validateTokenTests = []struct {
+	description    string
+	inKind         entity.TokenKind
+	inLegacy       bool
+	inLegacyUntil  time.Duration
+	inValidateKind entity.TokenKind
+	inIssuer       string
+	inAudience     string
+	expectErr      error
+}{
+	{"access token", entity.TokenKindAccess, false, 0, entity.TokenKindAccess, "api", "app", nil},
+	{"legacy access token", entity.TokenKindAccess, true, time.Hour, entity.TokenKindAccess, "api", "app", nil},
+	{"legacy access token within the refresh expiration", entity.TokenKindAccess, true, 0, entity.TokenKindAccess, "api", "app", nil},
+	{"legacy access token past the date", entity.TokenKindAccess, true, -time.Hour, entity.TokenKindAccess, "api", "app", ErrTokenInvalid},
+	{"verify token as access", entity.TokenKindVerify, false, 0, entity.TokenKindAccess, "api", "app", ErrTokenInvalid},
+	{"access token as verify", entity.TokenKindAccess, false, 0, entity.TokenKindVerify, "api", "app", ErrTokenInvalid},
+	{"other issuer", entity.TokenKindAccess, false, 0, entity.TokenKindAccess, "other", "app", ErrTokenInvalid},
+	{"other audience", entity.TokenKindAccess, false, 0, entity.TokenKindAccess, "api", "other", ErrTokenInvalid},
+}
+
+func TestValidateToken(t *testing.T) {
+	for _, testCase := range validateTokenTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			userID := uuid.New()
+			// the secrets are shared so that only the claims tell the kinds apart
+			secrets := AuthSecrets{TokenAccess: "1234", TokenVerify: "1234"}
+			enricher := WithClaimsEnricher(func(ctx context.Context, user entity.AuthUser) map[string]any {
+				return map[string]any{"tenant": "acme", "sub": "overridden"}
+			})
+			userStorage := WithUserStorage(inmem.NewUsers([]entity.AuthUser{{ID: userID, Email: "foo@bar.com"}}))
+
+			issuer := New(secrets, WithTokenIssuer("api"), WithTokenAudience("app"), userStorage, enricher)
+			claims, err := issuer.getAccessClaims(ctx, userID, uuid.Nil)
+			if err != nil {
+				t.Fatalf("expected: non error on the claims and got %v", err)
+			}
+
+			token, err := issuer.newKindToken(testCase.inKind, userID, claims)
+			if err != nil {
+				t.Fatalf("expected: non error on the token and got %v", err)
+			}
+
+			// the tokens were issued with the user as the issuer
+			if testCase.inLegacy {
+				legacy := tokenClaims{StandardClaims: jwt.StandardClaims{
+					Issuer:    userID.String(),
+					ExpiresAt: time.Now().Add(time.Minute).Unix(),
+				}}
+				token.Value, _ = jwt.NewWithClaims(jwt.SigningMethodHS256, &legacy).SignedString([]byte("1234"))
+			}
+
+			opts := []optFn{WithTokenIssuer(testCase.inIssuer), WithTokenAudience(testCase.inAudience)}
+			if testCase.inLegacyUntil != 0 {
+				opts = append(opts, WithLegacyTokens(time.Now().Add(testCase.inLegacyUntil)))
+			}
+			validator := New(secrets, opts...)
+			res, err := validator.ValidateToken(ctx, testCase.inValidateKind, token.Value)
+			if !errors.Is(err, testCase.expectErr) {
+				t.Fatalf("expected: %v and got %v", testCase.expectErr, err)
+			}
+
+			if testCase.expectErr != nil {
+				return
+			}
+
+			if res.UserID != userID || res.Kind != testCase.inValidateKind {
+				t.Fatalf("expected: the user %v and got %v", userID, res)
+			}
+
+			if testCase.inLegacy {
+				return
+			}
+
+			if res.Issuer != "api" || res.Audience != "app" || len(res.ID) == 0 ||
+				res.IssuedAt.IsZero() || res.NotBefore.IsZero() {
+				t.Fatalf("expected: the registered claims and got %+v", res)
+			}
+
+			if res.Custom["tenant"] != "acme" || res.Custom["sub"] != nil {
+				t.Fatalf("expected: only the custom claims that aren't reserved and got %v", res.Custom)
+			}
+		})
+	}
+}
+
+func TestForgedEmptySecretToken(t *testing.T) {
+	key, err := GenerateSigningKey(AlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("expected: non error generating the key and got %v", err)
+	}
+
+	tests := []struct {
+		description string
+		inSecrets   AuthSecrets
+		inOpts      []optFn
+	}{
+		{"without a secret", AuthSecrets{}, nil},
+		{"with a key provider", AuthSecrets{TokenAccess: "1234"}, []optFn{WithKeyProvider(NewKeyRing(key))}},
+		{"with a key provider and without a secret", AuthSecrets{}, []optFn{WithKeyProvider(NewKeyRing(key))}},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.description, func(t *testing.T) {
+			ctx := context.Background()
+			forged := tokenClaims{
+				StandardClaims: jwt.StandardClaims{
+					Subject:   uuid.NewString(),
+					ExpiresAt: time.Now().Add(time.Minute).Unix(),
+				},
+				Type:  entity.TokenKindAccess.String(),
+				Roles: []string{"admin"},
+			}
+			rawToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &forged).SignedString([]byte(""))
+			if err != nil {
+				t.Fatalf("expected: non error signing and got %v", err)
+			}
+
+			auth := New(testCase.inSecrets, testCase.inOpts...)
+			if _, err := auth.parseAccessTokenClaims(ctx, rawToken); err == nil {
+				t.Fatal("expected: the forged token to be refused")
+			}
+
+			if _, err := auth.ValidateToken(ctx, entity.TokenKindAccess, rawToken); err == nil {
+				t.Fatal("expected: the forged token to be refused on validate")
+			}
+		})
+	}
+}
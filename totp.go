@@ -0,0 +1,108 @@
+package goauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpDigits    = 6
+	totpPeriod    = 30 * time.Second
+	totpSkew      = 1
+	totpSecretLen = 20
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newTOTPSecret generates a random base32 encoded secret as expected by
+// the authenticator apps
+func newTOTPSecret() (string, error) {
+	raw := make([]byte, totpSecretLen)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(raw), nil
+}
+
+// generateTOTPCode generates the code for the time step of t (RFC 6238)
+func generateTOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/int64(totpPeriod.Seconds())))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation (RFC 4226)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, value%mod), nil
+}
+
+// validateTOTPCode checks the code against the current time step and the
+// adjacent ones to allow for some clock drift
+func validateTOTPCode(secret string, code string, t time.Time) bool {
+	_, ok := matchTOTPStep(secret, code, t)
+	return ok
+}
+
+// matchTOTPStep is validateTOTPCode returning the time step the code is of,
+// for the callers to refuse the steps already used
+func matchTOTPStep(secret string, code string, t time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	for i := -totpSkew; i <= totpSkew; i++ {
+		stepTime := t.Add(time.Duration(i) * totpPeriod)
+		expected, err := generateTOTPCode(secret, stepTime)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return stepTime.Unix() / int64(totpPeriod.Seconds()), true
+		}
+	}
+
+	return 0, false
+}
+
+// totpURI builds the otpauth:// uri to be rendered as a QR code
+func totpURI(issuer string, account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
@@ -0,0 +1,75 @@
+package goauth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 SHA1 test vectors truncated to 6 digits
+var generateTOTPCodeTests = []struct {
+	description string
+	inTime      int64
+	expected    string
+}{
+	{"time 59", 59, "287082"},
+	{"time 1111111109", 1111111109, "081804"},
+	{"time 1111111111", 1111111111, "050471"},
+	{"time 1234567890", 1234567890, "005924"},
+	{"time 2000000000", 2000000000, "279037"},
+}
+
+func TestGenerateTOTPCode(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	for _, testCase := range generateTOTPCodeTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			res, err := generateTOTPCode(secret, time.Unix(testCase.inTime, 0))
+			if err != nil {
+				t.Fatalf("expected: non error and got %v", err)
+			}
+
+			if res != testCase.expected {
+				t.Fatalf("expected: code=%v\ngot: %v", testCase.expected, res)
+			}
+		})
+	}
+}
+
+var validateTOTPCodeTests = []struct {
+	description string
+	inOffset    time.Duration
+	expected    bool
+}{
+	{"current step", 0, true},
+	{"previous step", -totpPeriod, true},
+	{"next step", totpPeriod, true},
+	{"too old", -3 * totpPeriod, false},
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	secret, _ := newTOTPSecret()
+	now := time.Now()
+
+	for _, testCase := range validateTOTPCodeTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			code, _ := generateTOTPCode(secret, now.Add(testCase.inOffset))
+
+			res := validateTOTPCode(secret, code, now)
+			if res != testCase.expected {
+				t.Fatalf("expected: result=%v\ngot: %v", testCase.expected, res)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	res := totpURI("goauth", "foo@bar.com", "ABCDEF")
+	if !strings.HasPrefix(res, "otpauth://totp/goauth:foo@bar.com?") {
+		t.Fatalf("expected: otpauth uri\ngot: %v", res)
+	}
+
+	if !strings.Contains(res, "secret=ABCDEF") {
+		t.Fatalf("expected: uri with the secret\ngot: %v", res)
+	}
+}
@@ -0,0 +1,639 @@
+package goauth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage"
+)
+
+var (
+	ErrPasskeyInvalid      = errors.New("passkey invalid")
+	ErrPasskeyConflict     = errors.New("passkey conflict")
+	ErrPasskeyOrigin       = errors.New("passkey origin mismatch")
+	ErrPasskeySignCount    = errors.New("passkey sign count mismatch, credential might be cloned")
+	ErrPasskeyUnsupported  = errors.New("passkey algorithm not supported")
+	ErrPasskeyUserPresence = errors.New("passkey user presence required")
+)
+
+const (
+	passkeyFlagUserPresent  = 0x01
+	passkeyFlagAttestedData = 0x40
+
+	passkeyTypeCreate = "webauthn.create"
+	passkeyTypeGet    = "webauthn.get"
+
+	coseAlgES256 = -7
+	coseAlgEdDSA = -8
+	coseAlgRS256 = -257
+)
+
+type PasskeyRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type PasskeyUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type PasskeyCredentialParam struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type PasskeyCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type PasskeyAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// PasskeyCreationOptions is to be passed as the publicKey of
+// navigator.credentials.create, binary values are base64url encoded
+type PasskeyCreationOptions struct {
+	Challenge              string                        `json:"challenge"`
+	RP                     PasskeyRelyingParty           `json:"rp"`
+	User                   PasskeyUser                   `json:"user"`
+	PubKeyCredParams       []PasskeyCredentialParam      `json:"pubKeyCredParams"`
+	Timeout                int64                         `json:"timeout"`
+	ExcludeCredentials     []PasskeyCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection PasskeyAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                        `json:"attestation"`
+}
+
+// PasskeyRequestOptions is to be passed as the publicKey of
+// navigator.credentials.get, binary values are base64url encoded
+type PasskeyRequestOptions struct {
+	Challenge        string                        `json:"challenge"`
+	RPID             string                        `json:"rpId"`
+	Timeout          int64                         `json:"timeout"`
+	AllowCredentials []PasskeyCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                        `json:"userVerification"`
+}
+
+type PasskeyAttestationResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"`
+}
+
+// PasskeyRegistration is the credential returned by navigator.credentials.create
+type PasskeyRegistration struct {
+	ID       string                     `json:"id"`
+	RawID    string                     `json:"rawId"`
+	Type     string                     `json:"type"`
+	Response PasskeyAttestationResponse `json:"response"`
+}
+
+type PasskeyAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
+
+// PasskeyAssertion is the credential returned by navigator.credentials.get
+type PasskeyAssertion struct {
+	ID       string                   `json:"id"`
+	RawID    string                   `json:"rawId"`
+	Type     string                   `json:"type"`
+	Response PasskeyAssertionResponse `json:"response"`
+}
+
+type passkeyClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type passkeyAuthData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+}
+
+// decodeBase64URL accepts base64url values with or without padding
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// getPasskeyRelyingParty resolves the relying party id and origin from the
+// base url
+func (auth Auth) getPasskeyRelyingParty() (string, string, error) {
+	u, err := url.Parse(auth.baseURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	return u.Hostname(), u.Scheme + "://" + u.Host, nil
+}
+
+// newPasskeyChallenge registers a challenge for the user, the challenge is
+// a token so that it carries its own expiration
+func (auth Auth) newPasskeyChallenge(ctx context.Context, userID uuid.UUID) (string, error) {
+	token, err := auth.newKindToken(entity.TokenKindPasskeyChallenge, userID, tokenClaims{})
+	if err != nil {
+		return "", err
+	}
+
+	err = auth.tokenStorage.CreateTokens(ctx, []entity.Token{token})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(token.Value)), nil
+}
+
+// verifyPasskeyClientData checks the client data against the ceremony and
+// returns the user of the challenge
+func (auth Auth) verifyPasskeyClientData(
+	ctx context.Context,
+	rawClientData []byte,
+	kind string,
+) (uuid.UUID, string, error) {
+	clientData := passkeyClientData{}
+	if err := json.Unmarshal(rawClientData, &clientData); err != nil {
+		return uuid.UUID{}, "", err
+	}
+
+	if clientData.Type != kind {
+		return uuid.UUID{}, "", ErrPasskeyInvalid
+	}
+
+	_, origin, err := auth.getPasskeyRelyingParty()
+	if err != nil {
+		return uuid.UUID{}, "", err
+	}
+
+	if clientData.Origin != origin {
+		return uuid.UUID{}, "", ErrPasskeyOrigin
+	}
+
+	rawChallenge, err := decodeBase64URL(clientData.Challenge)
+	if err != nil {
+		return uuid.UUID{}, "", err
+	}
+	challenge := string(rawChallenge)
+
+	ok, err := auth.tokenStorage.AreTokensRegistered(ctx, []string{challenge})
+	if err != nil {
+		return uuid.UUID{}, "", err
+	}
+	if !ok {
+		return uuid.UUID{}, "", ErrTokenNotRegistered
+	}
+
+	userID, err := auth.validateTokenOfKind(ctx, entity.TokenKindPasskeyChallenge, challenge)
+	if err != nil {
+		return uuid.UUID{}, "", err
+	}
+
+	return userID, challenge, nil
+}
+
+// parsePasskeyAuthData parses the authenticator data and checks it was
+// meant for the relying party
+func (auth Auth) parsePasskeyAuthData(raw []byte) (passkeyAuthData, error) {
+	data := passkeyAuthData{}
+	if len(raw) < 37 {
+		return data, ErrPasskeyInvalid
+	}
+
+	data.rpIDHash = raw[:32]
+	data.flags = raw[32]
+	data.signCount = binary.BigEndian.Uint32(raw[33:37])
+
+	rpID, _, err := auth.getPasskeyRelyingParty()
+	if err != nil {
+		return data, err
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	if !bytes.Equal(data.rpIDHash, rpIDHash[:]) {
+		return data, ErrPasskeyOrigin
+	}
+
+	if data.flags&passkeyFlagUserPresent == 0 {
+		return data, ErrPasskeyUserPresence
+	}
+
+	if data.flags&passkeyFlagAttestedData == 0 {
+		return data, nil
+	}
+
+	// attested credential data: aaguid (16) | id length (2) | id | cose key
+	rest := raw[37:]
+	if len(rest) < 18 {
+		return data, ErrPasskeyInvalid
+	}
+
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLen {
+		return data, ErrPasskeyInvalid
+	}
+	data.credentialID = rest[:idLen]
+	rest = rest[idLen:]
+
+	_, extensions, err := decodeCBOR(rest)
+	if err != nil {
+		return data, err
+	}
+	data.publicKey = rest[:len(rest)-len(extensions)]
+
+	return data, nil
+}
+
+// parsePasskeyPublicKey parses a COSE encoded key
+func parsePasskeyPublicKey(coseKey []byte) (crypto.PublicKey, error) {
+	rawKey, _, err := decodeCBOR(coseKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := rawKey.(map[any]any)
+	if !ok {
+		return nil, ErrPasskeyInvalid
+	}
+
+	alg, _ := key[int64(3)].(int64)
+	switch alg {
+	case coseAlgES256:
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if crv, _ := key[int64(-1)].(int64); crv != 1 || len(x) == 0 || len(y) == 0 {
+			return nil, ErrPasskeyUnsupported
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	case coseAlgEdDSA:
+		x, _ := key[int64(-2)].([]byte)
+		if crv, _ := key[int64(-1)].(int64); crv != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, ErrPasskeyUnsupported
+		}
+
+		return ed25519.PublicKey(x), nil
+	case coseAlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrPasskeyUnsupported
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	}
+
+	return nil, ErrPasskeyUnsupported
+}
+
+// verifyPasskeySignature verifies the signature with a COSE encoded key
+func verifyPasskeySignature(coseKey []byte, data []byte, signature []byte) error {
+	pub, err := parsePasskeyPublicKey(coseKey)
+	if err != nil {
+		return err
+	}
+
+	hashed := sha256.Sum256(data)
+	valid := false
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, hashed[:], signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature) == nil
+	}
+
+	if !valid {
+		return ErrPasskeyInvalid
+	}
+
+	return nil
+}
+
+// BeginPasskeyRegistration creates the options for the browser to create a
+// new passkey for the user
+func (auth Auth) BeginPasskeyRegistration(
+	ctx context.Context,
+	userID uuid.UUID,
+) (PasskeyCreationOptions, error) {
+	options := PasskeyCreationOptions{}
+
+	if auth.userStorage == nil || auth.tokenStorage == nil || auth.passkeyStorage == nil {
+		return options, ErrStorageRequired
+	}
+
+	user, err := auth.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return options, err
+	}
+
+	passkeys, err := auth.passkeyStorage.GetUserPasskeys(ctx, user.ID)
+	if err != nil {
+		return options, err
+	}
+
+	rpID, _, err := auth.getPasskeyRelyingParty()
+	if err != nil {
+		return options, err
+	}
+
+	challenge, err := auth.newPasskeyChallenge(ctx, user.ID)
+	if err != nil {
+		return options, err
+	}
+
+	options.Challenge = challenge
+	options.RP = PasskeyRelyingParty{ID: rpID, Name: auth.serviceName}
+	options.User = PasskeyUser{
+		ID:          base64.RawURLEncoding.EncodeToString(user.ID[:]),
+		Name:        user.Email,
+		DisplayName: user.Email,
+	}
+	options.PubKeyCredParams = []PasskeyCredentialParam{
+		{Type: "public-key", Alg: coseAlgES256},
+		{Type: "public-key", Alg: coseAlgEdDSA},
+		{Type: "public-key", Alg: coseAlgRS256},
+	}
+	options.Timeout = auth.tokenExpirationTimes.Passkey.Milliseconds()
+	options.ExcludeCredentials = make([]PasskeyCredentialDescriptor, len(passkeys))
+	for i, passkey := range passkeys {
+		options.ExcludeCredentials[i] = PasskeyCredentialDescriptor{
+			Type: "public-key",
+			ID:   base64.RawURLEncoding.EncodeToString(passkey.ID),
+		}
+	}
+	options.AuthenticatorSelection = PasskeyAuthenticatorSelection{
+		ResidentKey:      "preferred",
+		UserVerification: "preferred",
+	}
+	// attestation statements are not verified, as such, we ask for none
+	options.Attestation = "none"
+
+	return options, nil
+}
+
+// FinishPasskeyRegistration verifies the credential created by the browser
+// and registers it for the user
+func (auth Auth) FinishPasskeyRegistration(
+	ctx context.Context,
+	userID uuid.UUID,
+	registration PasskeyRegistration,
+) (err error) {
+	defer func() { err = auth.audit(ctx, AuditActionRegisterPasskey, userID, err) }()
+
+	if auth.tokenStorage == nil || auth.passkeyStorage == nil {
+		return ErrStorageRequired
+	}
+
+	rawClientData, err := decodeBase64URL(registration.Response.ClientDataJSON)
+	if err != nil {
+		return err
+	}
+
+	challengeUserID, challenge, err := auth.verifyPasskeyClientData(
+		ctx,
+		rawClientData,
+		passkeyTypeCreate,
+	)
+	if err != nil {
+		return err
+	}
+
+	if challengeUserID != userID {
+		return ErrWrongUser
+	}
+
+	rawAttestation, err := decodeBase64URL(registration.Response.AttestationObject)
+	if err != nil {
+		return err
+	}
+
+	decoded, _, err := decodeCBOR(rawAttestation)
+	if err != nil {
+		return err
+	}
+
+	attestation, ok := decoded.(map[any]any)
+	if !ok {
+		return ErrPasskeyInvalid
+	}
+
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return ErrPasskeyInvalid
+	}
+
+	authData, err := auth.parsePasskeyAuthData(rawAuthData)
+	if err != nil {
+		return err
+	}
+
+	if len(authData.credentialID) == 0 || len(authData.publicKey) == 0 {
+		return ErrPasskeyInvalid
+	}
+
+	// make sure the key is one we are able to verify later on
+	_, err = parsePasskeyPublicKey(authData.publicKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = auth.passkeyStorage.GetPasskey(ctx, authData.credentialID)
+	if err == nil {
+		return ErrPasskeyConflict
+	}
+	if !errors.Is(err, storage.ErrPasskeyNotFound) {
+		return err
+	}
+
+	err = auth.tokenStorage.RemoveUserToken(ctx, userID, challenge)
+	if err != nil {
+		return err
+	}
+
+	return auth.passkeyStorage.CreatePasskey(ctx, entity.Passkey{
+		ID:        authData.credentialID,
+		UserID:    userID,
+		PublicKey: authData.publicKey,
+		SignCount: authData.signCount,
+		CreatedAt: time.Now(),
+	})
+}
+
+// BeginPasskeyLogin creates the options for the browser to sign in with
+// one of the passkeys of the user
+func (auth Auth) BeginPasskeyLogin(
+	ctx context.Context,
+	email string,
+) (PasskeyRequestOptions, error) {
+	options := PasskeyRequestOptions{}
+
+	if auth.userStorage == nil || auth.tokenStorage == nil || auth.passkeyStorage == nil {
+		return options, ErrStorageRequired
+	}
+
+	user, err := auth.getUserByEmail(ctx, email)
+	if err != nil {
+		return options, err
+	}
+
+	passkeys, err := auth.passkeyStorage.GetUserPasskeys(ctx, user.ID)
+	if err != nil {
+		return options, err
+	}
+
+	if len(passkeys) == 0 {
+		return options, storage.ErrPasskeyNotFound
+	}
+
+	rpID, _, err := auth.getPasskeyRelyingParty()
+	if err != nil {
+		return options, err
+	}
+
+	challenge, err := auth.newPasskeyChallenge(ctx, user.ID)
+	if err != nil {
+		return options, err
+	}
+
+	options.Challenge = challenge
+	options.RPID = rpID
+	options.Timeout = auth.tokenExpirationTimes.Passkey.Milliseconds()
+	options.UserVerification = "preferred"
+	options.AllowCredentials = make([]PasskeyCredentialDescriptor, len(passkeys))
+	for i, passkey := range passkeys {
+		options.AllowCredentials[i] = PasskeyCredentialDescriptor{
+			Type: "public-key",
+			ID:   base64.RawURLEncoding.EncodeToString(passkey.ID),
+		}
+	}
+
+	return options, nil
+}
+
+// FinishPasskeyLogin verifies the assertion signed by the browser and
+// issues the access and refresh tokens
+func (auth Auth) FinishPasskeyLogin(
+	ctx context.Context,
+	assertion PasskeyAssertion,
+) (result signInResult, err error) {
+	var userID uuid.UUID
+	defer func() { err = auth.audit(ctx, AuditActionSignInPasskey, userID, err) }()
+
+	if auth.userStorage == nil || auth.tokenStorage == nil || auth.passkeyStorage == nil {
+		return result, ErrStorageRequired
+	}
+
+	credentialID, err := decodeBase64URL(assertion.RawID)
+	if err != nil {
+		return result, err
+	}
+
+	passkey, err := auth.passkeyStorage.GetPasskey(ctx, credentialID)
+	if err != nil {
+		return result, err
+	}
+	userID = passkey.UserID
+
+	rawClientData, err := decodeBase64URL(assertion.Response.ClientDataJSON)
+	if err != nil {
+		return result, err
+	}
+
+	challengeUserID, challenge, err := auth.verifyPasskeyClientData(
+		ctx,
+		rawClientData,
+		passkeyTypeGet,
+	)
+	if err != nil {
+		return result, err
+	}
+
+	if challengeUserID != passkey.UserID {
+		return result, ErrWrongUser
+	}
+
+	// the passkey stands for both factors, the user checks are the ones of
+	// the other sign ins
+	user, err := auth.userStorage.GetUserByID(ctx, passkey.UserID)
+	if err != nil {
+		return result, err
+	}
+
+	err = auth.checkUserSignIn(ctx, user)
+	if err != nil {
+		return result, err
+	}
+
+	rawAuthData, err := decodeBase64URL(assertion.Response.AuthenticatorData)
+	if err != nil {
+		return result, err
+	}
+
+	authData, err := auth.parsePasskeyAuthData(rawAuthData)
+	if err != nil {
+		return result, err
+	}
+
+	signature, err := decodeBase64URL(assertion.Response.Signature)
+	if err != nil {
+		return result, err
+	}
+
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	err = verifyPasskeySignature(passkey.PublicKey, signed, signature)
+	if err != nil {
+		return result, err
+	}
+
+	// authenticators without a counter always send 0, otherwise it should
+	// always increase or the credential might have been cloned
+	if (authData.signCount != 0 || passkey.SignCount != 0) &&
+		authData.signCount <= passkey.SignCount {
+		return result, ErrPasskeySignCount
+	}
+
+	err = auth.passkeyStorage.UpdatePasskeySignCount(ctx, passkey.ID, authData.signCount)
+	if err != nil {
+		return result, err
+	}
+
+	err = auth.tokenStorage.RemoveUserToken(ctx, passkey.UserID, challenge)
+	if err != nil {
+		return result, err
+	}
+
+	return auth.issueSignInTokens(ctx, passkey.UserID)
+}
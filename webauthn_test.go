@@ -0,0 +1,216 @@
+package goauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iamajoe/goauth/entity"
+	"github.com/iamajoe/goauth/storage/inmem"
+)
+
+// softAuthenticator builds attestation and assertion payloads the same way
+// a browser and a platform authenticator would
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+	rpID         string
+	origin       string
+}
+
+func newSoftAuthenticator(rpID string, origin string) *softAuthenticator {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	credentialID := make([]byte, 16)
+	_, _ = rand.Read(credentialID)
+
+	return &softAuthenticator{
+		key:          key,
+		credentialID: credentialID,
+		rpID:         rpID,
+		origin:       origin,
+	}
+}
+
+func (a *softAuthenticator) clientData(kind string, challenge string) []byte {
+	raw, _ := json.Marshal(passkeyClientData{
+		Type:      kind,
+		Challenge: challenge,
+		Origin:    a.origin,
+	})
+	return raw
+}
+
+func (a *softAuthenticator) authData(withCredential bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+
+	flags := byte(passkeyFlagUserPresent)
+	if withCredential {
+		flags |= passkeyFlagAttestedData
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+
+	if withCredential {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, encodeCBOR(map[any]any{
+			1:  2,
+			3:  coseAlgES256,
+			-1: 1,
+			-2: a.key.X.FillBytes(make([]byte, 32)),
+			-3: a.key.Y.FillBytes(make([]byte, 32)),
+		})...)
+	}
+
+	return data
+}
+
+func (a *softAuthenticator) register(challenge string) PasskeyRegistration {
+	attestation := encodeCBOR(map[any]any{
+		"fmt":      "none",
+		"attStmt":  map[any]any{},
+		"authData": a.authData(true),
+	})
+
+	return PasskeyRegistration{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		RawID: base64.RawURLEncoding.EncodeToString(a.credentialID),
+		Type:  "public-key",
+		Response: PasskeyAttestationResponse{
+			ClientDataJSON:    base64.RawURLEncoding.EncodeToString(a.clientData(passkeyTypeCreate, challenge)),
+			AttestationObject: base64.RawURLEncoding.EncodeToString(attestation),
+		},
+	}
+}
+
+func (a *softAuthenticator) login(challenge string) PasskeyAssertion {
+	a.signCount += 1
+	authData := a.authData(false)
+	clientData := a.clientData(passkeyTypeGet, challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	hashed := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, _ := ecdsa.SignASN1(rand.Reader, a.key, hashed[:])
+
+	return PasskeyAssertion{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		RawID: base64.RawURLEncoding.EncodeToString(a.credentialID),
+		Type:  "public-key",
+		Response: PasskeyAssertionResponse{
+			ClientDataJSON:    base64.RawURLEncoding.EncodeToString(clientData),
+			AuthenticatorData: base64.RawURLEncoding.EncodeToString(authData),
+			Signature:         base64.RawURLEncoding.EncodeToString(signature),
+		},
+	}
+}
+
+var passkeyTests = []struct {
+	description       string
+	inOrigin          string
+	inRepeatSignCount bool
+	inUnverified      bool
+	expectError       bool
+}{
+	{"success", "https://example.com", false, false, false},
+	{"wrong origin", "https://evil.com", false, false, true},
+	{"cloned authenticator", "https://example.com", true, false, true},
+	{"not verified", "https://example.com", false, true, true},
+}
+
+func TestPasskey(t *testing.T) {
+	for _, testCase := range passkeyTests {
+		t.Run(testCase.description, func(t *testing.T) {
+			email := "foo@bar.com"
+			userID := uuid.New()
+			tokenStore := inmem.NewTokens([]entity.Token{})
+			passkeyStore := inmem.NewPasskeys([]entity.Passkey{})
+			userStore := inmem.NewUsers([]entity.AuthUser{
+				{ID: userID, Email: email, Password: encryptPassword("12345678"), IsVerified: !testCase.inUnverified},
+			})
+			auth := New(
+				AuthSecrets{
+					TokenAccess:  "1234",
+					TokenRefresh: "2345",
+					TokenPasskey: "3456",
+				},
+				WithTokenStorage(tokenStore),
+				WithUserStorage(userStore),
+				WithPasskeyStorage(passkeyStore),
+				WithBaseURL("https://example.com"),
+			)
+			authenticator := newSoftAuthenticator("example.com", testCase.inOrigin)
+
+			creation, err := auth.BeginPasskeyRegistration(context.Background(), userID)
+			if err != nil {
+				t.Fatalf("expected: non error on begin registration and got %v", err)
+			}
+
+			err = auth.FinishPasskeyRegistration(
+				context.Background(),
+				userID,
+				authenticator.register(creation.Challenge),
+			)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error on finish registration and got %v", err)
+			}
+
+			request, err := auth.BeginPasskeyLogin(context.Background(), email)
+			if err != nil {
+				t.Fatalf("expected: non error on begin login and got %v", err)
+			}
+
+			if len(request.AllowCredentials) != 1 {
+				t.Fatalf("expected: allowed credentials=%v\ngot: %v", 1, len(request.AllowCredentials))
+			}
+
+			if testCase.inRepeatSignCount {
+				_, _ = auth.FinishPasskeyLogin(
+					context.Background(),
+					authenticator.login(request.Challenge),
+				)
+				request, _ = auth.BeginPasskeyLogin(context.Background(), email)
+				authenticator.signCount -= 1
+			}
+
+			res, err := auth.FinishPasskeyLogin(
+				context.Background(),
+				authenticator.login(request.Challenge),
+			)
+			if err != nil {
+				if testCase.expectError {
+					return
+				}
+				t.Fatalf("expected: non error on finish login and got %v", err)
+			}
+
+			if testCase.expectError {
+				t.Fatal("expected: error")
+			}
+
+			if res.UserID != userID || len(res.AccessToken) == 0 || len(res.RefreshToken) == 0 {
+				t.Fatal("expected: access and refresh tokens for the user")
+			}
+
+			// check if the challenge can't be reused
+			_, err = auth.FinishPasskeyLogin(
+				context.Background(),
+				authenticator.login(request.Challenge),
+			)
+			if err == nil {
+				t.Fatal("expected: challenge to have been removed")
+			}
+		})
+	}
+}